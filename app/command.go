@@ -0,0 +1,117 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"qora/fim"
+	"qora/unpack"
+	"strings"
+)
+
+// Command run the command line tool instead of service, like 'qora inspect -format json file.pak'
+// it returns the exit code, 0 for success, 1 for failure like anomalies or drift and 2 for usage error
+func Command(args []string) int {
+	switch args[0] {
+	case "inspect":
+		return inspect(args[1:])
+	case "fim":
+		return fimCommand(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %v\nUsage: qora inspect|fim ...\n", args[0])
+		return 2
+	}
+}
+
+// inspect print the inspect report of every package, it fails when any package has anomalies
+func inspect(args []string) int {
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format: text, json or yaml")
+	key := fs.String("key", "", "table key which open the sealed entry table")
+	if err := fs.Parse(args); err != nil || fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: qora inspect [-format text|json|yaml] [-key table-key] package...")
+		return 2
+	}
+	opt := unpack.UnpackOption{}
+	if *key != "" {
+		opt.TableKey = []byte(*key)
+	}
+	code := 0
+	for _, v := range fs.Args() {
+		r, err := unpack.InspectWithOption(v, opt)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to inspect %v: %s\n", v, err)
+			code = 1
+			continue
+		}
+		s, err := unpack.FormatInspect(r, *format)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		fmt.Print(s)
+		if len(r.Anomalies) > 0 {
+			code = 1
+		}
+	}
+	return code
+}
+
+// fimCommand record or check the file integrity baseline, like 'qora fim init -key-file key -out base.pak /usr/bin'
+// and 'qora fim check -key-file key -format json base.pak', check fails when any file drifts
+func fimCommand(args []string) int {
+	usage := "Usage: qora fim init [-key password | -key-file path] [-algorithm sha256] [-exclude pattern,...] -out baseline dir...\n" +
+		"       qora fim check [-key password | -key-file path] [-format text|json|yaml] baseline"
+	if len(args) == 0 || (args[0] != "init" && args[0] != "check") {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+	fs := flag.NewFlagSet("fim "+args[0], flag.ContinueOnError)
+	key := fs.String("key", "", "baseline password")
+	keyFile := fs.String("key-file", "", "file which contains baseline password")
+	out := fs.String("out", "", "baseline package path to write")
+	algorithm := fs.String("algorithm", "", "digest algorithm, default sha256")
+	exclude := fs.String("exclude", "", "comma separated file name patterns which are not recorded")
+	format := fs.String("format", "text", "output format: text, json or yaml")
+	if err := fs.Parse(args[1:]); err != nil || (*key == "") == (*keyFile == "") ||
+		(args[0] == "init" && (*out == "" || fs.NArg() == 0)) || (args[0] == "check" && fs.NArg() != 1) {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+	password := []byte(*key)
+	if *keyFile != "" {
+		data, err := ioutil.ReadFile(*keyFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Failed to read key file:", err)
+			return 1
+		}
+		password = []byte(strings.TrimRight(string(data), "\r\n"))
+	}
+	if args[0] == "init" {
+		opt := fim.FimOption{Algorithm: *algorithm}
+		if *exclude != "" {
+			opt.Exclude = strings.Split(*exclude, ",")
+		}
+		if err := fim.Init(fs.Args(), *out, password, opt); err != nil {
+			fmt.Fprintln(os.Stderr, "Failed to record baseline:", err)
+			return 1
+		}
+		return 0
+	}
+	r, err := fim.Check(fs.Arg(0), password)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to check baseline:", err)
+		return 1
+	}
+	s, err := fim.FormatReport(r, *format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	fmt.Print(s)
+	if r.Drift {
+		return 1
+	}
+	return 0
+}
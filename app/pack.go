@@ -1 +1,99 @@
 package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"qora/pack"
+)
+
+// hashEncode handle 'POST /qora/v1/hash/encode', it encodes src by algorithm, key is used by keyed algorithm
+func hashEncode(c *gin.Context) {
+	var req HashRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var dest string
+	var err error
+	if req.Key != "" {
+		dest, err = pack.PackHashEncodeWithKey(req.Src, req.Key, req.Algorithm)
+	} else {
+		dest, err = pack.PackHashEncode(req.Src, req.Algorithm)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"algorithm": req.Algorithm, "dest": dest})
+}
+
+// hashCheck handle 'POST /qora/v1/hash/check', it checks src with dest by algorithm, key is used by keyed algorithm
+func hashCheck(c *gin.Context) {
+	var req HashRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var b bool
+	var err error
+	if req.Key != "" {
+		b, err = pack.PackHashCheckWithKey(req.Src, req.Dest, req.Key, req.Algorithm)
+	} else {
+		b, err = pack.PackHashCheck(req.Src, req.Dest, req.Algorithm)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"algorithm": req.Algorithm, "match": b})
+}
+
+// hashCompare handle 'POST /qora/v1/hash/compare', it compares two fuzzy hashes src and dest,
+// score is the similarity from 0 to 100
+func hashCompare(c *gin.Context) {
+	var req HashRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	score, err := pack.CTPHCompare(req.Src, req.Dest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"score": score})
+}
+
+// passwordHash handle 'POST /qora/v1/password/hash', it hashes password by algorithm into PHC string,
+// params are always the default of server, caller could not choose the cost
+func passwordHash(c *gin.Context) {
+	var req PasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	encoded, err := pack.HashPassword(req.Algorithm, req.Password, pack.PasswordParams{})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"encoded": encoded})
+}
+
+// passwordVerify handle 'POST /qora/v1/password/verify', it verifies password with encoded hash,
+// and tells whether encoded hash needs rehash with the default params of server,
+// encoded hash whose cost is over the default is refused before anything is derived
+func passwordVerify(c *gin.Context) {
+	var req PasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	b, err := pack.VerifyPasswordWithLimit(req.Encoded, req.Password, pack.PasswordParams{})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rehash, _ := pack.NeedsRehash(req.Encoded, pack.PasswordParams{})
+	c.JSON(http.StatusOK, gin.H{"match": b, "needs_rehash": rehash})
+}
@@ -45,6 +45,11 @@ func (qora *Qora) Start() {
 	qoraService := router.Group("qora/v1")
 	{
 		qoraService.GET("/test", func(c *gin.Context) { c.String(http.StatusOK, "hello Qora\n") })
+		qoraService.POST("/hash/encode", hashEncode)
+		qoraService.POST("/hash/check", hashCheck)
+		qoraService.POST("/hash/compare", hashCompare)
+		qoraService.POST("/password/hash", passwordHash)
+		qoraService.POST("/password/verify", passwordVerify)
 	}
 	// enable tls settings
 	var tlsConfig *tls.Config
@@ -10,3 +10,16 @@ type UnpackFile struct {
 	SrcFile  string `json:"src_file" yaml:"src_file" xml:"src_file"`
 	DestFile string `json:"dest_file" yaml:"dest_file" xml:"dest_file"`
 }
+
+type HashRequest struct {
+	Src       string `json:"src" yaml:"src" xml:"src"`
+	Dest      string `json:"dest" yaml:"dest" xml:"dest"`
+	Key       string `json:"key" yaml:"key" xml:"key"`
+	Algorithm string `json:"algorithm" yaml:"algorithm" xml:"algorithm"`
+}
+
+type PasswordRequest struct {
+	Algorithm string `json:"algorithm" yaml:"algorithm" xml:"algorithm"`
+	Password  string `json:"password" yaml:"password" xml:"password"`
+	Encoded   string `json:"encoded" yaml:"encoded" xml:"encoded"`
+}
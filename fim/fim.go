@@ -0,0 +1,379 @@
+package fim
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/scrypt"
+	"gopkg.in/yaml.v3"
+	"log"
+	"os"
+	"path/filepath"
+	. "qora/global"
+	"qora/pack"
+	"qora/unpack"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Init function
+// This function is mainly used for record the file integrity baseline of directories, like the build hosts for compliance.
+// input monitored directories, output baseline package path, password and option, return error info
+// path, size, mode, modify time and digest of every regular file and symbolic link under roots are recorded,
+// symbolic link is not followed, its digest is of the link target, other special files are skipped
+// the records are packed as 'AESGCM' package with sealed entry table, so only password holders could read it,
+// and signed by hmac-sha256, metadata is covered by keyed digest, tampered baseline fails to load, see Load
+// keys are derived from password by scrypt, salt and cost parameter are kept in metadata labels
+// the baseline package itself and its parity, lock and temporary files are skipped when it is under roots
+// compare the directories with baseline by Check
+// return err indicate the success or failure function execute
+func Init(roots []string, dest string, password []byte, opt FimOption) (err error) {
+	// first, check the option
+	if len(password) == 0 {
+		err = errors.New("Baseline password is empty.")
+		return err
+	}
+	b := TFimBaseline{Version: FimVersion, Created: time.Now(), Algorithm: strings.ToLower(opt.Algorithm), Exclude: opt.Exclude}
+	if b.Algorithm == "" {
+		b.Algorithm = FimAlgorithm
+	}
+	_, err = pack.HashReader(bytes.NewReader(nil), b.Algorithm)
+	if err != nil {
+		return err
+	}
+	for _, v := range opt.Exclude {
+		if _, err := filepath.Match(v, ""); err != nil {
+			s := fmt.Sprintf("Invalid exclude pattern: %v", v)
+			err = errors.New(s)
+			return err
+		}
+	}
+	n := FimScryptN
+	if opt.ScryptN > 0 {
+		n = opt.ScryptN
+	}
+	b.Host, _ = os.Hostname()
+	for _, v := range roots {
+		root, err := filepath.Abs(v)
+		if err != nil {
+			log.Println("Error get absolute path:", err)
+			return err
+		}
+		if _, err = os.Stat(root); err != nil {
+			log.Println("Error stat root:", err)
+			return err
+		}
+		b.Roots = append(b.Roots, root)
+	}
+	if len(b.Roots) == 0 {
+		err = errors.New("Baseline has no directory to monitor.")
+		return err
+	}
+	// second, record the files
+	var failed map[string]string
+	b.Files, failed = scan(b.Roots, b.Exclude, b.Algorithm, dest)
+	if len(failed) > 0 {
+		s := fmt.Sprintf("%v files could not be read: %v", len(failed), unreadable(failed)[0])
+		err = errors.New(s)
+		return err
+	}
+	// third, derive the keys and sign the baseline
+	salt := make([]byte, 32)
+	_, err = rand.Read(salt)
+	if err != nil {
+		log.Println("Error generate random salt:", err)
+		return err
+	}
+	tableKey, signKey, err := deriveKey(password, salt, n)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	err = pack.GobEncode(&buf, b)
+	if err != nil {
+		return err
+	}
+	sig, err := pack.HMAC(FimAlgorithm, signKey, buf.Bytes())
+	if err != nil {
+		return err
+	}
+	// finally, write the baseline package
+	entries := []pack.Entry{
+		{Name: FimBaseline, Bytes: buf.Bytes(), Mode: 0600, ModTime: b.Created},
+		{Name: FimSignature, Bytes: sig, Mode: 0600, ModTime: b.Created},
+	}
+	meta := pack.TPackMeta{Creator: FimCreator, Created: b.Created, Labels: map[string]string{FimLabelSalt: hex.EncodeToString(salt), FimLabelN: strconv.Itoa(n)}}
+	return pack.PackEntriesWithOption(entries, dest, GCMType, pack.PackOption{TableKey: tableKey, Meta: meta, MetaDigest: true})
+}
+
+// Load function
+// This function is mainly used for read the baseline which is recorded by Init.
+// input baseline package path and password, output the baseline
+// the package signature, metadata digest and every entry are verified,
+// wrong password, tampered or replaced baseline fails here
+// return err indicate the success or failure function execute
+func Load(src string, password []byte) (b *TFimBaseline, err error) {
+	// first, read the key parameters from metadata
+	meta, err := unpack.ExtractMeta(src)
+	if err != nil {
+		return b, err
+	}
+	if meta == nil || meta.Creator != FimCreator {
+		s := fmt.Sprintf("Package is not file integrity baseline: %v", src)
+		err = errors.New(s)
+		return b, err
+	}
+	salt, err := hex.DecodeString(meta.Labels[FimLabelSalt])
+	n, e := strconv.Atoi(meta.Labels[FimLabelN])
+	if err != nil || e != nil || len(salt) == 0 || n < 2 || n > FimScryptMax || n&(n-1) != 0 {
+		s := fmt.Sprintf("Baseline key parameters are invalid: %v", src)
+		err = errors.New(s)
+		return b, err
+	}
+	tableKey, signKey, err := deriveKey(password, salt, n)
+	if err != nil {
+		return b, err
+	}
+	// second, open the package and check the signature
+	p, err := unpack.ParsePackageWithOption(src, unpack.UnpackOption{TableKey: tableKey})
+	if err != nil {
+		s := fmt.Sprintf("Baseline could not be opened, password is wrong or baseline is tampered: %v", src)
+		err = errors.New(s)
+		return b, err
+	}
+	if p.Meta == nil || !p.Meta.Verified {
+		s := fmt.Sprintf("Baseline metadata is tampered: %v", src)
+		err = errors.New(s)
+		return b, err
+	}
+	data := make(map[string][]byte, len(p.Entries))
+	for _, v := range p.Entries {
+		data[v.Name], err = unpack.DecryptEntry(v)
+		if err != nil {
+			return b, err
+		}
+	}
+	ok, err := pack.HMACVerify(FimAlgorithm, signKey, data[FimBaseline], data[FimSignature])
+	if err != nil || !ok {
+		s := fmt.Sprintf("Baseline signature mismatch: %v", src)
+		err = errors.New(s)
+		return b, err
+	}
+	// finally, decode the baseline
+	b = &TFimBaseline{}
+	err = unpack.GobDecode(bytes.NewBuffer(data[FimBaseline]), b)
+	if err != nil {
+		return nil, err
+	}
+	if b.Version != FimVersion {
+		s := fmt.Sprintf("Undefined baseline version: %v", b.Version)
+		err = errors.New(s)
+		return nil, err
+	}
+	return b, err
+}
+
+// Check function
+// This function is mainly used for compare the monitored directories with baseline, like the daily compliance job.
+// input baseline package path and password, output the check report
+// the directories recorded in baseline are scanned again with the same algorithm and exclude patterns,
+// files are reported as added, removed or modified(size, mode, mtime or digest), the files or directories
+// which could not be read are reported as unreadable, r.Drift is true when any of them is not empty
+// drift is not an error, err is returned only when baseline could not be loaded
+// format the report by FormatReport
+// return err indicate the success or failure function execute
+func Check(src string, password []byte) (r *TFimReport, err error) {
+	b, err := Load(src, password)
+	if err != nil {
+		return r, err
+	}
+	r = &TFimReport{Baseline: src, Created: b.Created, Checked: time.Now(), Host: b.Host, Algorithm: b.Algorithm, Roots: b.Roots}
+	files, failed := scan(b.Roots, b.Exclude, b.Algorithm, src)
+	r.Files = len(files)
+	r.Unreadable = unreadable(failed)
+	current := make(map[string]TFimFile, len(files))
+	for _, v := range files {
+		current[v.Path] = v
+	}
+	for _, v := range b.Files {
+		f, ok := current[v.Path]
+		delete(current, v.Path)
+		if _, bad := failed[v.Path]; !ok && !bad {
+			r.Removed = append(r.Removed, v.Path)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		var fields []string
+		if f.Size != v.Size {
+			fields = append(fields, "size")
+		}
+		if f.Mode != v.Mode {
+			fields = append(fields, "mode")
+		}
+		if !f.ModTime.Equal(v.ModTime) {
+			fields = append(fields, "mtime")
+		}
+		if f.Digest != v.Digest {
+			fields = append(fields, "digest")
+		}
+		if len(fields) > 0 {
+			r.Modified = append(r.Modified, TFimChange{Path: v.Path, Fields: fields, Before: v, After: f})
+		}
+	}
+	for _, v := range files {
+		if _, ok := current[v.Path]; ok {
+			r.Added = append(r.Added, v.Path)
+		}
+	}
+	r.Drift = len(r.Added) > 0 || len(r.Removed) > 0 || len(r.Modified) > 0 || len(r.Unreadable) > 0
+	return r, err
+}
+
+// FormatReport function
+// This function is mainly used for print the check report.
+// format now support 'text', 'json' and 'yaml', empty format is 'text' which is for human
+// return s the formatted report, err indicate the success or failure function execute
+func FormatReport(r *TFimReport, format string) (s string, err error) {
+	switch strings.ToLower(format) {
+	case "", "text":
+	case "json":
+		b, err := json.MarshalIndent(r, "", "  ")
+		return string(b) + "\n", err
+	case "yaml":
+		b, err := yaml.Marshal(r)
+		return string(b), err
+	default:
+		s := fmt.Sprintf("Undefined report format: %v", format)
+		err = errors.New(s)
+		return "", err
+	}
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Baseline:\t%v, created %v on %v\n", r.Baseline, r.Created.Format("2006-01-02 15:04:05 MST"), r.Host)
+	fmt.Fprintf(w, "Checked:\t%v, %v files by %v\n", r.Checked.Format("2006-01-02 15:04:05 MST"), r.Files, r.Algorithm)
+	fmt.Fprintf(w, "Roots:\t%v\n", strings.Join(r.Roots, ", "))
+	fmt.Fprintf(w, "Drift:\t%v added, %v removed, %v modified, %v unreadable\n", len(r.Added), len(r.Removed), len(r.Modified), len(r.Unreadable))
+	_ = w.Flush()
+	for _, v := range r.Added {
+		fmt.Fprintf(&buf, "A  %v\n", v)
+	}
+	for _, v := range r.Removed {
+		fmt.Fprintf(&buf, "D  %v\n", v)
+	}
+	for _, v := range r.Modified {
+		fmt.Fprintf(&buf, "M  %v (%v)\n", v.Path, strings.Join(v.Fields, ", "))
+	}
+	for _, v := range r.Unreadable {
+		fmt.Fprintf(&buf, "U  %v\n", v)
+	}
+	return buf.String(), err
+}
+
+// deriveKey function
+// it derives the aes table key and hmac signature key from password
+func deriveKey(password []byte, salt []byte, n int) (tableKey []byte, signKey []byte, err error) {
+	key, err := scrypt.Key(password, salt, n, RepoScryptR, RepoScryptP, 64)
+	if err != nil {
+		log.Println("Error derive baseline key:", err)
+		return tableKey, signKey, err
+	}
+	return key[0:32], key[32:64], err
+}
+
+// scan function
+// it records the files under roots sorted by path, failed records the paths which could not be read with reason,
+// skip is the baseline package which is not recorded
+func scan(roots []string, exclude []string, algorithm string, skip string) (files []TFimFile, failed map[string]string) {
+	failed = make(map[string]string)
+	skip, _ = filepath.Abs(skip)
+	seen := make(map[string]bool)
+	for _, root := range roots {
+		_ = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				failed[path] = err.Error()
+				return nil
+			}
+			if path != root && excluded(info.Name(), exclude) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.IsDir() || seen[path] {
+				return nil
+			}
+			for _, v := range []string{"", ParitySuffix, ".lock", ".tmp"} {
+				if path == skip+v {
+					return nil
+				}
+			}
+			seen[path] = true
+			f := TFimFile{Path: path, Size: info.Size(), Mode: info.Mode(), ModTime: info.ModTime()}
+			if info.Mode()&os.ModeSymlink != 0 {
+				target, err := os.Readlink(path)
+				if err != nil {
+					failed[path] = err.Error()
+					return nil
+				}
+				h, _ := pack.HashReader(strings.NewReader(target), algorithm)
+				f.Digest = h[algorithm]
+			} else if !info.Mode().IsRegular() {
+				return nil
+			}
+			files = append(files, f)
+			return nil
+		})
+	}
+	// hash the regular files through goroutine
+	wg := &sync.WaitGroup{}
+	ch := make(chan interface{}, ConfineFiles)
+	errs := make([]error, len(files))
+	for k, v := range files {
+		if v.Mode.IsRegular() {
+			wg.Add(1)
+			ch <- struct{}{}
+			go pack.HashFileConfineGo(v.Path, algorithm, &files[k].Digest, &errs[k], wg, ch)
+		}
+	}
+	wg.Wait()
+	r := files[:0]
+	for k, v := range files {
+		if errs[k] != nil {
+			failed[v.Path] = errs[k].Error()
+			continue
+		}
+		r = append(r, v)
+	}
+	sort.Slice(r, func(i, j int) bool { return r[i].Path < r[j].Path })
+	return r, failed
+}
+
+// excluded function
+// return whether the file name matches any exclude pattern
+func excluded(name string, exclude []string) bool {
+	for _, v := range exclude {
+		if ok, _ := filepath.Match(v, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// unreadable function
+// return the sorted 'path: reason' list of failed paths
+func unreadable(failed map[string]string) (r []string) {
+	for k, v := range failed {
+		r = append(r, fmt.Sprintf("%v: %v", k, v))
+	}
+	sort.Strings(r)
+	return r
+}
@@ -0,0 +1,57 @@
+package fim
+
+import (
+	"os"
+	"time"
+)
+
+// file integrity option
+type FimOption struct {
+	Algorithm string   // digest algorithm, see pack.HashFile, empty use FimAlgorithm
+	Exclude   []string // file name patterns which are not recorded, matched with base name by filepath.Match
+	ScryptN   int      // scrypt cost parameter N, 0 use FimScryptN
+}
+
+// file integrity baseline, gob encoded and stored as FimBaseline entry of baseline package
+type TFimBaseline struct {
+	Version   int        // baseline format version
+	Created   time.Time  // baseline create time
+	Host      string     // host name where baseline is created
+	Algorithm string     // digest algorithm
+	Roots     []string   // absolute paths of monitored directories
+	Exclude   []string   // file name patterns which are not recorded
+	Files     []TFimFile // recorded files, sorted by path
+}
+
+// file integrity record of one file
+type TFimFile struct {
+	Path    string      `json:"path" yaml:"path"`     // absolute path
+	Size    int64       `json:"size" yaml:"size"`     // file size
+	Mode    os.FileMode `json:"mode" yaml:"mode"`     // file type and permission bits
+	ModTime time.Time   `json:"mtime" yaml:"mtime"`   // modify time
+	Digest  string      `json:"digest" yaml:"digest"` // hex digest of content, symbolic link digest is of its target
+}
+
+// file integrity change of one file
+type TFimChange struct {
+	Path   string   `json:"path" yaml:"path"`     // absolute path
+	Fields []string `json:"fields" yaml:"fields"` // changed fields: size, mode, mtime and digest
+	Before TFimFile `json:"before" yaml:"before"` // record in baseline
+	After  TFimFile `json:"after" yaml:"after"`   // record of current file
+}
+
+// file integrity check report
+type TFimReport struct {
+	Baseline   string       `json:"baseline" yaml:"baseline"`     // baseline package path
+	Created    time.Time    `json:"created" yaml:"created"`       // baseline create time
+	Checked    time.Time    `json:"checked" yaml:"checked"`       // check time
+	Host       string       `json:"host" yaml:"host"`             // host name where baseline is created
+	Algorithm  string       `json:"algorithm" yaml:"algorithm"`   // digest algorithm
+	Roots      []string     `json:"roots" yaml:"roots"`           // monitored directories
+	Files      int          `json:"files" yaml:"files"`           // current file number
+	Added      []string     `json:"added" yaml:"added"`           // files which are not in baseline
+	Removed    []string     `json:"removed" yaml:"removed"`       // files of baseline which are gone
+	Modified   []TFimChange `json:"modified" yaml:"modified"`     // files which are changed
+	Unreadable []string     `json:"unreadable" yaml:"unreadable"` // files or directories which could not be read, with reason
+	Drift      bool         `json:"drift" yaml:"drift"`           // any file is added, removed, modified or unreadable
+}
@@ -0,0 +1,91 @@
+package fim
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestFim function
+func TestFim(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	_ = os.MkdirAll(filepath.Join(root, "bin"), 0755)
+	_ = os.MkdirAll(filepath.Join(root, "cache"), 0755)
+	_ = ioutil.WriteFile(filepath.Join(root, "bin", "tool"), []byte("tool v1"), 0755)
+	_ = ioutil.WriteFile(filepath.Join(root, "config.yml"), []byte("port: 80"), 0644)
+	_ = ioutil.WriteFile(filepath.Join(root, "old.txt"), []byte("old"), 0644)
+	_ = ioutil.WriteFile(filepath.Join(root, "cache", "a.tmp"), []byte("cache"), 0644)
+	_ = ioutil.WriteFile(filepath.Join(root, "build.log"), []byte("log"), 0644)
+	_ = os.Symlink("bin/tool", filepath.Join(root, "link"))
+	password := []byte("Satellite-266414")
+	// the baseline is under root and is skipped
+	baseline := filepath.Join(root, "baseline.pak")
+	opt := FimOption{Exclude: []string{"cache", "*.log"}, ScryptN: 1024}
+	err := Init([]string{root}, baseline, password, opt)
+	if err != nil {
+		t.Fatal("Error Init:", err)
+	}
+	b, err := Load(baseline, password)
+	if err != nil || len(b.Files) != 4 || b.Algorithm != "sha256" {
+		t.Fatal("Error Load:", b, err)
+	}
+	r, err := Check(baseline, password)
+	if err != nil || r.Drift || r.Files != 4 {
+		t.Fatal("Error Check clean:", r, err)
+	}
+	_, err = Check(baseline, []byte("Satellite-000000"))
+	if err == nil {
+		t.Fatal("Error Check: wrong password should fail")
+	}
+	// add, remove, modify content with the same size and time, change mode, and retarget link
+	st, _ := os.Stat(filepath.Join(root, "bin", "tool"))
+	_ = ioutil.WriteFile(filepath.Join(root, "bin", "tool"), []byte("tool v2"), 0755)
+	_ = os.Chtimes(filepath.Join(root, "bin", "tool"), st.ModTime(), st.ModTime())
+	_ = os.Chmod(filepath.Join(root, "config.yml"), 0666)
+	_ = os.Remove(filepath.Join(root, "old.txt"))
+	_ = ioutil.WriteFile(filepath.Join(root, "new.txt"), []byte("new"), 0644)
+	_ = ioutil.WriteFile(filepath.Join(root, "cache", "b.tmp"), []byte("cache"), 0644)
+	_ = os.Remove(filepath.Join(root, "link"))
+	_ = os.Symlink("config.yml", filepath.Join(root, "link"))
+	r, err = Check(baseline, password)
+	if err != nil || !r.Drift || len(r.Added) != 1 || len(r.Removed) != 1 || len(r.Modified) != 3 || len(r.Unreadable) != 0 {
+		t.Fatal("Error Check drift:", r, err)
+	}
+	if !strings.HasSuffix(r.Added[0], "new.txt") || !strings.HasSuffix(r.Removed[0], "old.txt") {
+		t.Fatal("Error Check added or removed:", r.Added, r.Removed)
+	}
+	fields := make(map[string]string)
+	for _, v := range r.Modified {
+		fields[filepath.Base(v.Path)] = strings.Join(v.Fields, ",")
+	}
+	if fields["tool"] != "digest" || fields["config.yml"] != "mode" || !strings.Contains(fields["link"], "digest") {
+		t.Fatal("Error Check modified:", fields)
+	}
+	for _, format := range []string{"text", "json", "yaml"} {
+		s, err := FormatReport(r, format)
+		if err != nil || !strings.Contains(s, "new.txt") {
+			t.Fatal("Error Format Report:", format, err)
+		}
+		var rr TFimReport
+		if format == "json" && (json.Unmarshal([]byte(s), &rr) != nil || !rr.Drift || len(rr.Modified) != 3) {
+			t.Fatal("Error Format Report decode:", rr)
+		}
+	}
+	// tampered baseline fails to load
+	data, _ := ioutil.ReadFile(baseline)
+	data[len(data)-1] ^= 0x01
+	tamper := filepath.Join(dir, "tamper.pak")
+	_ = ioutil.WriteFile(tamper, data, 0644)
+	_, err = Load(tamper, password)
+	if err == nil {
+		t.Fatal("Error Load: tampered baseline should fail")
+	}
+	err = Init([]string{filepath.Join(dir, "missing")}, filepath.Join(dir, "missing.pak"), password, opt)
+	if err == nil {
+		t.Fatal("Error Init: missing root should fail")
+	}
+}
@@ -1,11 +1,136 @@
 package global
 
+const (
+	QoraName    = "Qora"       // Tool name recorded in package metadata
+	QoraVersion = "1.1.0"      // Tool version recorded in package metadata
+	PackAuthor  = "Alopex6414" // Default package author written in header
+)
+
 const (
 	AESBufferSize    = 128  // AES buffer size should be 128, 256, ...
 	DESBufferSize    = 128  // DES buffer size should be 128, 256, ...
 	RSAPacketSize    = 64   // RSA buffer size should less than 128(Packet)
 	RSAUnpackSize    = 128  // RSA buffer size(Unpack)
 	Base64BufferSize = 128  // Base64 buffer size
+	Base64UnpackSize = 172  // Base64 buffer size(Unpack), encoded length of Base64BufferSize
 	ConfineFiles     = 5    // Confine go-routine concurrent files
 	ConfineBuffers   = 8192 // Confine go-routine concurrent buffers
 )
+
+const (
+	LockRetryTimes    = 100 // Package lock retry times before give up
+	LockRetryInterval = 50  // Package lock retry interval(millisecond)
+)
+
+const (
+	PackVersionOffset = 55 // Package format version byte, the last byte of header type field
+	PackVersion2      = 2  // Package format v2, v1 package keeps zero in version byte
+)
+
+const (
+	MixedType = "MIXED" // Mixed package type, every entry records its own algorithm in 8 bytes behind entry name
+)
+
+const (
+	GCMType    = "AESGCM" // AES-GCM entry type, every entry has its own aes-256 key which is used once, so nonce is zero
+	GCMTagSize = 16       // AES-GCM authentication tag size behind entry cipher text
+)
+
+const (
+	VolumeMagic    = "QVOL" // Volume part magic, the first 4 bytes of every part
+	VolumeHeadSize = 96     // Volume part header size
+)
+
+const (
+	ParityMagic      = "QPAR"  // Parity file magic
+	ParitySuffix     = ".qpar" // Parity file suffix, parity file is kept beside the package
+	ParityShardSize  = 4096    // Parity shard size, damage is detected and repaired by shard
+	ParityStripeSize = 128     // Parity stripe size, data shards in one stripe share the parity shards
+)
+
+const (
+	SalvageReportName = "salvage_report.txt" // Salvage report file name, written into dest path with recovered entries
+)
+
+const (
+	RepoMagic     = "QREPO"   // Repository config magic, sealed by master key to check password
+	RepoChunkMin  = 256 << 10 // Repository chunk min size
+	RepoChunkBits = 20        // Repository chunk average size is 1<<RepoChunkBits
+	RepoChunkMax  = 4 << 20   // Repository chunk max size
+	RepoScryptN   = 1 << 15   // Repository scrypt cost parameter N
+	RepoScryptR   = 8         // Repository scrypt block size parameter r
+	RepoScryptP   = 1         // Repository scrypt parallelization parameter p
+)
+
+const (
+	PatchMagic     = "QPAT" // Patch file magic
+	PatchBlockSize = 16     // Patch delta block size, matches shorter than it are not searched
+)
+
+const (
+	HashBufferSize = 64 << 10 // Hash stream buffer size, data is read and hashed by fixed buffer
+	ShakeMaxSize   = 1024     // Shake max output bytes
+)
+
+const (
+	MerkleChunkSize = 1 << 20 // Merkle tree default chunk size, every chunk is one leaf
+	MerkleLeafByte  = 0x00    // Merkle leaf hash prefix, leaf and node are hashed in different domain
+	MerkleNodeByte  = 0x01    // Merkle node hash prefix
+)
+
+const (
+	FimVersion   = 1              // File integrity baseline format version
+	FimCreator   = "qora fim"     // File integrity baseline package creator
+	FimBaseline  = "baseline.gob" // File integrity baseline entry name, gob encoded records
+	FimSignature = "baseline.sig" // File integrity baseline signature entry name, hmac-sha256 of baseline entry
+	FimAlgorithm = "sha256"       // File integrity default digest algorithm
+	FimScryptN   = 1 << 15        // File integrity scrypt cost parameter N, keys are derived from password
+	FimScryptMax = 1 << 20        // File integrity scrypt max cost parameter N which is accepted from baseline
+	FimLabelSalt = "fim-salt"     // File integrity metadata label of scrypt salt
+	FimLabelN    = "fim-scrypt-n" // File integrity metadata label of scrypt cost parameter N
+)
+
+const (
+	PasswordArgon2Time    = 3        // Password argon2id default iterations
+	PasswordArgon2Memory  = 64 << 10 // Password argon2id default memory in KiB
+	PasswordArgon2Threads = 4        // Password argon2id default parallelism
+	PasswordScryptN       = 1 << 15  // Password scrypt default cost parameter N
+	PasswordScryptR       = 8        // Password scrypt default block size parameter r
+	PasswordScryptP       = 1        // Password scrypt default parallelization parameter p
+	PasswordBcryptCost    = 12       // Password bcrypt default cost
+	PasswordSaltSize      = 16       // Password default salt size
+	PasswordKeySize       = 32       // Password default hash size of argon2id and scrypt
+	PasswordMinSaltSize   = 8        // Password min salt size
+	PasswordMinKeySize    = 16       // Password min hash size
+	PasswordMaxMemory     = 1 << 30  // Password max memory in bytes of argon2id and scrypt
+	PasswordMaxTime       = 64       // Password max argon2id iterations
+)
+
+const (
+	KDFKeySize       = 32       // KDF default output size
+	KDFMinKeySize    = 16       // KDF min output size
+	KDFMaxKeySize    = 1024     // KDF max output size of pbkdf2, scrypt and argon2, hkdf is limited to 255 hash size
+	KDFMinSecretSize = 16       // KDF min hkdf input secret size, password of other kdf is not limited
+	KDFMinSaltSize   = 16       // KDF min salt size of pbkdf2, scrypt and argon2
+	KDFHash          = "sha256" // KDF default hash of hkdf and prf of pbkdf2
+	KDFIterations    = 600000   // KDF default pbkdf2 iterations
+	KDFMinIterations = 1000     // KDF min pbkdf2 iterations
+)
+
+const (
+	OTPAlgorithm     = "sha1" // OTP default hmac algorithm, authenticator apps mostly support sha1 only
+	OTPDigits        = 6      // OTP default code digits
+	OTPPeriod        = 30     // OTP default totp time step in seconds
+	OTPSecretSize    = 20     // OTP default secret size
+	OTPMinSecretSize = 16     // OTP min secret size, RFC 4226 requires 128 bits
+	OTPMaxSkew       = 10     // OTP max steps of skew window
+)
+
+const (
+	CTPHWindow    = 7          // CTPH rolling hash window
+	CTPHMinBlock  = 3          // CTPH min block size, block size is CTPHMinBlock << n
+	CTPHLength    = 64         // CTPH max digest length of one block size
+	CTPHBlocks    = 31         // CTPH block size number
+	CTPHHashInit  = 0x28021967 // CTPH piece hash initial value
+	CTPHHashPrime = 0x01000193 // CTPH piece hash prime
+)
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"net/http"
+	"os"
 	"qora/app"
 	"runtime"
 )
@@ -18,6 +19,10 @@ func init() {
 }
 
 func main() {
+	// run command line tool when command is given, like 'qora inspect file.pak'
+	if len(os.Args) > 1 {
+		os.Exit(app.Command(os.Args[1:]))
+	}
 	fmt.Println("The Qora Project")
 	qora := app.New()
 	qora.Init()
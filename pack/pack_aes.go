@@ -2,8 +2,6 @@ package pack
 
 import (
 	"bytes"
-	"crypto/aes"
-	"crypto/cipher"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -53,13 +51,13 @@ func PackAES(src []string, dest string) (err error) {
 	head.Author = make([]byte, 16)
 	head.Type = make([]byte, 8)
 	head.Number = make([]byte, 4)
-	_, name := filepath.Split(dest)
-	if len([]byte(name)) > 32 {
-		log.Println("Error dest file name length:", err)
-		return
+	id, err := NewUUID()
+	if err != nil {
+		log.Println("Error new package uuid:", err)
+		return err
 	}
-	BytesCopy(&(head.Name), []byte(name))
-	BytesCopy(&(head.Author), []byte("Alopex6414"))
+	BytesCopy(&(head.Name), id)
+	BytesCopy(&(head.Author), []byte(PackAuthor))
 	BytesCopy(&(head.Type), []byte("AES"))
 	BytesCopy(&(head.Number), IntToBytes(len(src)))
 	r[0] = head.Name
@@ -108,13 +106,13 @@ func PackAESConfine(src []string, dest string) (err error) {
 	head.Author = make([]byte, 16)
 	head.Type = make([]byte, 8)
 	head.Number = make([]byte, 4)
-	_, name := filepath.Split(dest)
-	if len([]byte(name)) > 32 {
-		log.Println("Error dest file name length:", err)
-		return
+	id, err := NewUUID()
+	if err != nil {
+		log.Println("Error new package uuid:", err)
+		return err
 	}
-	BytesCopy(&(head.Name), []byte(name))
-	BytesCopy(&(head.Author), []byte("Alopex6414"))
+	BytesCopy(&(head.Name), id)
+	BytesCopy(&(head.Author), []byte(PackAuthor))
 	BytesCopy(&(head.Type), []byte("AES"))
 	BytesCopy(&(head.Number), IntToBytes(len(src)))
 	r[0] = head.Name
@@ -199,7 +197,6 @@ func PackAESOneConfineGo(src string, r *[]byte, wg *sync.WaitGroup, ch chan inte
 // PackAESOne function
 // it the base function of PackAESOneGo
 func PackAESOne(src string) (r []byte, err error) {
-	rand.Seed(time.Now().UnixNano())
 	// first, open the file
 	file, err := os.Open(src)
 	if err != nil {
@@ -213,20 +210,35 @@ func PackAESOne(src string) (r []byte, err error) {
 		log.Println("Error read file:", err)
 		return r, err
 	}
-	// third, generate random key
+	_, name := filepath.Split(src)
+	return PackAESOneData(name, data)
+}
+
+// PackAESOneData function
+// it common with function PackAESOne, just take name and data from memory instead of file
+// name is the entry name in package, it should not be longer than 32 bytes
+func PackAESOneData(name string, data []byte) (r []byte, err error) {
+	rand.Seed(time.Now().UnixNano())
+	// first, generate random key
 	key := make([]byte, 16)
 	_, err = rand.Read(key)
 	if err != nil {
 		log.Println("Error generate random key:", err)
 		return r, err
 	}
-	// fourth, split the data slice
+	return packAESOneData(name, data, key)
+}
+
+// packAESOneData function
+// it common with function PackAESOneData, just the key is given by caller instead of random
+func packAESOneData(name string, data []byte, key []byte) (r []byte, err error) {
+	// first, split the data slice
 	ss, err := SplitByte(data, AESBufferSize)
 	if err != nil {
 		log.Println("Error split bytes:", err)
 		return r, err
 	}
-	// fifth, we can call AESEncrypt function
+	// second, we can call AESEncrypt function
 	wg := &sync.WaitGroup{}
 	rr := make([][]byte, len(ss))
 	for k, v := range ss {
@@ -235,8 +247,7 @@ func PackAESOne(src string) (r []byte, err error) {
 	}
 	wg.Wait()
 	dest := bytes.Join(rr, []byte(""))
-	// sixth, fill the packet struct
-	_, name := filepath.Split(src)
+	// third, fill the packet struct
 	if len([]byte(name)) > 32 {
 		log.Println("Error source file name length:", err)
 		return
@@ -387,21 +398,10 @@ func AESEncryptConfineGo(src, key []byte, dest *[]byte, wg *sync.WaitGroup, ch c
 // original function of encrypt
 func AESEncrypt(src, key []byte) (dest []byte, err error) {
 	// key length should be 16, 24, 32
-	block, err := aes.NewCipher(key)
+	dest, err = EntryEncrypt("AES", key, nil, src)
 	if err != nil {
 		log.Println("Error key length:", err)
-		return dest, err
 	}
-	// calculate block size
-	blockSize := block.BlockSize()
-	// fill block data
-	src = PKCS7Padding(src, blockSize)
-	// encrypt mode
-	blockMode := cipher.NewCBCEncrypter(block, key[:blockSize])
-	// create slice
-	dest = make([]byte, len(src))
-	// encrypt
-	blockMode.CryptBlocks(dest, src)
 	return dest, err
 }
 
@@ -2,6 +2,9 @@ package pack
 
 import (
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"qora/unpack"
 	"sync"
 	"testing"
 )
@@ -16,6 +19,28 @@ func TestPackAES(t *testing.T) {
 	}
 }
 
+// TestPackAESRenamed function
+func TestPackAESRenamed(t *testing.T) {
+	src := []string{"../test/data/pack/file_1.txt"}
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "file_aes.txt")
+	err := PackAES(src, dest)
+	if err != nil {
+		t.Fatal("Error Pack AES:", err)
+	}
+	rename := filepath.Join(dir, "file_aes (1).txt")
+	_ = os.Rename(dest, rename)
+	err = unpack.UnpackAES(rename, dir+"/")
+	if err != nil {
+		t.Fatal("Error Unpack renamed AES:", err)
+	}
+	r, _ := ioutil.ReadFile(filepath.Join(dir, "file_1.txt"))
+	s, _ := ioutil.ReadFile(src[0])
+	if string(r) != string(s) {
+		t.Fatal("Error Unpack renamed AES content")
+	}
+}
+
 // TestPackAESConfine function
 func TestPackAESConfine(t *testing.T) {
 	src := []string{"../test/data/pack/file_1.txt", "../test/data/pack/file_2.txt", "../test/data/pack/file_3.txt", "../test/data/pack/file_4.txt", "../test/data/pack/file_5.txt"}
@@ -0,0 +1,64 @@
+package pack
+
+import (
+	"bytes"
+	"crypto/rand"
+	"log"
+	. "qora/global"
+	. "qora/utils"
+)
+
+// PackAESGCMOneData function
+// it pack one entry by aes-256-gcm, name and data are taken from memory
+// every entry has its own random key which is used only once, so the nonce is zero
+// entry name is authenticated with data, entry layout is the same as aes: name, key, origin size, crypt size and body
+// name is the entry name in package, it should not be longer than 32 bytes
+func PackAESGCMOneData(name string, data []byte) (r []byte, err error) {
+	// first, generate random key
+	key := make([]byte, 32)
+	_, err = rand.Read(key)
+	if err != nil {
+		log.Println("Error generate random key:", err)
+		return r, err
+	}
+	return packAESGCMOneData(name, data, key)
+}
+
+// packAESGCMOneData function
+// it common with function PackAESGCMOneData, just the key is given by caller instead of random
+func packAESGCMOneData(name string, data []byte, key []byte) (r []byte, err error) {
+	// first, fill the packet struct
+	if len([]byte(name)) > 32 {
+		log.Println("Error source file name length:", name)
+		return r, err
+	}
+	head := TPackAESOne{}
+	head.Name = make([]byte, 32)
+	head.Key = make([]byte, 32)
+	head.OriginSize = make([]byte, 4)
+	head.CryptSize = make([]byte, 4)
+	BytesCopy(&(head.Name), []byte(name))
+	BytesCopy(&(head.Key), key)
+	// second, we can call AESGCMEncrypt function
+	dest, err := AESGCMEncrypt(data, key, head.Name)
+	if err != nil {
+		return r, err
+	}
+	BytesCopy(&(head.OriginSize), IntToBytes(len(data)))
+	BytesCopy(&(head.CryptSize), IntToBytes(len(dest)))
+	// finally, return result
+	r = bytes.Join([][]byte{head.Name, head.Key, head.OriginSize, head.CryptSize, dest}, []byte(""))
+	return r, err
+}
+
+// AESGCMEncrypt function
+// this function encrypt byte slice by aes-gcm with zero nonce, key should never be used twice
+// aad is authenticated but not encrypted
+// return the cipher text followed by GCMTagSize bytes tag
+func AESGCMEncrypt(src, key, aad []byte) (dest []byte, err error) {
+	dest, err = EntryEncrypt(GCMType, key, aad, src)
+	if err != nil {
+		log.Println("Error key length:", err)
+	}
+	return dest, err
+}
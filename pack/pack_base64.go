@@ -1,7 +1,6 @@
 package pack
 
 import (
-	"encoding/base64"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -50,13 +49,13 @@ func PackBase64(src []string, dest string) (err error) {
 	head.Author = make([]byte, 16)
 	head.Type = make([]byte, 8)
 	head.Number = make([]byte, 4)
-	_, name := filepath.Split(dest)
-	if len([]byte(name)) > 32 {
-		log.Println("Error dest file name length:", err)
-		return
+	id, err := NewUUID()
+	if err != nil {
+		log.Println("Error new package uuid:", err)
+		return err
 	}
-	BytesCopy(&(head.Name), []byte(name))
-	BytesCopy(&(head.Author), []byte("Alopex6414"))
+	BytesCopy(&(head.Name), id)
+	BytesCopy(&(head.Author), []byte(PackAuthor))
 	BytesCopy(&(head.Type), []byte("BASE64"))
 	BytesCopy(&(head.Number), IntToBytes(len(src)))
 	r[0] = string(head.Name)
@@ -140,7 +139,15 @@ func PackBase64One(src string) (r string, err error) {
 		log.Println("Error read file:", err)
 		return r, err
 	}
-	// third, split the data slice
+	_, name := filepath.Split(src)
+	return PackBase64OneData(name, data)
+}
+
+// PackBase64OneData function
+// it common with function PackBase64One, just take name and data from memory instead of file
+// name is the entry name in package, it should not be longer than 32 bytes
+func PackBase64OneData(name string, data []byte) (r string, err error) {
+	// first, split the data slice
 	ss, err := SplitByte(data, Base64BufferSize)
 	if err != nil {
 		log.Println("Error split bytes:", err)
@@ -151,7 +158,7 @@ func PackBase64One(src string) (r string, err error) {
 		last := len(data) / Base64BufferSize
 		ss[last] = append(ss[last][:0], ss[last][:size]...)
 	}
-	// fourth, we can call Base64Encrypt function
+	// second, we can call Base64Encrypt function
 	wg := &sync.WaitGroup{}
 	rr := make([]string, len(ss))
 	for k, v := range ss {
@@ -160,8 +167,7 @@ func PackBase64One(src string) (r string, err error) {
 	}
 	wg.Wait()
 	dest := strings.Join(rr, "")
-	// fifth, fill the packet struct
-	_, name := filepath.Split(src)
+	// third, fill the packet struct
 	if len([]byte(name)) > 32 {
 		log.Println("Error source file name length:", err)
 		return
@@ -196,7 +202,6 @@ func Base64EncryptGo(str string, r *string, wg *sync.WaitGroup) {
 // Base64Encrypt function
 // it common with function Base64EncryptGo
 func Base64Encrypt(str string) string {
-	s := []byte(str)
-	r := base64.StdEncoding.EncodeToString(s)
-	return r
+	r, _ := EntryEncrypt("BASE64", nil, nil, []byte(str))
+	return string(r)
 }
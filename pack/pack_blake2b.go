@@ -2,11 +2,13 @@ package pack
 
 import (
 	"encoding/hex"
+	"errors"
+	"fmt"
 	"github.com/dchest/blake2b"
 )
 
 func Blake2bEncode128(src string) string {
-	h := blake2b.NewMAC(16, nil)
+	h, _ := blake2b.New(&blake2b.Config{Size: 16})
 	h.Write([]byte(src))
 	return hex.EncodeToString(h.Sum(nil))
 }
@@ -25,7 +27,7 @@ func Blake2bEncode512(src string) string {
 
 func Blake2bCheck128(src string, dest string) bool {
 	b := false
-	if Blake2bEncode128(src) == dest {
+	if hashEqual(Blake2bEncode128(src), dest) {
 		b = true
 	}
 	return b
@@ -33,7 +35,7 @@ func Blake2bCheck128(src string, dest string) bool {
 
 func Blake2bCheck256(src string, dest string) bool {
 	b := false
-	if Blake2bEncode256(src) == dest {
+	if hashEqual(Blake2bEncode256(src), dest) {
 		b = true
 	}
 	return b
@@ -41,8 +43,27 @@ func Blake2bCheck256(src string, dest string) bool {
 
 func Blake2bCheck512(src string, dest string) bool {
 	b := false
-	if Blake2bEncode512(src) == dest {
+	if hashEqual(Blake2bEncode512(src), dest) {
 		b = true
 	}
 	return b
 }
+
+// Blake2bMAC function
+// input src string, key and output bytes size, output mac string with keyed blake2b
+// key is no more than 64 bytes, size is 1 to 64 bytes, like 16, 32 or 64
+// keyed blake2b is a mac by itself, it is not the same as hmac with blake2b
+// return err indicate the success or failure function execute
+func Blake2bMAC(src string, key string, size int) (dest string, err error) {
+	if len(key) == 0 || len(key) > blake2b.KeySize || size <= 0 || size > blake2b.Size {
+		s := fmt.Sprintf("Invalid blake2b mac key length %v or size %v", len(key), size)
+		err = errors.New(s)
+		return dest, err
+	}
+	h, err := blake2b.New(&blake2b.Config{Size: uint8(size), Key: []byte(key)})
+	if err != nil {
+		return dest, err
+	}
+	h.Write([]byte(src))
+	return hex.EncodeToString(h.Sum(nil)), err
+}
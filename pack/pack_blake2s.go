@@ -0,0 +1,19 @@
+package pack
+
+import (
+	"encoding/hex"
+	"golang.org/x/crypto/blake2s"
+)
+
+func Blake2sEncode256(src string) string {
+	h := blake2s.Sum256([]byte(src))
+	return hex.EncodeToString(h[:])
+}
+
+func Blake2sCheck256(src string, dest string) bool {
+	b := false
+	if hashEqual(Blake2sEncode256(src), dest) {
+		b = true
+	}
+	return b
+}
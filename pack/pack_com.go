@@ -1,5 +1,11 @@
 package pack
 
+import (
+	"io"
+	"os"
+	"time"
+)
+
 var Done int64
 
 // pack aes
@@ -73,3 +79,182 @@ type TPackBase64One struct {
 	Name []byte // [32]byte/256bit
 	Size []byte // [4]byte/32bit
 }
+
+// pack entry
+type Entry struct {
+	Name    string      // entry name in package, no more than 32 bytes
+	Reader  io.Reader   // entry data reader, used when Bytes is nil
+	Bytes   []byte      // entry data
+	Mode    os.FileMode // entry permission, recorded in manifest of v2 package, v1 package restores 0644
+	ModTime time.Time   // entry modify time, recorded in manifest of v2 package
+	// entry algorithm of mixed package, empty uses 'AES'
+	// package of other algorithm only accepts empty or its own algorithm
+	Algorithm string
+}
+
+// pack option
+type PackOption struct {
+	TableKey   []byte    // aes key which seal the entry table, 16, 24 or 32 bytes, nil keeps the table plain
+	PadSize    int       // pad entry data to multiple of PadSize bytes before encrypt, 0 disable
+	PadPow2    bool      // pad entry data to power of two bytes before encrypt
+	Meta       TPackMeta // package metadata, stored plain in header extension
+	MetaDigest bool      // cover metadata by digest, it is hmac-sha256 with table key when sealed, otherwise sha256
+	VolumeSize int64     // split package into parts no more than VolumeSize bytes, 0 disable
+	Parity     int       // write reed-solomon parity file with Parity percent overhead, 0 disable
+	Seed       []byte    // derive keys, nonces and uuid from Seed by hkdf, so the same input gives the same package, nil keeps them random
+	Version    int       // package format version, 0 is 2, 1 writes legacy header which only records Meta.Author
+}
+
+// edit option
+type EditOption struct {
+	TableKey   []byte    // aes key which sealed the entry table, the edited table is sealed by it again, nil for plain table
+	Meta       TPackMeta // metadata of merged package, empty author, creator and create time are filled with default values
+	MetaDigest bool      // cover metadata of merged package by digest, see PackOption.MetaDigest
+}
+
+// pack metadata
+type TPackMeta struct {
+	Author  string            // package author, the first 16 bytes are also written in header
+	Creator string            // tool which create the package
+	Version string            // version of creator
+	Created time.Time         // package create time
+	Comment string            // free text comment
+	Labels  map[string]string // arbitrary key/value labels
+}
+
+// pack v2 header extension, gob encoded behind the header
+type TPackExtend struct {
+	Sealed     bool   // entry table is sealed
+	Table      []byte // sealed entry table, nonce followed by aes-gcm cipher text
+	Meta       []byte // gob encoded metadata
+	MetaDigest []byte // digest of encoded metadata
+	MetaKeyed  bool   // metadata digest is hmac-sha256 with table key
+	Manifest   []byte // gob encoded manifest, sealed like entry table when the table is sealed
+}
+
+// pack manifest, it records the whole file tree when package is created
+type TPackManifest struct {
+	Base    string              // uuid of base package, empty for full package
+	Deleted []string            // files which are in base package but deleted now
+	Files   []TPackManifestFile // every file of the tree, including the unchanged files which are only in base package
+}
+
+type TPackManifestFile struct {
+	Name    string      // entry name
+	Size    int64       // file size
+	ModTime time.Time   // modify time
+	Mode    os.FileMode // permission bits
+	Digest  []byte      // sha256 of file content
+}
+
+// pack volume part header
+type TPackVolume struct {
+	Magic  []byte // [4]byte/32bit
+	SetID  []byte // [16]byte/128bit
+	Seq    []byte // [4]byte/32bit, start from 1
+	Total  []byte // [4]byte/32bit
+	Size   []byte // [4]byte/32bit, payload size
+	Digest []byte // [32]byte/256bit, sha256 of payload
+	Name   []byte // [32]byte/256bit, file name of the whole package
+}
+
+// pack parity file header, gob encoded behind magic, header size and header digest
+type TPackParity struct {
+	Size       int      // package size
+	ShardSize  int      // shard size
+	StripeSize int      // max data shard number of one stripe
+	Percent    int      // parity shard number percent of data shard number in one stripe
+	Digests    [][]byte // sha256 of data shards followed by parity shards, the last data shard is zero padded
+}
+
+// pack patch, gob encoded, compressed and sealed behind patch magic
+type TPackPatch struct {
+	Head    []byte            // header of new package
+	Extend  []byte            // raw header extension of new package with its size, empty for v1 package
+	Sealed  bool              // entry table of new package is sealed, entry headers are not written before bodies
+	Digest  []byte            // sha256 of new package
+	Entries []TPackPatchEntry // entries of new package in package order
+}
+
+type TPackPatchEntry struct {
+	Head   []byte // raw entry header of new package, the entry key is used to encrypt the plain data again
+	Base   string // name of old entry which delta is based on, empty when entry is new
+	Ctrl   []int  // bsdiff style control triples: diff length, extra length, next old offset
+	Diff   []byte // bytes added to old data
+	Extra  []byte // bytes inserted as is
+	Digest []byte // sha256 of plain data
+}
+
+// rekey option
+type RekeyOption struct {
+	// algorithm of new package, empty keeps the algorithm of old package
+	// 'MIXED' or mixed old package keeps the algorithm of every entry
+	Algorithm string
+	Option    PackOption // pack option of new package, like new table key, empty metadata keeps the old one
+}
+
+// rekey report of package directory
+type TRekeyReport struct {
+	Done   []string     // relative paths of packages which are rekeyed
+	Failed []TRekeyFail // packages which are failed, they are not written
+}
+
+type TRekeyFail struct {
+	Path   string // relative path of package
+	Reason string // error info
+}
+
+// hash result, lower case algorithm name to hex digest
+type THashResult map[string]string
+
+// checksum manifest verify report
+type TSumReport struct {
+	Algorithm  string   // hash algorithm of manifest
+	Passed     []string // files which match the manifest
+	Mismatched []string // files whose digest mismatch
+	Missing    []string // files which are in manifest but not found
+	Unreadable []string // files which are found but could not be read
+	Extra      []string // files which are beside the manifest but not in it
+}
+
+// merkle tree of file, leaves are sha256 of fixed size chunks
+type TMerkleTree struct {
+	ChunkSize int      // chunk size, the last chunk could be shorter
+	Size      int64    // data size
+	Leaves    [][]byte // leaf hashes in chunk order
+	Root      []byte   // merkle root
+}
+
+// password hash parameters, zero value uses the default of global
+type PasswordParams struct {
+	Time     uint32 // argon2id iterations
+	Memory   uint32 // argon2id memory in KiB
+	Threads  uint8  // argon2id parallelism
+	N        int    // scrypt cost parameter N, power of two
+	R        int    // scrypt block size parameter r
+	P        int    // scrypt parallelization parameter p
+	Cost     int    // bcrypt cost
+	SaltSize int    // salt size of argon2id and scrypt, bcrypt salt is always 16 bytes
+	KeySize  int    // hash size of argon2id and scrypt, bcrypt hash is always 23 bytes
+}
+
+// key derivation option, zero value uses the default of global
+type KDFOption struct {
+	Salt       []byte         // salt, optional for hkdf, at least KDFMinSaltSize bytes for other kdf
+	Info       []byte         // hkdf context info, like tenant id, it binds the key to its usage
+	Size       int            // output size
+	Hash       string         // hash of hkdf and prf of pbkdf2, like 'sha256' and 'sha512', 'hmac_' prefix is accepted
+	Iterations int            // pbkdf2 iterations
+	Params     PasswordParams // scrypt and argon2 cost params, salt and key size are taken from Salt and Size
+}
+
+// one-time password option, zero value uses the default of global
+type OTPOption struct {
+	Algorithm string // hmac algorithm, 'sha1', 'sha256' or 'sha512'
+	Digits    int    // code digits, 6 to 8
+	Period    int64  // totp time step in seconds
+	Skew      int    // steps accepted before and after current totp step, or steps looked ahead of hotp counter
+	// replay protection hook, it is called with the counter or time step which matches,
+	// return false when it is used before, so the code is rejected, the hook should record the accepted one
+	Replay func(counter uint64) bool
+}
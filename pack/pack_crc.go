@@ -0,0 +1,53 @@
+package pack
+
+import (
+	"encoding/hex"
+	"hash/crc32"
+	"hash/crc64"
+)
+
+// crc tables, crc32c is castagnoli polynomial and crc64 is ecma polynomial(the same as xz)
+var (
+	crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+	crc64Table  = crc64.MakeTable(crc64.ECMA)
+)
+
+// CRC32CCheck function
+// input src string and output dest string with crc32c
+// crc is fast but not cryptographic, it only detects accidental change
+// return bool indicate the success or failure function execute
+func CRC32CCheck(src string, dest string) bool {
+	b := false
+	if hashEqual(CRC32CEncode(src), dest) {
+		b = true
+	}
+	return b
+}
+
+// CRC32CEncode function
+// encode src string output dest string with crc32c, it is big endian hex
+func CRC32CEncode(src string) string {
+	h := crc32.New(crc32cTable)
+	h.Write([]byte(src))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// CRC64Check function
+// input src string and output dest string with crc64
+// crc is fast but not cryptographic, it only detects accidental change
+// return bool indicate the success or failure function execute
+func CRC64Check(src string, dest string) bool {
+	b := false
+	if hashEqual(CRC64Encode(src), dest) {
+		b = true
+	}
+	return b
+}
+
+// CRC64Encode function
+// encode src string output dest string with crc64, it is big endian hex
+func CRC64Encode(src string) string {
+	h := crc64.New(crc64Table)
+	h.Write([]byte(src))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,350 @@
+package pack
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	. "qora/global"
+	"strconv"
+	"strings"
+)
+
+const ctphBase64 = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// ctph digest of one block size
+type ctphBlock struct {
+	digest [CTPHLength]byte // piece hash characters
+	dindex int              // digest length
+	half   byte             // piece hash character of the second half
+	h      uint32           // piece hash of current piece
+	halfh  uint32           // piece hash of current piece, it is not reset after half digest is full
+}
+
+// ctph is context triggered piecewise hash which is compatible with ssdeep,
+// every block size is hashed in the same pass, so the stream is read only once
+type ctph struct {
+	size       uint64                // data size
+	start, end int                   // block sizes in use
+	last       uint32                // piece hash of the last block size
+	needLast   bool                  // piece hash of the last block size is needed
+	blocks     [CTPHBlocks]ctphBlock // digest of every block size
+	window     [CTPHWindow]byte      // rolling hash window
+	h1, h2, h3 uint32                // rolling hash
+	n          uint32                // rolling hash position
+}
+
+// CTPHEncode function
+// This function is mainly used for find the near duplicate data, like two config files which differ by one line.
+// input src string, output ssdeep compatible fuzzy hash, like '3:hMCEpFn:hur'
+// compare two fuzzy hashes by CTPHCompare
+func CTPHEncode(src string) string {
+	h := newCTPH()
+	_, _ = h.Write([]byte(src))
+	return string(h.Sum(nil))
+}
+
+// CTPHCheck function
+// input src string and fuzzy hash, output whether the fuzzy hash of src is the same as dest
+func CTPHCheck(src, dest string) bool {
+	return hashEqual(CTPHEncode(src), dest)
+}
+
+// CTPHFile function
+// input src file path, return the fuzzy hash and error info
+// src file support both absolute and relative paths, like 'C:\\file.txt' or '../test/data/file.txt'
+// return dest the fuzzy hash, err indicate the success or failure function execute
+func CTPHFile(src string) (dest string, err error) {
+	file, err := os.Open(src)
+	if err != nil {
+		log.Println("Error open file:", err)
+		return dest, err
+	}
+	defer file.Close()
+	return CTPHReader(file)
+}
+
+// CTPHReader function
+// input src reader, return the fuzzy hash and error info
+// the stream is read once by fixed buffer, the fuzzy hash is the same as ssdeep of the whole data,
+// data over 3 << 30 * 64 bytes is not supported as ssdeep does
+// return dest the fuzzy hash, err indicate the success or failure function execute
+func CTPHReader(src io.Reader) (dest string, err error) {
+	r, err := HashReader(src, "ssdeep")
+	return r["ssdeep"], err
+}
+
+// CTPHCompare function
+// input two fuzzy hashes, return the similarity score from 0 to 100 and error info
+// it is the same as ssdeep compare, 0 is no similarity and 100 is very similar or identical,
+// the hashes of too different block sizes could not be compared and score 0
+// return score the similarity, err indicate the success or failure function execute
+func CTPHCompare(a string, b string) (score int, err error) {
+	bs1, a1, a2, err := ctphParse(a)
+	if err != nil {
+		return score, err
+	}
+	bs2, b1, b2, err := ctphParse(b)
+	if err != nil {
+		return score, err
+	}
+	if bs1 != bs2 && bs1*2 != bs2 && bs1 != bs2*2 {
+		return 0, err
+	}
+	if bs1 == bs2 && a1 == b1 {
+		return 100, err
+	}
+	switch {
+	case bs1 == bs2:
+		score = ctphScore(a1, b1, bs1)
+		if s := ctphScore(a2, b2, bs1*2); s > score {
+			score = s
+		}
+	case bs1*2 == bs2:
+		score = ctphScore(b1, a2, bs2)
+	default:
+		score = ctphScore(a1, b2, bs1)
+	}
+	return score, err
+}
+
+// newCTPH function
+// it returns the ctph of initial state
+func newCTPH() *ctph {
+	h := &ctph{}
+	h.Reset()
+	return h
+}
+
+// Reset function
+// it resets the ctph to initial state
+func (h *ctph) Reset() {
+	*h = ctph{end: 1}
+	h.blocks[0].h, h.blocks[0].halfh = CTPHHashInit, CTPHHashInit
+}
+
+// Size function
+// it returns the max length of fuzzy hash, the real length varies with data
+func (h *ctph) Size() int {
+	return len(strconv.FormatUint(CTPHMinBlock<<(CTPHBlocks-1), 10)) + CTPHLength + CTPHLength/2 + 2
+}
+
+// BlockSize function
+func (h *ctph) BlockSize() int {
+	return 1
+}
+
+// Write function
+// it hashes p byte by byte, it fails when total size is over the max which ssdeep supports
+func (h *ctph) Write(p []byte) (n int, err error) {
+	if h.size+uint64(len(p)) > uint64(CTPHMinBlock<<(CTPHBlocks-1))*CTPHLength {
+		s := fmt.Sprintf("CTPH data size is over %v bytes.", uint64(CTPHMinBlock<<(CTPHBlocks-1))*CTPHLength)
+		err = errors.New(s)
+		return n, err
+	}
+	h.size += uint64(len(p))
+	for _, c := range p {
+		h.step(c)
+	}
+	return len(p), err
+}
+
+// Sum function
+// it appends the fuzzy hash text 'blocksize:digest:digest' to b
+func (h *ctph) Sum(b []byte) []byte {
+	sum := h.h1 + h.h2 + h.h3
+	// the smallest block size whose digest could hold the data, then shrink it when the digest is short
+	i := h.start
+	for uint64(CTPHMinBlock<<uint(i))*CTPHLength < h.size {
+		i++
+	}
+	if i >= h.end {
+		i = h.end - 1
+	}
+	for i > h.start && h.blocks[i].dindex < CTPHLength/2 {
+		i--
+	}
+	bl := &h.blocks[i]
+	b = strconv.AppendUint(b, uint64(CTPHMinBlock<<uint(i)), 10)
+	b = append(b, ':')
+	b = append(b, bl.digest[:bl.dindex]...)
+	if sum != 0 {
+		b = append(b, ctphBase64[bl.h%64])
+	} else if bl.digest[bl.dindex] != 0 {
+		b = append(b, bl.digest[bl.dindex])
+	}
+	b = append(b, ':')
+	if i < h.end-1 {
+		nb := &h.blocks[i+1]
+		n := nb.dindex
+		if n > CTPHLength/2-1 {
+			n = CTPHLength/2 - 1
+		}
+		b = append(b, nb.digest[:n]...)
+		if sum != 0 {
+			b = append(b, ctphBase64[nb.halfh%64])
+		} else if nb.half != 0 {
+			b = append(b, nb.half)
+		}
+	} else if sum != 0 && i == 0 {
+		b = append(b, ctphBase64[bl.h%64])
+	} else if sum != 0 {
+		b = append(b, ctphBase64[h.last%64])
+	}
+	return b
+}
+
+// step function
+// it updates the rolling hash and piece hashes with one byte, the piece is ended where rolling hash triggers
+func (h *ctph) step(c byte) {
+	h.h2 -= h.h1
+	h.h2 += CTPHWindow * uint32(c)
+	h.h1 += uint32(c)
+	h.h1 -= uint32(h.window[h.n%CTPHWindow])
+	h.window[h.n%CTPHWindow] = c
+	h.n++
+	h.h3 = h.h3<<5 ^ uint32(c)
+	sum := h.h1 + h.h2 + h.h3
+	for i := h.start; i < h.end; i++ {
+		h.blocks[i].h = h.blocks[i].h*CTPHHashPrime ^ uint32(c)
+		h.blocks[i].halfh = h.blocks[i].halfh*CTPHHashPrime ^ uint32(c)
+	}
+	if h.needLast {
+		h.last = h.last*CTPHHashPrime ^ uint32(c)
+	}
+	// the trigger of larger block size is also the trigger of smaller one
+	for i := h.start; i < h.end; i++ {
+		bs := uint32(CTPHMinBlock) << uint(i)
+		if sum%bs != bs-1 {
+			break
+		}
+		bl := &h.blocks[i]
+		if bl.dindex == 0 {
+			h.fork()
+		}
+		bl.digest[bl.dindex] = ctphBase64[bl.h%64]
+		bl.half = ctphBase64[bl.halfh%64]
+		if bl.dindex < CTPHLength-1 {
+			// the tail pieces are merged into the last character when digest is full
+			bl.dindex++
+			bl.digest[bl.dindex] = 0
+			bl.h = CTPHHashInit
+			if bl.dindex < CTPHLength/2 {
+				bl.halfh = CTPHHashInit
+				bl.half = 0
+			}
+		} else {
+			h.reduce()
+		}
+	}
+}
+
+// fork function
+// it starts the next block size from the state of the current last one
+func (h *ctph) fork() {
+	if h.end < CTPHBlocks {
+		o, n := &h.blocks[h.end-1], &h.blocks[h.end]
+		n.h, n.halfh = o.h, o.halfh
+		n.digest[0], n.half, n.dindex = 0, 0, 0
+		h.end++
+	} else if !h.needLast {
+		h.needLast = true
+		h.last = h.blocks[h.end-1].h
+	}
+}
+
+// reduce function
+// it drops the smallest block size which could not be selected any more
+func (h *ctph) reduce() {
+	if h.end-h.start < 2 || uint64(CTPHMinBlock<<uint(h.start))*CTPHLength >= h.size || h.blocks[h.start+1].dindex < CTPHLength/2 {
+		return
+	}
+	h.start++
+}
+
+// ctphParse function
+// it parses the fuzzy hash into block size and two digests, the file name behind ',' is ignored
+func ctphParse(src string) (bs uint64, d1 string, d2 string, err error) {
+	parts := strings.SplitN(src, ":", 3)
+	if len(parts) == 3 {
+		bs, err = strconv.ParseUint(parts[0], 10, 32)
+		d1, d2 = parts[1], strings.SplitN(parts[2], ",", 2)[0]
+	}
+	if len(parts) != 3 || err != nil || bs == 0 || len(d1) > CTPHLength || len(d2) > CTPHLength {
+		s := fmt.Sprintf("Invalid fuzzy hash: %v", src)
+		err = errors.New(s)
+		return bs, d1, d2, err
+	}
+	return bs, ctphSequence(d1), ctphSequence(d2), err
+}
+
+// ctphSequence function
+// it cuts the runs of the same character to 3, they carry little information
+func ctphSequence(src string) string {
+	b := make([]byte, 0, len(src))
+	for i := 0; i < len(src); i++ {
+		if i < 3 || src[i] != src[i-1] || src[i] != src[i-2] || src[i] != src[i-3] {
+			b = append(b, src[i])
+		}
+	}
+	return string(b)
+}
+
+// ctphScore function
+// it scores two digests of block size bs from 0 to 100 by weighted edit distance
+func ctphScore(a string, b string, bs uint64) int {
+	// the digests without common substring of window size are not similar
+	common := false
+	for i := 0; i+CTPHWindow <= len(a) && !common; i++ {
+		common = strings.Contains(b, a[i:i+CTPHWindow])
+	}
+	if !common {
+		return 0
+	}
+	score := uint64(ctphDistance(a, b)) * CTPHLength / uint64(len(a)+len(b))
+	score = 100 * score / CTPHLength
+	if score >= 100 {
+		return 0
+	}
+	score = 100 - score
+	// small block size does not exaggerate the match
+	if bs < (99+CTPHWindow)/CTPHWindow*CTPHMinBlock {
+		n := len(a)
+		if len(b) < n {
+			n = len(b)
+		}
+		if limit := bs / CTPHMinBlock * uint64(n); score > limit {
+			score = limit
+		}
+	}
+	return int(score)
+}
+
+// ctphDistance function
+// it returns the edit distance of a and b, insert and delete cost 1, replace costs 2
+func ctphDistance(a string, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 2
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
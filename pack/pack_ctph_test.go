@@ -0,0 +1,86 @@
+package pack
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestCTPHEncode function
+func TestCTPHEncode(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&b, "key%d = value%d\n", i, i*i%977)
+	}
+	src := b.String()
+	// the same as ssdeep
+	for k, v := range map[string]string{
+		"":  "3::",
+		src: "768:KV05khxZI7STrjNnt6/y9dNUDLQaUROwjE5LfzYsV:y05OxZIirr6/4dqDLQaU1jQfksV",
+	} {
+		dest := CTPHEncode(k)
+		if dest != v || !CTPHCheck(k, v) {
+			t.Fatal("Error CTPH Encode:", len(k), dest)
+		}
+	}
+	// stream of small chunks and hash dispatch
+	h := newCTPH()
+	for i := 0; i < len(src); i += 1000 {
+		h.Write([]byte(src[i:min(i+1000, len(src))]))
+	}
+	dest, err := CTPHReader(strings.NewReader(src))
+	if err != nil || dest != string(h.Sum(nil)) || dest != CTPHEncode(src) {
+		t.Fatal("Error CTPH Reader:", dest, err)
+	}
+	r, err := HashReader(bytes.NewReader([]byte(src)), "SSDEEP", "sha256")
+	if err != nil || r["ssdeep"] != dest || len(r["sha256"]) != 64 {
+		t.Fatal("Error Hash Reader ssdeep:", r, err)
+	}
+	dest, err = PackHashEncode(src, "ctph")
+	if err != nil || dest != r["ssdeep"] {
+		t.Fatal("Error pack hash encode ctph:", dest, err)
+	}
+	ok, err := PackHashCheck(src, dest, "ssdeep")
+	if err != nil || !ok {
+		t.Fatal("Error pack hash check ssdeep:", err)
+	}
+	_, err = PackHashEncodeWithKey(src, "key", "ssdeep")
+	if err == nil {
+		t.Fatal("Error pack hash encode: ssdeep with key should fail")
+	}
+}
+
+// TestCTPHCompare function
+func TestCTPHCompare(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&b, "key%d = value%d\n", i, i*i%977)
+	}
+	src := b.String()
+	a := CTPHEncode(src)
+	score, err := CTPHCompare(a, a)
+	if err != nil || score != 100 {
+		t.Fatal("Error CTPH Compare identical:", score, err)
+	}
+	// one line changed, ssdeep scores 99
+	score, err = CTPHCompare(a, CTPHEncode(strings.Replace(src, "key1000 = value", "key1000 = changed", 1)))
+	if err != nil || score != 99 {
+		t.Fatal("Error CTPH Compare similar:", score, err)
+	}
+	score, err = CTPHCompare(a, CTPHEncode(strings.Repeat("unrelated data\n", 2400)))
+	if err != nil || score != 0 {
+		t.Fatal("Error CTPH Compare unrelated:", score, err)
+	}
+	// file name of ssdeep output is ignored
+	score, err = CTPHCompare(a+`,"config.txt"`, a)
+	if err != nil || score != 100 {
+		t.Fatal("Error CTPH Compare with file name:", score, err)
+	}
+	for _, v := range []string{"", "768:abc", "x:abc:abc", "0:abc:abc", "3:" + strings.Repeat("a", 65) + ":a"} {
+		_, err = CTPHCompare(v, a)
+		if err == nil {
+			t.Fatal("Error CTPH Compare: invalid hash should fail", v)
+		}
+	}
+}
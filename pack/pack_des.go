@@ -2,8 +2,6 @@ package pack
 
 import (
 	"bytes"
-	"crypto/cipher"
-	"crypto/des"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -53,13 +51,13 @@ func Pack3DES(src []string, dest string) (err error) {
 	head.Author = make([]byte, 16)
 	head.Type = make([]byte, 8)
 	head.Number = make([]byte, 4)
-	_, name := filepath.Split(dest)
-	if len([]byte(name)) > 32 {
-		log.Println("Error dest file name length:", err)
-		return
+	id, err := NewUUID()
+	if err != nil {
+		log.Println("Error new package uuid:", err)
+		return err
 	}
-	BytesCopy(&(head.Name), []byte(name))
-	BytesCopy(&(head.Author), []byte("Alopex6414"))
+	BytesCopy(&(head.Name), id)
+	BytesCopy(&(head.Author), []byte(PackAuthor))
 	BytesCopy(&(head.Type), []byte("3DES"))
 	BytesCopy(&(head.Number), IntToBytes(len(src)))
 	r[0] = head.Name
@@ -109,13 +107,13 @@ func PackDES(src []string, dest string) (err error) {
 	head.Author = make([]byte, 16)
 	head.Type = make([]byte, 8)
 	head.Number = make([]byte, 4)
-	_, name := filepath.Split(dest)
-	if len([]byte(name)) > 32 {
-		log.Println("Error dest file name length:", err)
-		return
+	id, err := NewUUID()
+	if err != nil {
+		log.Println("Error new package uuid:", err)
+		return err
 	}
-	BytesCopy(&(head.Name), []byte(name))
-	BytesCopy(&(head.Author), []byte("Alopex6414"))
+	BytesCopy(&(head.Name), id)
+	BytesCopy(&(head.Author), []byte(PackAuthor))
 	BytesCopy(&(head.Type), []byte("DES"))
 	BytesCopy(&(head.Number), IntToBytes(len(src)))
 	r[0] = head.Name
@@ -183,7 +181,6 @@ func Pack3DESOneGo(src string, r *[]byte, wg *sync.WaitGroup) (err error) {
 // this function pack one file by 3des
 // inner function called by Pack3DESOneGo
 func Pack3DESOne(src string) (r []byte, err error) {
-	rand.Seed(time.Now().UnixNano())
 	// first, open the file
 	file, err := os.Open(src)
 	if err != nil {
@@ -197,20 +194,35 @@ func Pack3DESOne(src string) (r []byte, err error) {
 		log.Println("Error read file:", err)
 		return r, err
 	}
-	// third, generate random key
+	_, name := filepath.Split(src)
+	return Pack3DESOneData(name, data)
+}
+
+// Pack3DESOneData function
+// it common with function Pack3DESOne, just take name and data from memory instead of file
+// name is the entry name in package, it should not be longer than 32 bytes
+func Pack3DESOneData(name string, data []byte) (r []byte, err error) {
+	rand.Seed(time.Now().UnixNano())
+	// first, generate random key
 	key := make([]byte, 24)
 	_, err = rand.Read(key)
 	if err != nil {
 		log.Println("Error generate random key:", err)
 		return r, err
 	}
-	// fourth, split the data slice
+	return pack3DESOneData(name, data, key)
+}
+
+// pack3DESOneData function
+// it common with function Pack3DESOneData, just the key is given by caller instead of random
+func pack3DESOneData(name string, data []byte, key []byte) (r []byte, err error) {
+	// first, split the data slice
 	ss, err := SplitByte(data, DESBufferSize)
 	if err != nil {
 		log.Println("Error split bytes:", err)
 		return r, err
 	}
-	// fifth, we can call TripleDESEncryptGo function
+	// second, we can call TripleDESEncryptGo function
 	wg := &sync.WaitGroup{}
 	rr := make([][]byte, len(ss))
 	for k, v := range ss {
@@ -219,8 +231,7 @@ func Pack3DESOne(src string) (r []byte, err error) {
 	}
 	wg.Wait()
 	dest := bytes.Join(rr, []byte(""))
-	// sixth, fill the packet struct
-	_, name := filepath.Split(src)
+	// third, fill the packet struct
 	if len([]byte(name)) > 32 {
 		log.Println("Error source file name length:", err)
 		return
@@ -266,7 +277,6 @@ func PackDESOneGo(src string, r *[]byte, wg *sync.WaitGroup) (err error) {
 // this function pack one file by des
 // inner function called by PackDESOneGo
 func PackDESOne(src string) (r []byte, err error) {
-	rand.Seed(time.Now().UnixNano())
 	// first, open the file
 	file, err := os.Open(src)
 	if err != nil {
@@ -280,20 +290,35 @@ func PackDESOne(src string) (r []byte, err error) {
 		log.Println("Error read file:", err)
 		return r, err
 	}
-	// third, generate random key
+	_, name := filepath.Split(src)
+	return PackDESOneData(name, data)
+}
+
+// PackDESOneData function
+// it common with function PackDESOne, just take name and data from memory instead of file
+// name is the entry name in package, it should not be longer than 32 bytes
+func PackDESOneData(name string, data []byte) (r []byte, err error) {
+	rand.Seed(time.Now().UnixNano())
+	// first, generate random key
 	key := make([]byte, 8)
 	_, err = rand.Read(key)
 	if err != nil {
 		log.Println("Error generate random key:", err)
 		return r, err
 	}
-	// fourth, split the data slice
+	return packDESOneData(name, data, key)
+}
+
+// packDESOneData function
+// it common with function PackDESOneData, just the key is given by caller instead of random
+func packDESOneData(name string, data []byte, key []byte) (r []byte, err error) {
+	// first, split the data slice
 	ss, err := SplitByte(data, DESBufferSize)
 	if err != nil {
 		log.Println("Error split bytes:", err)
 		return r, err
 	}
-	// fifth, we can call DESEncrypt function
+	// second, we can call DESEncrypt function
 	wg := &sync.WaitGroup{}
 	rr := make([][]byte, len(ss))
 	for k, v := range ss {
@@ -302,8 +327,7 @@ func PackDESOne(src string) (r []byte, err error) {
 	}
 	wg.Wait()
 	dest := bytes.Join(rr, []byte(""))
-	// sixth, fill the packet struct
-	_, name := filepath.Split(src)
+	// third, fill the packet struct
 	if len([]byte(name)) > 32 {
 		log.Println("Error source file name length:", err)
 		return
@@ -351,21 +375,10 @@ func TripleDESEncryptGo(src, key []byte, dest *[]byte, wg *sync.WaitGroup) (err
 // inner function called by TripleDESEncryptGo
 func TripleDESEncrypt(src, key []byte) (dest []byte, err error) {
 	// key length should be 24
-	block, err := des.NewTripleDESCipher(key)
+	dest, err = EntryEncrypt("3DES", key, nil, src)
 	if err != nil {
 		log.Println("Error key length:", err)
-		return dest, err
 	}
-	// calculate block size
-	blockSize := block.BlockSize()
-	// fill block data
-	src = PKCS5Padding(src, blockSize)
-	// encrypt mode
-	blockMode := cipher.NewCBCEncrypter(block, key[:blockSize])
-	// create slice
-	dest = make([]byte, len(src))
-	// encrypt
-	blockMode.CryptBlocks(dest, src)
 	return dest, err
 }
 
@@ -388,21 +401,10 @@ func DESEncryptGo(src, key []byte, dest *[]byte, wg *sync.WaitGroup) (err error)
 // inner function called by DESEncryptGo
 func DESEncrypt(src, key []byte) (dest []byte, err error) {
 	// key length should be 8
-	block, err := des.NewCipher(key)
+	dest, err = EntryEncrypt("DES", key, nil, src)
 	if err != nil {
 		log.Println("Error key length:", err)
-		return dest, err
 	}
-	// calculate block size
-	blockSize := block.BlockSize()
-	// fill block data
-	src = PKCS5Padding(src, blockSize)
-	// encrypt mode
-	blockMode := cipher.NewCBCEncrypter(block, key[:blockSize])
-	// create slice
-	dest = make([]byte, len(src))
-	// encrypt
-	blockMode.CryptBlocks(dest, src)
 	return dest, err
 }
 
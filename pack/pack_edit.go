@@ -0,0 +1,415 @@
+package pack
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	. "qora/global"
+	"qora/unpack"
+	. "qora/utils"
+	"sync"
+)
+
+// Append function
+// input package path and source file list, output error information
+// package with sealed entry table needs table key, see AppendWithOption
+// it will encrypt the new files with the package algorithm and append them behind the existing entries
+// existing entries are copied verbatim, only the header is rewritten, so nothing is decrypted again
+// dest package support both absolute and relative paths, like 'C:\\package.pak' or '../test/data/package.pak'
+// src file support both absolute and relative paths, like 'C:\\file.txt' or '../test/data/file.txt'
+// return err indicate the success or failure function execute
+func Append(dest string, src []string) (err error) {
+	return AppendWithOption(dest, src, EditOption{})
+}
+
+// AppendWithOption function
+// it is common with function Append, opt.TableKey opens the sealed entry table, which is sealed again by it after edit
+// new entries are not padded, because pad size is not recorded in package
+// return err indicate the success or failure function execute
+func AppendWithOption(dest string, src []string, opt EditOption) (err error) {
+	unlock, err := LockPackage(dest)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	// first, parse the package
+	p, err := unpack.ParsePackageWithOption(dest, unpack.UnpackOption{TableKey: opt.TableKey})
+	if err != nil {
+		log.Println("Error parse package:", err)
+		return err
+	}
+	// second, check the entry name whether exist or not
+	for _, v := range src {
+		_, name := filepath.Split(v)
+		if findEntry(p.Entries, name) >= 0 {
+			s := fmt.Sprintf("Package entry already exist: %v", name)
+			err = errors.New(s)
+			return err
+		}
+	}
+	// third, pack the new files, new entry of mixed package is packed by 'AES'
+	r, err := packEdit(src, p.Type, make([]string, len(src)))
+	if err != nil {
+		return err
+	}
+	// finally, write the package
+	return writePackage(dest, p, append(p.Entries, r...), opt.TableKey)
+}
+
+// Remove function
+// input package path and target entry name list, output error information
+// it will drop the target entries, other entries are copied verbatim
+// target string is the file name in package, for instance, 'capture.png'
+// package with sealed entry table needs table key, see RemoveWithOption
+// return err indicate the success or failure function execute
+func Remove(dest string, target []string) (err error) {
+	return RemoveWithOption(dest, target, EditOption{})
+}
+
+// RemoveWithOption function
+// it is common with function Remove, opt.TableKey opens the sealed entry table, which is sealed again by it after edit
+// return err indicate the success or failure function execute
+func RemoveWithOption(dest string, target []string, opt EditOption) (err error) {
+	unlock, err := LockPackage(dest)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	// first, parse the package
+	p, err := unpack.ParsePackageWithOption(dest, unpack.UnpackOption{TableKey: opt.TableKey})
+	if err != nil {
+		log.Println("Error parse package:", err)
+		return err
+	}
+	// second, check the entry name whether exist or not
+	drop := make(map[string]bool)
+	for _, v := range target {
+		if findEntry(p.Entries, v) < 0 {
+			s := fmt.Sprintf("Package entry not found: %v", v)
+			err = errors.New(s)
+			return err
+		}
+		drop[v] = true
+	}
+	// finally, write the package
+	var rr []unpack.TUnpackEntry
+	for _, v := range p.Entries {
+		if !drop[v.Name] {
+			rr = append(rr, v)
+		}
+	}
+	return writePackage(dest, p, rr, opt.TableKey)
+}
+
+// Replace function
+// input package path and source file list, output error information
+// every source file replaces the entry with the same file name and keeps its position in package
+// untouched entries are copied verbatim
+// package with sealed entry table needs table key, see ReplaceWithOption
+// return err indicate the success or failure function execute
+func Replace(dest string, src []string) (err error) {
+	return ReplaceWithOption(dest, src, EditOption{})
+}
+
+// ReplaceWithOption function
+// it is common with function Replace, opt.TableKey opens the sealed entry table, which is sealed again by it after edit
+// new entries are not padded, because pad size is not recorded in package
+// return err indicate the success or failure function execute
+func ReplaceWithOption(dest string, src []string, opt EditOption) (err error) {
+	unlock, err := LockPackage(dest)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	// first, parse the package
+	p, err := unpack.ParsePackageWithOption(dest, unpack.UnpackOption{TableKey: opt.TableKey})
+	if err != nil {
+		log.Println("Error parse package:", err)
+		return err
+	}
+	// second, check the entry name whether exist or not
+	index := make([]int, len(src))
+	for k, v := range src {
+		_, name := filepath.Split(v)
+		index[k] = findEntry(p.Entries, name)
+		if index[k] < 0 {
+			s := fmt.Sprintf("Package entry not found: %v", name)
+			err = errors.New(s)
+			return err
+		}
+	}
+	// third, pack the new files, entry of mixed package keeps its algorithm
+	types := make([]string, len(src))
+	for k, v := range index {
+		types[k] = p.Entries[v].Type
+	}
+	r, err := packEdit(src, p.Type, types)
+	if err != nil {
+		return err
+	}
+	// finally, write the package
+	rr := make([]unpack.TUnpackEntry, len(p.Entries))
+	copy(rr, p.Entries)
+	for k, v := range index {
+		rr[v] = r[k]
+	}
+	return writePackage(dest, p, rr, opt.TableKey)
+}
+
+// Merge function
+// input dest package path and source package list, output error information
+// all source packages should be packed by the same algorithm, entry names should be unique among them
+// entries are copied verbatim in order of source packages
+// dest package will be created or overwritten, it is a new package with new uuid and default metadata
+// return err indicate the success or failure function execute
+func Merge(dest string, src ...string) (err error) {
+	return MergeWithOption(dest, src, EditOption{})
+}
+
+// MergeWithOption function
+// it is common with function Merge, opt.Meta is the metadata of merged package
+// metadata, digest and author of source packages are not inherited, merged package keeps format version of the first one,
+// v1 package records author only
+// opt.TableKey opens the sealed entry table of source packages, merged package is sealed by it in format v2 when it is set
+// return err indicate the success or failure function execute
+func MergeWithOption(dest string, src []string, opt EditOption) (err error) {
+	if len(src) == 0 {
+		err = errors.New("Merge package list is empty.")
+		return err
+	}
+	unlock, err := LockPackage(dest)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	var pp *unpack.TUnpackPackage
+	var entries []unpack.TUnpackEntry
+	for _, v := range src {
+		// first, parse the package
+		p, err := unpack.ParsePackageWithOption(v, unpack.UnpackOption{TableKey: opt.TableKey})
+		if err != nil {
+			log.Println("Error parse package:", err)
+			return err
+		}
+		// second, check algorithm and entry name
+		if pp == nil {
+			pp = p
+		}
+		if p.Type != pp.Type {
+			s := fmt.Sprintf("Package algorithm mismatch: %v(%v) and %v", v, p.Type, pp.Type)
+			err = errors.New(s)
+			return err
+		}
+		for _, vv := range p.Entries {
+			if findEntry(entries, vv.Name) >= 0 {
+				s := fmt.Sprintf("Package entry already exist: %v", vv.Name)
+				err = errors.New(s)
+				return err
+			}
+			entries = append(entries, vv)
+		}
+	}
+	// third, merged package is a new package, it should not share uuid and metadata with source
+	m := &unpack.TUnpackPackage{Version: pp.Version, Type: pp.Type}
+	if opt.TableKey != nil {
+		m.Version = 2
+		m.Extend.Sealed = true
+	}
+	meta := packMeta(opt.Meta)
+	m.Author = truncate(meta.Author, 16)
+	if m.Version == 2 {
+		m.Extend.Meta, err = encodeMeta(meta)
+		if err != nil {
+			return err
+		}
+		if opt.MetaDigest {
+			m.Extend.MetaDigest, m.Extend.MetaKeyed = metaDigest(m.Extend.Meta, opt.TableKey)
+		}
+	}
+	// finally, write the package
+	return writePackage(dest, m, entries, opt.TableKey)
+}
+
+// packEdit function
+// it will pack the source files through goroutine with algorithm of edited package
+// types is the entry algorithm of mixed package, empty uses 'AES'
+func packEdit(src []string, algorithm string, types []string) (r []unpack.TUnpackEntry, err error) {
+	wg := &sync.WaitGroup{}
+	r = make([]unpack.TUnpackEntry, len(src))
+	e := make([]error, len(src))
+	for k, v := range src {
+		wg.Add(1)
+		go func(k int, v string) {
+			defer wg.Done()
+			r[k], e[k] = packOne(v, algorithm, types[k])
+		}(k, v)
+	}
+	wg.Wait()
+	for k, v := range e {
+		if v != nil {
+			s := fmt.Sprintf("Error %v pack one file: %v", algorithm, src[k])
+			err = errors.New(s)
+			return r, err
+		}
+	}
+	return r, err
+}
+
+// packOne function
+// it will read the source file and call the pack one function base on algorithm
+// the packed entry is split into header and body by entry header size
+func packOne(src string, algorithm string, tp string) (e unpack.TUnpackEntry, err error) {
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		log.Println("Error read file:", err)
+		return e, err
+	}
+	_, name := filepath.Split(src)
+	if algorithm != MixedType {
+		tp = algorithm
+	} else if tp == "" {
+		tp = "AES"
+	}
+	r, err := packOneData(name, data, tp)
+	if err != nil {
+		return e, err
+	}
+	if algorithm == MixedType {
+		r = mixedEntry(r, tp)
+	}
+	n, err := unpack.EntryHeadSize(tp, algorithm == MixedType)
+	if err != nil {
+		return e, err
+	}
+	e = unpack.TUnpackEntry{Name: name, Type: tp, Head: r[:n], Body: r[n:]}
+	return e, err
+}
+
+// checkVolume function
+// return error when dest is a volume part, dest which does not exist is ok
+func checkVolume(dest string) (err error) {
+	file, err := os.Open(dest)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		log.Println("Error open file:", err)
+		return err
+	}
+	defer file.Close()
+	head := make([]byte, len(VolumeMagic))
+	n, _ := file.Read(head)
+	if unpack.IsVolume(head[:n]) {
+		err = errors.New("Volume part is not supported by edit, please join volume first.")
+	}
+	return err
+}
+
+// findEntry function
+// return the index of entry with name, -1 if not found
+func findEntry(entries []unpack.TUnpackEntry, name string) int {
+	for k, v := range entries {
+		if v.Name == name {
+			return k
+		}
+	}
+	return -1
+}
+
+// writePackage function
+// it will fill a new header for entries and replace dest file through a temporary file
+// format version, uuid, author and header extension of the parsed package p are kept,
+// except the manifest which could not describe the edited entries any more
+// entry table of sealed package is sealed again by key, entry headers are not written before bodies then
+// legacy package which records file name in header gets a new uuid
+// the temporary file is renamed at last, so reader never see a half written package
+// parity file of dest is generated again with its old percent, it is removed when its header could not be read
+// volume part is refused, writing the whole package over one part would leave the other parts stale
+func writePackage(dest string, p *unpack.TUnpackPackage, entries []unpack.TUnpackEntry, key []byte) (err error) {
+	// first, fill the header
+	err = checkVolume(dest)
+	if err != nil {
+		return err
+	}
+	head := TPackAES{}
+	head.Name = make([]byte, 32)
+	head.Author = make([]byte, 16)
+	head.Type = make([]byte, 8)
+	head.Number = make([]byte, 4)
+	id := []byte(p.UUID)
+	if p.UUID == "" {
+		id, err = NewUUID()
+		if err != nil {
+			log.Println("Error new package uuid:", err)
+			return err
+		}
+	}
+	BytesCopy(&(head.Name), id)
+	BytesCopy(&(head.Author), []byte(p.Author))
+	BytesCopy(&(head.Type), []byte(p.Type))
+	if p.Version == 2 {
+		head.Type[PackVersionOffset-48] = PackVersion2
+	}
+	if !p.Extend.Sealed {
+		BytesCopy(&(head.Number), IntToBytes(len(entries)))
+	}
+	r := [][]byte{head.Name, head.Author, head.Type, head.Number}
+	// second, seal the entry table
+	var table [][]byte
+	for _, v := range entries {
+		if p.Extend.Sealed {
+			table = append(table, v.Head)
+			r = append(r, v.Body)
+		} else {
+			r = append(r, v.Head, v.Body)
+		}
+	}
+	if p.Extend.Sealed {
+		if key == nil {
+			return unpack.ErrSealedPackage
+		}
+		p.Extend.Table, err = SealBytes(key, bytes.Join(table, []byte("")), bytes.Join(r[:4], []byte("")))
+		if err != nil {
+			log.Println("Error seal entry table:", err)
+			return err
+		}
+	}
+	// third, encode the header extension
+	if p.Version == 2 {
+		var buf bytes.Buffer
+		p.Extend.Manifest = nil
+		err = GobEncode(&buf, p.Extend)
+		if err != nil {
+			return err
+		}
+		r = append(r[:4], append([][]byte{IntToBytes(buf.Len()), buf.Bytes()}, r[4:]...)...)
+	}
+	// fourth, write to temporary file
+	data := bytes.Join(r, []byte(""))
+	tmp := dest + ".tmp"
+	err = ioutil.WriteFile(tmp, data, 0644)
+	if err != nil {
+		log.Println("Error write package file:", err)
+		return err
+	}
+	// finally, replace dest file and its parity file
+	percent, err := parityPercent(dest)
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	err = os.Rename(tmp, dest)
+	if err != nil {
+		log.Println("Error rename package file:", err)
+		_ = os.Remove(tmp)
+		return err
+	}
+	if percent > 0 {
+		return writeParity(data, dest, percent)
+	}
+	return err
+}
@@ -0,0 +1,311 @@
+package pack
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"qora/unpack"
+	"strings"
+	"testing"
+)
+
+// TestAppend function
+func TestAppend(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "file_edit.pak")
+	err := PackAES([]string{"../test/data/pack/file_1.txt", "../test/data/pack/file_2.txt"}, dest)
+	if err != nil {
+		t.Fatal("Error Pack AES:", err)
+	}
+	p, err := unpack.ParsePackage(dest)
+	if err != nil {
+		t.Fatal("Error Parse Package:", err)
+	}
+	err = Append(dest, []string{"../test/data/pack/file_3.txt"})
+	if err != nil {
+		t.Fatal("Error Append:", err)
+	}
+	pp, err := unpack.ParsePackage(dest)
+	if err != nil {
+		t.Fatal("Error Parse Package:", err)
+	}
+	if pp.Number != 3 || pp.Entries[2].Name != "file_3.txt" {
+		t.Fatal("Error Append entry:", pp.Number)
+	}
+	if !bytes.Equal(pp.Entries[0].Body, p.Entries[0].Body) {
+		t.Fatal("Error Append should copy ciphertext verbatim")
+	}
+	r, err := unpack.DecryptEntry(pp.Entries[2])
+	if err != nil {
+		t.Fatal("Error Decrypt Entry:", err)
+	}
+	s, _ := ioutil.ReadFile("../test/data/pack/file_3.txt")
+	if !bytes.Equal(r, s) {
+		t.Fatal("Error Append entry content")
+	}
+	err = Append(dest, []string{"../test/data/pack/file_3.txt"})
+	if err == nil {
+		t.Fatal("Error Append should reject duplicate entry")
+	}
+}
+
+// TestRemove function
+func TestRemove(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "file_edit.pak")
+	err := PackDES([]string{"../test/data/pack/file_1.txt", "../test/data/pack/file_2.txt", "../test/data/pack/file_3.txt"}, dest)
+	if err != nil {
+		t.Fatal("Error Pack DES:", err)
+	}
+	err = Remove(dest, []string{"file_2.txt"})
+	if err != nil {
+		t.Fatal("Error Remove:", err)
+	}
+	p, err := unpack.ParsePackage(dest)
+	if err != nil {
+		t.Fatal("Error Parse Package:", err)
+	}
+	if p.Number != 2 || p.Entries[0].Name != "file_1.txt" || p.Entries[1].Name != "file_3.txt" {
+		t.Fatal("Error Remove entry:", p.Number)
+	}
+	err = Remove(dest, []string{"file_2.txt"})
+	if err == nil {
+		t.Fatal("Error Remove should reject missing entry")
+	}
+}
+
+// TestReplace function
+func TestReplace(t *testing.T) {
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "file_edit.pak")
+	err := PackAES([]string{"../test/data/pack/file_1.txt", "../test/data/pack/file_2.txt"}, dest)
+	if err != nil {
+		t.Fatal("Error Pack AES:", err)
+	}
+	src := filepath.Join(dir, "file_1.txt")
+	err = ioutil.WriteFile(src, []byte("hello,replace!"), 0644)
+	if err != nil {
+		t.Fatal("Error Write File:", err)
+	}
+	err = Replace(dest, []string{src})
+	if err != nil {
+		t.Fatal("Error Replace:", err)
+	}
+	p, err := unpack.ParsePackage(dest)
+	if err != nil {
+		t.Fatal("Error Parse Package:", err)
+	}
+	r, err := unpack.DecryptEntry(p.Entries[0])
+	if err != nil || string(r) != "hello,replace!" {
+		t.Fatal("Error Replace entry content:", err)
+	}
+}
+
+// TestMerge function
+func TestMerge(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.pak")
+	b := filepath.Join(dir, "b.pak")
+	dest := filepath.Join(dir, "merge.pak")
+	err := Pack3DES([]string{"../test/data/pack/file_1.txt"}, a)
+	if err != nil {
+		t.Fatal("Error Pack 3DES:", err)
+	}
+	err = Pack3DES([]string{"../test/data/pack/file_2.txt", "../test/data/pack/file_3.txt"}, b)
+	if err != nil {
+		t.Fatal("Error Pack 3DES:", err)
+	}
+	err = Merge(dest, a, b)
+	if err != nil {
+		t.Fatal("Error Merge:", err)
+	}
+	err = unpack.Unpack(dest, dir+"/")
+	if err != nil {
+		t.Fatal("Error Unpack:", err)
+	}
+	r, _ := ioutil.ReadFile(filepath.Join(dir, "file_3.txt"))
+	s, _ := ioutil.ReadFile("../test/data/pack/file_3.txt")
+	if !bytes.Equal(r, s) {
+		t.Fatal("Error Merge entry content")
+	}
+	err = Merge(dest, a, a)
+	if err == nil {
+		t.Fatal("Error Merge should reject duplicate entry")
+	}
+}
+
+// TestMergeWithOption function
+func TestMergeWithOption(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.pak")
+	b := filepath.Join(dir, "b.pak")
+	dest := filepath.Join(dir, "merge.pak")
+	err := PackWithOption([]string{"../test/data/pack/file_1.txt"}, a, "AES", PackOption{Meta: TPackMeta{Author: "source", Comment: "first"}, MetaDigest: true})
+	if err != nil {
+		t.Fatal("Error Pack With Option:", err)
+	}
+	err = PackWithOption([]string{"../test/data/pack/file_2.txt"}, b, "AES", PackOption{})
+	if err != nil {
+		t.Fatal("Error Pack With Option:", err)
+	}
+	err = Merge(dest, a, b)
+	if err != nil {
+		t.Fatal("Error Merge:", err)
+	}
+	pa, _ := unpack.ParsePackage(a)
+	p, err := unpack.ParsePackage(dest)
+	if err != nil || p.Version != 2 || p.UUID == pa.UUID || p.Author != "Alopex6414" {
+		t.Fatal("Error Merge header:", err)
+	}
+	if p.Meta == nil || p.Meta.Comment != "" || p.Meta.Created.Equal(pa.Meta.Created) || len(p.Extend.MetaDigest) != 0 {
+		t.Fatal("Error Merge should not inherit metadata of source:", p.Meta)
+	}
+	err = MergeWithOption(dest, []string{b, a}, EditOption{Meta: TPackMeta{Author: "merger", Comment: "merged"}, MetaDigest: true})
+	if err != nil {
+		t.Fatal("Error Merge With Option:", err)
+	}
+	p, err = unpack.ParsePackage(dest)
+	if err != nil || p.Author != "merger" || p.Meta.Comment != "merged" || !p.Meta.Verified || p.Number != 2 || p.Entries[0].Name != "file_2.txt" {
+		t.Fatal("Error Merge With Option metadata:", err)
+	}
+}
+
+// TestLockPackage function
+func TestLockPackage(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "file_edit.pak")
+	unlock, err := LockPackage(dest)
+	if err != nil {
+		t.Fatal("Error Lock Package:", err)
+	}
+	err = unlock()
+	if err != nil {
+		t.Fatal("Error Unlock Package:", err)
+	}
+	_, err = LockPackage(dest)
+	if err != nil {
+		t.Fatal("Error Lock Package again:", err)
+	}
+}
+
+// TestAppendV2 function
+func TestAppendV2(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "file_edit.pak")
+	err := PackWithOption([]string{"../test/data/pack/file_1.txt"}, dest, "AES", PackOption{})
+	if err != nil {
+		t.Fatal("Error Pack With Option:", err)
+	}
+	err = Append(dest, []string{"../test/data/pack/file_2.txt"})
+	if err != nil {
+		t.Fatal("Error Append:", err)
+	}
+	p, err := unpack.ParsePackage(dest)
+	if err != nil || p.Version != 2 || p.Number != 2 {
+		t.Fatal("Error Append should keep v2 format:", err)
+	}
+	err = PackWithOption([]string{"../test/data/pack/file_1.txt"}, dest, "AES", PackOption{TableKey: []byte("Satellite-266414")})
+	if err != nil {
+		t.Fatal("Error Pack With Option:", err)
+	}
+	err = Append(dest, []string{"../test/data/pack/file_2.txt"})
+	if err == nil {
+		t.Fatal("Error Append should reject sealed package")
+	}
+}
+
+// TestEditSealed function
+func TestEditSealed(t *testing.T) {
+	dir := t.TempDir()
+	key := []byte("Satellite-266414")
+	dest := filepath.Join(dir, "file_sealed.pak")
+	opt := EditOption{TableKey: key}
+	err := PackWithOption([]string{"../test/data/pack/file_1.txt", "../test/data/pack/file_2.txt"}, dest, "AES", PackOption{TableKey: key, MetaDigest: true})
+	if err != nil {
+		t.Fatal("Error Pack With Option:", err)
+	}
+	err = Append(dest, []string{"../test/data/pack/file_3.txt"})
+	if err != unpack.ErrSealedPackage {
+		t.Fatal("Error Append: sealed package should need key", err)
+	}
+	err = AppendWithOption(dest, []string{"../test/data/pack/file_3.txt"}, opt)
+	if err != nil {
+		t.Fatal("Error Append With Option:", err)
+	}
+	err = RemoveWithOption(dest, []string{"file_1.txt"}, opt)
+	if err != nil {
+		t.Fatal("Error Remove With Option:", err)
+	}
+	err = ReplaceWithOption(dest, []string{"../test/data/pack/file_3.txt"}, opt)
+	if err != nil {
+		t.Fatal("Error Replace With Option:", err)
+	}
+	// entry table is still sealed, names are not visible without key
+	data, _ := ioutil.ReadFile(dest)
+	if bytes.Contains(data, []byte("file_3.txt")) {
+		t.Fatal("Error Edit: entry name is plain")
+	}
+	_, err = unpack.ParsePackage(dest)
+	if err != unpack.ErrSealedPackage {
+		t.Fatal("Error Edit: edited package should be sealed", err)
+	}
+	p, err := unpack.ParsePackageWithOption(dest, unpack.UnpackOption{TableKey: key})
+	if err != nil || p.Number != 2 || p.Entries[0].Name != "file_2.txt" || p.Entries[1].Name != "file_3.txt" || !p.Meta.Verified {
+		t.Fatal("Error Edit sealed package:", err)
+	}
+	r, err := unpack.UnpackAllToMemoryWithOption(dest, unpack.UnpackOption{TableKey: key})
+	if err != nil {
+		t.Fatal("Error Unpack All To Memory With Option:", err)
+	}
+	for _, v := range []string{"file_2.txt", "file_3.txt"} {
+		s, _ := ioutil.ReadFile("../test/data/pack/" + v)
+		if !bytes.Equal(r[v], s) {
+			t.Fatal("Error Edit sealed entry content:", v)
+		}
+	}
+	// merged package of sealed sources is sealed by the key as well
+	a := filepath.Join(dir, "a.pak")
+	err = PackWithOption([]string{"../test/data/pack/file_1.txt"}, a, "AES", PackOption{TableKey: key})
+	if err != nil {
+		t.Fatal("Error Pack With Option:", err)
+	}
+	merged := filepath.Join(dir, "merge.pak")
+	err = MergeWithOption(merged, []string{a, dest}, EditOption{TableKey: key, MetaDigest: true})
+	if err != nil {
+		t.Fatal("Error Merge With Option:", err)
+	}
+	p, err = unpack.ParsePackageWithOption(merged, unpack.UnpackOption{TableKey: key})
+	if err != nil || !p.Extend.Sealed || p.Number != 3 || !p.Meta.Verified || !p.Extend.MetaKeyed {
+		t.Fatal("Error Merge sealed package:", err)
+	}
+}
+
+// TestEditVolume function
+func TestEditVolume(t *testing.T) {
+	entries := []Entry{{Name: "a.txt", Bytes: bytes.Repeat([]byte("qora"), 1000)}, {Name: "b.txt", Bytes: []byte("hello")}}
+	dir := t.TempDir()
+	err := PackEntriesWithOption(entries, filepath.Join(dir, "file_volume.pak"), "AES", PackOption{VolumeSize: 1024})
+	if err != nil {
+		t.Fatal("Error Pack Entries With Option:", err)
+	}
+	parts, _ := filepath.Glob(filepath.Join(dir, "file_volume.q*"))
+	origin, _ := ioutil.ReadFile(parts[0])
+	_ = ioutil.WriteFile(filepath.Join(dir, "c.txt"), []byte("charlie"), 0644)
+	src := filepath.Join(dir, "b.txt")
+	_ = ioutil.WriteFile(src, []byte("bravo"), 0644)
+	for _, f := range []func() error{
+		func() error { return Append(parts[0], []string{filepath.Join(dir, "c.txt")}) },
+		func() error { return Remove(parts[0], []string{"b.txt"}) },
+		func() error { return Replace(parts[1], []string{src}) },
+		func() error { return Merge(parts[0], parts[1]) },
+	} {
+		err = f()
+		if err == nil || !strings.Contains(err.Error(), "Volume part") {
+			t.Fatal("Error edit volume part should fail:", err)
+		}
+	}
+	data, _ := ioutil.ReadFile(parts[0])
+	if !bytes.Equal(data, origin) {
+		t.Fatal("Error edit volume part: part is changed")
+	}
+	r, err := unpack.UnpackAllToMemory(parts[0])
+	if err != nil || len(r) != 2 {
+		t.Fatal("Error Unpack volume after refused edit:", err)
+	}
+}
@@ -0,0 +1,301 @@
+package pack
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	. "qora/global"
+	"qora/unpack"
+	. "qora/utils"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// PackEntries function
+// input entry list, dest package path and algorithm which used in pack, return error info
+// it is common with function Pack, just the entry data come from memory or reader instead of files
+// so plain data never need to be written into temporary files
+// algorithm now support 'AES', 'DES', '3DES', 'RSA' and 'BASE64', you can send both up case and low case
+// return err indicate the success or failure function execute
+func PackEntries(entries []Entry, dest string, algorithm string) (err error) {
+	var buf bytes.Buffer
+	err = PackToWriter(entries, &buf, algorithm)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(dest, buf.Bytes(), 0644)
+	if err != nil {
+		log.Println("Error write package file:", err)
+	}
+	return err
+}
+
+// PackToWriter function
+// input entry list, output writer and algorithm which used in pack, return error info
+// a random uuid is written in package header as package identity, so package could be saved as any file name
+// algorithm now support 'AES', 'DES', '3DES', 'RSA' and 'BASE64', you can send both up case and low case
+// return err indicate the success or failure function execute
+func PackToWriter(entries []Entry, w io.Writer, algorithm string) (err error) {
+	return packLegacy(entries, w, algorithm, PackAuthor)
+}
+
+// packLegacy function
+// it is common with function PackToWriter, author is written in header, it is cut to 16 bytes
+func packLegacy(entries []Entry, w io.Writer, algorithm string, author string) (err error) {
+	// start multi-cpu
+	core := runtime.NumCPU()
+	runtime.GOMAXPROCS(core)
+	// clear global variable
+	atomic.StoreInt64(&Done, 0)
+	// first, check the entry names and algorithms
+	err = checkEntries(entries)
+	if err != nil {
+		return err
+	}
+	algorithm = packType(algorithm)
+	if algorithm == MixedType || algorithm == GCMType {
+		s := fmt.Sprintf("%v package needs format v2, please pack it with option.", algorithm)
+		err = errors.New(s)
+		return err
+	}
+	types, err := entryTypes(entries, algorithm)
+	if err != nil {
+		return err
+	}
+	// second, pack every entry through goroutine
+	rr, _, err := packEntries(entries, algorithm, types, nil, nil)
+	if err != nil {
+		return err
+	}
+	r := append(make([][]byte, 4), rr...)
+	// third, fill the header
+	head := TPackAES{}
+	head.Name = make([]byte, 32)
+	head.Author = make([]byte, 16)
+	head.Type = make([]byte, 8)
+	head.Number = make([]byte, 4)
+	id, err := NewUUID()
+	if err != nil {
+		log.Println("Error new package uuid:", err)
+		return err
+	}
+	BytesCopy(&(head.Name), id)
+	BytesCopy(&(head.Author), []byte(truncate(author, 16)))
+	BytesCopy(&(head.Type), []byte(algorithm))
+	BytesCopy(&(head.Number), IntToBytes(len(entries)))
+	r[0] = head.Name
+	r[1] = head.Author
+	r[2] = head.Type
+	r[3] = head.Number
+	// finally, write to writer
+	_, err = w.Write(bytes.Join(r, []byte("")))
+	if err != nil {
+		log.Println("Error write package:", err)
+	}
+	return err
+}
+
+// packEntries function
+// it will pack every entry through goroutine, entry data is padded first when pad is not nil
+// the origin size in entry header always record the size before padding
+// entry key is derived from seed and entry data when seed is not nil, otherwise it is random
+// types is the algorithm of every entry, entry of mixed package records it behind entry name
+// return the packed entries and their manifest records
+func packEntries(entries []Entry, algorithm string, types []string, pad func(int) int, seed []byte) (r [][]byte, files []TPackManifestFile, err error) {
+	wg := &sync.WaitGroup{}
+	r = make([][]byte, len(entries))
+	files = make([]TPackManifestFile, len(entries))
+	e := make([]error, len(entries))
+	for k, v := range entries {
+		wg.Add(1)
+		go func(k int, v Entry) {
+			defer wg.Done()
+			data, err := entryData(v)
+			if err != nil {
+				e[k] = err
+				return
+			}
+			d := sha256.Sum256(data)
+			files[k] = TPackManifestFile{Name: v.Name, Size: int64(len(data)), ModTime: v.ModTime, Mode: v.Mode, Digest: d[:]}
+			size := len(data)
+			if pad != nil {
+				data = append(data[:size:size], make([]byte, pad(size)-size)...)
+			}
+			if seed != nil {
+				r[k], e[k] = packOneSeedData(v.Name, data, types[k], seed, d[:])
+			} else {
+				r[k], e[k] = packOneData(v.Name, data, types[k])
+			}
+			if e[k] == nil && pad != nil {
+				n, _ := unpack.EntryKeySize(types[k])
+				copy(r[k][32+n:36+n], IntToBytes(size))
+			}
+			if e[k] == nil && algorithm == MixedType {
+				r[k] = mixedEntry(r[k], types[k])
+			}
+		}(k, v)
+	}
+	wg.Wait()
+	// check goroutine whether success or not
+	for k, v := range e {
+		if v != nil {
+			s := fmt.Sprintf("Error %v pack one entry: %v, %v", algorithm, entries[k].Name, v)
+			err = errors.New(s)
+			return r, files, err
+		}
+	}
+	return r, files, err
+}
+
+// packOneData function
+// it will call the pack one data function base on algorithm
+func packOneData(name string, data []byte, algorithm string) (r []byte, err error) {
+	switch algorithm {
+	case "AES", "aes":
+		r, err = PackAESOneData(name, data)
+	case "DES", "des":
+		r, err = PackDESOneData(name, data)
+	case "3DES", "3des":
+		r, err = Pack3DESOneData(name, data)
+	case "RSA", "rsa":
+		r, err = PackRSAOneData(name, data)
+	case "BASE64", "base64":
+		var s string
+		s, err = PackBase64OneData(name, data)
+		r = []byte(s)
+	case "AESGCM", "aesgcm":
+		r, err = PackAESGCMOneData(name, data)
+	default:
+		s := fmt.Sprint("Undefined pack algorithm.")
+		err = errors.New(s)
+	}
+	if err == nil && len(r) == 0 {
+		s := fmt.Sprintf("Error %v pack one entry: %v", algorithm, name)
+		err = errors.New(s)
+	}
+	return r, err
+}
+
+// packOneSeedData function
+// it is common with function packOneData, the entry key is derived from seed and digest of entry data
+// rsa is not supported, because its key generation and padding are random
+func packOneSeedData(name string, data []byte, algorithm string, seed []byte, digest []byte) (r []byte, err error) {
+	size, err := unpack.EntryKeySize(algorithm)
+	if err != nil {
+		return r, err
+	}
+	key, err := seedBytes(seed, digest, "qora entry key "+name, size)
+	if err != nil {
+		return r, err
+	}
+	switch algorithm {
+	case "AES":
+		r, err = packAESOneData(name, data, key)
+	case "DES":
+		r, err = packDESOneData(name, data, key)
+	case "3DES":
+		r, err = pack3DESOneData(name, data, key)
+	case "BASE64":
+		r, err = packOneData(name, data, algorithm)
+	case "AESGCM":
+		r, err = packAESGCMOneData(name, data, key)
+	default:
+		s := fmt.Sprintf("Deterministic package does not support algorithm: %v", algorithm)
+		err = errors.New(s)
+	}
+	return r, err
+}
+
+// mixedEntry function
+// it inserts entry algorithm in 8 bytes behind entry name, which is the entry layout of mixed package
+func mixedEntry(r []byte, algorithm string) []byte {
+	t := make([]byte, 8)
+	BytesCopy(&t, []byte(algorithm))
+	return bytes.Join([][]byte{r[:32], t, r[32:]}, []byte(""))
+}
+
+// entryTypes function
+// return the algorithm of every entry, empty entry algorithm of mixed package is 'AES'
+func entryTypes(entries []Entry, algorithm string) (types []string, err error) {
+	types = make([]string, len(entries))
+	for k, v := range entries {
+		t := packType(v.Algorithm)
+		switch {
+		case algorithm == MixedType && t == "":
+			t = "AES"
+		case algorithm != MixedType && (t == "" || t == algorithm):
+			t = algorithm
+		case algorithm != MixedType:
+			s := fmt.Sprintf("Entry algorithm %v differs from package algorithm %v, please pack it as mixed package: %v", t, algorithm, v.Name)
+			err = errors.New(s)
+			return types, err
+		}
+		_, err = unpack.EntryKeySize(t)
+		if err != nil {
+			return types, err
+		}
+		types[k] = t
+	}
+	return types, err
+}
+
+// packType function
+// return the algorithm name which written in package header
+func packType(algorithm string) string {
+	switch algorithm {
+	case "aes":
+		return "AES"
+	case "des":
+		return "DES"
+	case "3des":
+		return "3DES"
+	case "rsa":
+		return "RSA"
+	case "base64":
+		return "BASE64"
+	case "mixed":
+		return MixedType
+	case "aesgcm":
+		return GCMType
+	}
+	return algorithm
+}
+
+// checkEntries function
+// entry name should be a plain file name, since unpack joins it with dest path
+func checkEntries(entries []Entry) (err error) {
+	names := make(map[string]bool)
+	for _, v := range entries {
+		if v.Name == "" || v.Name == "." || v.Name == ".." || filepath.Base(v.Name) != v.Name || len([]byte(v.Name)) > 32 {
+			s := fmt.Sprintf("Invalid entry name: %q", v.Name)
+			err = errors.New(s)
+			return err
+		}
+		if names[v.Name] {
+			s := fmt.Sprintf("Package entry already exist: %v", v.Name)
+			err = errors.New(s)
+			return err
+		}
+		names[v.Name] = true
+	}
+	return err
+}
+
+// entryData function
+// return the entry data from bytes or reader
+func entryData(e Entry) (data []byte, err error) {
+	if e.Bytes != nil || e.Reader == nil {
+		return e.Bytes, err
+	}
+	data, err = ioutil.ReadAll(e.Reader)
+	if err != nil {
+		log.Println("Error read entry:", err)
+	}
+	return data, err
+}
@@ -0,0 +1,55 @@
+package pack
+
+import (
+	"bytes"
+	"path/filepath"
+	"qora/unpack"
+	"strings"
+	"testing"
+)
+
+// TestPackEntries function
+func TestPackEntries(t *testing.T) {
+	entries := []Entry{
+		{Name: "hello.txt", Bytes: []byte("hello,world!")},
+		{Name: "reader.txt", Reader: strings.NewReader(strings.Repeat("qora", 100))},
+		{Name: "empty.txt", Bytes: []byte{}},
+	}
+	for _, algorithm := range []string{"AES", "DES", "3DES", "RSA", "BASE64"} {
+		dest := filepath.Join(t.TempDir(), "file_entry.pak")
+		entries[1].Reader = strings.NewReader(strings.Repeat("qora", 100))
+		err := PackEntries(entries, dest, algorithm)
+		if err != nil {
+			t.Fatal("Error Pack Entries:", algorithm, err)
+		}
+		r, err := unpack.UnpackAllToMemory(dest)
+		if err != nil {
+			t.Fatal("Error Unpack All To Memory:", algorithm, err)
+		}
+		if string(r["hello.txt"]) != "hello,world!" || string(r["reader.txt"]) != strings.Repeat("qora", 100) || len(r["empty.txt"]) != 0 {
+			t.Fatal("Error Pack Entries content:", algorithm)
+		}
+	}
+}
+
+// TestPackToWriter function
+func TestPackToWriter(t *testing.T) {
+	var buf bytes.Buffer
+	entries := []Entry{{Name: "hello.txt", Bytes: []byte("hello,world!")}}
+	err := PackToWriter(entries, &buf, "aes")
+	if err != nil {
+		t.Fatal("Error Pack To Writer:", err)
+	}
+	p, err := unpack.ParsePackageBytes(buf.Bytes())
+	if err != nil || p.Type != "AES" || p.UUID == "" || p.Name != p.UUID {
+		t.Fatal("Error Pack To Writer header:", err)
+	}
+	err = PackToWriter([]Entry{{Name: "../evil.txt", Bytes: []byte("x")}}, &buf, "aes")
+	if err == nil {
+		t.Fatal("Error Pack To Writer should reject path in entry name")
+	}
+	err = PackToWriter([]Entry{{Name: "a.txt"}, {Name: "a.txt"}}, &buf, "aes")
+	if err == nil {
+		t.Fatal("Error Pack To Writer should reject duplicate entry")
+	}
+}
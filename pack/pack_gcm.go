@@ -0,0 +1,69 @@
+package pack
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"log"
+	. "qora/utils"
+)
+
+// SealBytes function
+// input aes key, plain data and additional data, output sealed data
+// it encrypts and authenticates src with aes-gcm, a random nonce is put in front of cipher text
+// key length should be 16, 24 or 32
+// aad is authenticated but not encrypted, it can be nil
+// return err indicate the success or failure function execute
+func SealBytes(key, src, aad []byte) (dest []byte, err error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return dest, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		log.Println("Error generate random nonce:", err)
+		return dest, err
+	}
+	dest = gcm.Seal(nonce, nonce, src, aad)
+	return dest, err
+}
+
+// sealBytesSeed function
+// it is common with function SealBytes, the nonce is derived from seed, src and aad instead of random when seed is not nil
+// nonce only repeats when src and aad repeat, then the sealed data is the same as well
+func sealBytesSeed(key, src, aad, seed []byte) (dest []byte, err error) {
+	if seed == nil {
+		return SealBytes(key, src, aad)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return dest, err
+	}
+	h := sha256.New()
+	h.Write(IntToBytes(len(aad)))
+	h.Write(aad)
+	h.Write(src)
+	nonce, err := seedBytes(seed, h.Sum(nil), "qora seal nonce", gcm.NonceSize())
+	if err != nil {
+		return dest, err
+	}
+	dest = gcm.Seal(nonce, nonce, src, aad)
+	return dest, err
+}
+
+// newGCM function
+// it creates aes-gcm aead with key
+func newGCM(key []byte) (gcm cipher.AEAD, err error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		log.Println("Error key length:", err)
+		return gcm, err
+	}
+	gcm, err = cipher.NewGCM(block)
+	if err != nil {
+		log.Println("Error create gcm:", err)
+	}
+	return gcm, err
+}
@@ -0,0 +1,29 @@
+package pack
+
+import (
+	"bytes"
+	"qora/unpack"
+	"testing"
+)
+
+// TestSealBytes function
+func TestSealBytes(t *testing.T) {
+	key := []byte("Satellite-266414")
+	src := []byte("hello,world!")
+	r, err := SealBytes(key, src, nil)
+	if err != nil {
+		t.Fatal("Error Seal Bytes:", err)
+	}
+	rr, err := SealBytes(key, src, nil)
+	if err != nil || bytes.Equal(r, rr) {
+		t.Fatal("Error Seal Bytes: nonce should be random")
+	}
+	s, err := unpack.OpenBytes(key, r, nil)
+	if err != nil || !bytes.Equal(s, src) {
+		t.Fatal("Error Open Bytes:", err)
+	}
+	_, err = SealBytes([]byte("short"), src, nil)
+	if err == nil {
+		t.Fatal("Error Seal Bytes: invalid key length should fail")
+	}
+}
@@ -1,8 +1,27 @@
 package pack
 
 import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"github.com/dchest/blake2b"
+	"golang.org/x/crypto/blake2s"
+	"golang.org/x/crypto/sha3"
+	"hash"
+	"hash/crc32"
+	"hash/crc64"
+	"io"
+	"log"
+	"os"
+	. "qora/global"
+	"strconv"
+	"strings"
 )
 
 // PackHashEncode function
@@ -10,7 +29,14 @@ import (
 // this function will base on algorithm to call correspond function
 // src string which you want to encode by hash algorithm, like 'hello,world!' or '../test/data/file.txt'
 // dest string is the result of hash value, like 'C:\\package.pak' or '../test/data/package.pak'
-// algorithm now support 'md5', 'sha1', 'sha256', 'sha512', you can send both up case and low case
+// algorithm now support 'md5', 'sha1', 'sha224', 'sha256', 'sha384', 'sha512', 'sha512_256', 'sha3_256', 'sha3_512',
+// 'shake128', 'shake256', 'blake2b128', 'blake2b256', 'blake2b512', 'blake2s256', 'crc32c' and 'crc64',
+// you can send both up case and low case
+// shake output is 256 bits for 'shake128' and 512 bits for 'shake256', other output bits is set like 'shake256_1024'
+// crc is fast but not cryptographic, it only detects accidental change
+// 'hmac_sha1', 'hmac_sha256' and 'hmac_sha512' use empty key here, they are kept for compatibility, use HMAC with real key
+// 'argon2id', 'scrypt' and 'bcrypt' are password hash with random salt and default params, dest is PHC string, see HashPassword
+// 'ssdeep' or 'ctph' is fuzzy hash text like '3:hMCEpFn:hur', similar data gives similar hash, see CTPHCompare
 // return err indicate the success or failure function execute
 func PackHashEncode(src string, algorithm string) (dest string, err error) {
 	switch algorithm {
@@ -34,9 +60,28 @@ func PackHashEncode(src string, algorithm string) (dest string, err error) {
 		dest = HMAC_SHA256(src, "")
 	case "HMAC_SHA512", "hmac_sha512":
 		dest = HMAC_SHA512(src, "")
+	case "SHA224", "sha224":
+		dest = SHA224Encode(src)
+	case "SHA384", "sha384":
+		dest = SHA384Encode(src)
+	case "SHA512_256", "sha512_256":
+		dest = SHA512_256Encode(src)
+	case "SHA3_256", "sha3_256":
+		dest = SHA3Encode256(src)
+	case "SHA3_512", "sha3_512":
+		dest = SHA3Encode512(src)
+	case "BLAKE2S256", "blake2s256":
+		dest = Blake2sEncode256(src)
+	case "CRC32C", "crc32c":
+		dest = CRC32CEncode(src)
+	case "CRC64", "crc64":
+		dest = CRC64Encode(src)
+	case "ARGON2ID", "argon2id", "SCRYPT", "scrypt", "BCRYPT", "bcrypt":
+		dest, err = HashPassword(algorithm, src, PasswordParams{})
+	case "SSDEEP", "ssdeep", "CTPH", "ctph":
+		dest = CTPHEncode(src)
 	default:
-		s := fmt.Sprint("Undefined hash algorithm.")
-		err = errors.New(s)
+		dest, err = hashEncode(src, algorithm, nil)
 	}
 	return dest, err
 }
@@ -46,7 +91,9 @@ func PackHashEncode(src string, algorithm string) (dest string, err error) {
 // this function will base on algorithm to call correspond function
 // src string which you want to encode by hash algorithm, like 'hello,world!' or '../test/data/file.txt'
 // dest string is the result of hash value, like 'C:\\package.pak' or '../test/data/package.pak'
-// algorithm now support 'md5', 'sha1', 'sha256', 'sha512', you can send both up case and low case
+// algorithm now support all algorithms of PackHashEncode, you can send both up case and low case
+// the digest is compared in constant time, password hash dest is checked with its own salt and params,
+// its cost should not be over the default params which PackHashEncode uses, see VerifyPasswordWithLimit
 // return b indicate check pass or failed, err indicate the success or failure function execute
 func PackHashCheck(src string, dest string, algorithm string) (b bool, err error) {
 	switch algorithm {
@@ -64,9 +111,220 @@ func PackHashCheck(src string, dest string, algorithm string) (b bool, err error
 		b = Blake2bCheck256(src, dest)
 	case "BLAKE2B512", "blake2b512":
 		b = Blake2bCheck512(src, dest)
+	case "HMAC_SHA1", "hmac_sha1":
+		b = hashEqual(HMAC_SHA1(src, ""), dest)
+	case "HMAC_SHA256", "hmac_sha256":
+		b = hashEqual(HMAC_SHA256(src, ""), dest)
+	case "HMAC_SHA512", "hmac_sha512":
+		b = hashEqual(HMAC_SHA512(src, ""), dest)
+	case "SHA224", "sha224":
+		b = SHA224Check(src, dest)
+	case "SHA384", "sha384":
+		b = SHA384Check(src, dest)
+	case "SHA512_256", "sha512_256":
+		b = SHA512_256Check(src, dest)
+	case "SHA3_256", "sha3_256":
+		b = SHA3Check256(src, dest)
+	case "SHA3_512", "sha3_512":
+		b = SHA3Check512(src, dest)
+	case "BLAKE2S256", "blake2s256":
+		b = Blake2sCheck256(src, dest)
+	case "CRC32C", "crc32c":
+		b = CRC32CCheck(src, dest)
+	case "CRC64", "crc64":
+		b = CRC64Check(src, dest)
+	case "ARGON2ID", "argon2id", "SCRYPT", "scrypt", "BCRYPT", "bcrypt":
+		if passwordAlgorithm(dest) == strings.ToLower(algorithm) {
+			b, err = VerifyPasswordWithLimit(dest, src, PasswordParams{})
+		}
+	case "SSDEEP", "ssdeep", "CTPH", "ctph":
+		b = CTPHCheck(src, dest)
 	default:
-		s := fmt.Sprint("Undefined hash algorithm.")
-		err = errors.New(s)
+		var r string
+		r, err = hashEncode(src, algorithm, nil)
+		b = err == nil && hashEqual(r, dest)
 	}
 	return b, err
 }
+
+// PackHashEncodeWithKey function
+// it is common with function PackHashEncode, key is used by keyed algorithm:
+// 'blake2b128', 'blake2b256' and 'blake2b512' are keyed blake2b mac with key no more than 64 bytes,
+// 'blake2s256' is keyed blake2s mac with key no more than 32 bytes, 'hmac_' with hash algorithm like 'hmac_sha3_256' is hmac,
+// other algorithms do not support key
+// return err indicate the success or failure function execute
+func PackHashEncodeWithKey(src string, key string, algorithm string) (dest string, err error) {
+	return hashEncode(src, algorithm, []byte(key))
+}
+
+// PackHashCheckWithKey function
+// it is common with function PackHashCheck, key is used by keyed algorithm, see PackHashEncodeWithKey
+// return b indicate check pass or failed, err indicate the success or failure function execute
+func PackHashCheckWithKey(src string, dest string, key string, algorithm string) (b bool, err error) {
+	r, err := hashEncode(src, algorithm, []byte(key))
+	return err == nil && hashEqual(r, dest), err
+}
+
+// hashEqual function
+// compare two hex digests in constant time, so the time does not leak how many bytes match
+func hashEqual(src string, dest string) bool {
+	return subtle.ConstantTimeCompare([]byte(src), []byte(dest)) == 1
+}
+
+// hashEncode function
+// encode src string output dest string with the hash of algorithm
+func hashEncode(src string, algorithm string, key []byte) (dest string, err error) {
+	h, err := newHash(strings.ToLower(algorithm), key)
+	if err != nil {
+		return dest, err
+	}
+	h.Write([]byte(src))
+	return hashDigest(h), err
+}
+
+// hashDigest function
+// return the hex digest of hash, fuzzy hash is text already
+func hashDigest(h hash.Hash) string {
+	if _, ok := h.(*ctph); ok {
+		return string(h.Sum(nil))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// HashFile function
+// This function is mainly used for hash the large file without reading it into memory.
+// input src file path and algorithms which used in hash, return the hash result and error info
+// src file support both absolute and relative paths, like 'C:\\file.txt' or '../test/data/file.txt'
+// file is read once by fixed buffer, every algorithm is calculated in the same pass, see HashReader
+// return r the hex digest of every algorithm, err indicate the success or failure function execute
+func HashFile(src string, algorithms ...string) (r THashResult, err error) {
+	file, err := os.Open(src)
+	if err != nil {
+		log.Println("Error open file:", err)
+		return r, err
+	}
+	defer file.Close()
+	return HashReader(file, algorithms...)
+}
+
+// HashReader function
+// This function is mainly used for calculate several digests of stream in one pass, like md5, sha256 and blake2b512 together.
+// input src reader and algorithms which used in hash, return the hash result and error info
+// algorithm now support all algorithms of PackHashEncode, you can send both up case and low case,
+// the digest is the same as PackHashEncode of the whole data
+// return r the hex digest of every algorithm keyed by lower case name, err indicate the success or failure function execute
+func HashReader(src io.Reader, algorithms ...string) (r THashResult, err error) {
+	if len(algorithms) == 0 {
+		err = errors.New("No hash algorithm.")
+		return r, err
+	}
+	// first, create the hash of every algorithm
+	hs := make(map[string]hash.Hash, len(algorithms))
+	var ws []io.Writer
+	for _, v := range algorithms {
+		name := strings.ToLower(v)
+		if _, ok := hs[name]; ok {
+			continue
+		}
+		h, err := newHash(name, nil)
+		if err != nil {
+			return r, err
+		}
+		hs[name] = h
+		ws = append(ws, h)
+	}
+	// second, hash the stream by fixed buffer
+	_, err = io.CopyBuffer(io.MultiWriter(ws...), src, make([]byte, HashBufferSize))
+	if err != nil {
+		log.Println("Error read stream:", err)
+		return r, err
+	}
+	// finally, encode the digests
+	r = make(THashResult, len(hs))
+	for k, v := range hs {
+		r[k] = hashDigest(v)
+	}
+	return r, err
+}
+
+// newHash function
+// it returns the hash of lower case algorithm name, it gives the same digest as PackHashEncode
+// key is used by keyed blake2 and hmac, nil key keeps them unkeyed, see PackHashEncodeWithKey
+func newHash(algorithm string, key []byte) (h hash.Hash, err error) {
+	switch algorithm {
+	case "blake2b128", "blake2b256", "blake2b512":
+		size, _ := strconv.Atoi(strings.TrimPrefix(algorithm, "blake2b"))
+		return blake2b.New(&blake2b.Config{Size: uint8(size / 8), Key: key})
+	case "blake2s256":
+		return blake2s.New256(key)
+	}
+	if strings.HasPrefix(algorithm, "hmac_") {
+		f, err := hmacBase(algorithm)
+		if err != nil {
+			return h, err
+		}
+		return hmac.New(f, key), err
+	}
+	if len(key) > 0 {
+		s := fmt.Sprintf("Hash algorithm does not support key: %v", algorithm)
+		err = errors.New(s)
+		return h, err
+	}
+	switch algorithm {
+	case "md5":
+		h = md5.New()
+	case "sha1":
+		h = sha1.New()
+	case "sha224":
+		h = sha256.New224()
+	case "sha256":
+		h = sha256.New()
+	case "sha384":
+		h = sha512.New384()
+	case "sha512":
+		h = sha512.New()
+	case "sha512_256":
+		h = sha512.New512_256()
+	case "sha3_256":
+		h = sha3.New256()
+	case "sha3_512":
+		h = sha3.New512()
+	case "crc32c":
+		h = crc32.New(crc32cTable)
+	case "crc64":
+		h = crc64.New(crc64Table)
+	case "ssdeep", "ctph":
+		h = newCTPH()
+	default:
+		return newShakeHash(algorithm)
+	}
+	return h, err
+}
+
+// newShakeHash function
+// it returns the shake of algorithm name like 'shake128' or 'shake256_1024', the suffix is output bits
+func newShakeHash(algorithm string) (h hash.Hash, err error) {
+	name := strings.SplitN(algorithm, "_", 2)
+	var shake sha3.ShakeHash
+	switch name[0] {
+	case "shake128":
+		shake = sha3.NewShake128()
+	case "shake256":
+		shake = sha3.NewShake256()
+	default:
+		s := fmt.Sprintf("Undefined hash algorithm: %v", algorithm)
+		err = errors.New(s)
+		return h, err
+	}
+	size := 0
+	if len(name) == 2 {
+		bits, e := strconv.Atoi(name[1])
+		if e != nil || bits <= 0 || bits%8 != 0 {
+			s := fmt.Sprintf("Invalid shake output bits: %v", algorithm)
+			err = errors.New(s)
+			return h, err
+		}
+		size = bits / 8
+	}
+	return newShake(shake, size)
+}
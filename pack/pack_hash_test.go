@@ -1,7 +1,10 @@
 package pack
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
+	"strings"
 	"testing"
 )
 
@@ -347,3 +350,110 @@ func BenchmarkPackHashCheckBlake2b512(b *testing.B) {
 		}
 	}
 }
+
+func TestHashFile(t *testing.T) {
+	src := "../test/data/pack/file.txt"
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		t.Fatal("Error read file:", err)
+	}
+	algorithms := []string{"md5", "SHA1", "sha256", "sha512", "blake2b128", "blake2b256", "BLAKE2B512", "hmac_sha1", "hmac_sha256", "hmac_sha512"}
+	r, err := HashFile(src, algorithms...)
+	if err != nil || len(r) != len(algorithms) {
+		t.Fatal("Error hash file:", err)
+	}
+	for _, v := range algorithms {
+		dest, _ := PackHashEncode(string(data), v)
+		if r[strings.ToLower(v)] != dest {
+			t.Fatal("Error hash file result:", v)
+		}
+	}
+	_, err = HashFile(src, "md5", "crc16")
+	if err == nil {
+		t.Fatal("Error hash file: undefined algorithm should fail")
+	}
+}
+
+func TestHashReader(t *testing.T) {
+	data := bytes.Repeat([]byte("hello,world!"), 100000)
+	r, err := HashReader(bytes.NewReader(data), "md5", "sha256", "blake2b512")
+	if err != nil {
+		t.Fatal("Error hash reader:", err)
+	}
+	if r["md5"] != MD5Encode(string(data)) || r["sha256"] != SHA256Encode(string(data)) || r["blake2b512"] != Blake2bEncode512(string(data)) {
+		t.Fatal("Error hash reader result:", r)
+	}
+}
+
+func BenchmarkHashReader(b *testing.B) {
+	data := bytes.Repeat([]byte("hello,world!"), 100000)
+	for i := 0; i < b.N; i++ {
+		_, err := HashReader(bytes.NewReader(data), "md5", "sha256", "blake2b512")
+		if err != nil {
+			b.Fatal("Error hash reader:", err)
+		}
+	}
+}
+
+func TestPackHashEncodeCatalogue(t *testing.T) {
+	src := "hello,world!"
+	vectors := map[string]string{
+		"sha224":       "8ca8306359700b64b25a070da3c042dc8fa6a885427580d2b6d774f4",
+		"SHA384":       "ceff8fdf21cc4e0f5217f7b674af88e5337636728d0d0b87acc28923a206d3a975443197253ceb306a3ff9b8e83f3c5a",
+		"sha512_256":   "85d3c4b1146fea577fdc956104c7a50feb080ad669c575981e9ddc5befdfa2c9",
+		"sha3_256":     "a2d2e46e20c995e295fadc00839288d74dd85b8feef8b778042427ab8ff6a5c5",
+		"SHA3_512":     "4ed8dc6739e8edaa481d548e7f93d7a6c63d833a75bcfbd615fd932c97e6f357316e10d488778b12d2b2004341b641c54fa3a7942555bdcc1f672c3bfa578725",
+		"shake128":     "12eaea2f1de368092b5418c6c5c8575a9a3490fd8d3c85a7fac818ccba01ea5d",
+		"shake128_64":  "12eaea2f1de36809",
+		"shake256":     "de9c5223df66f54d8ec0a07d36c5aa8ffbb9ed62b40050e476690cd99f41c23fbae714a5167bc48198d332d3c8c09beb4a8b95fb37397d56f859b4242671b345",
+		"blake2s256":   "10cca47b9aef109eec894de28449f92c9f66a39419636e5f4ac22984c03d28fc",
+		"blake2b128":   "1748e3d0f53508245851db4571424eee",
+		"crc32c":       CRC32CEncode(src),
+		"crc64":        CRC64Encode(src),
+		"shake256_128": "de9c5223df66f54d8ec0a07d36c5aa8f",
+	}
+	for k, v := range vectors {
+		dest, err := PackHashEncode(src, k)
+		if err != nil || dest != v {
+			t.Fatal("Error pack hash encode:", k, dest, err)
+		}
+		result, err := PackHashCheck(src, v, k)
+		if err != nil || !result {
+			t.Fatal("Error pack hash check:", k, err)
+		}
+	}
+	// crc check values of '123456789'
+	if CRC32CEncode("123456789") != "e3069283" || CRC64Encode("123456789") != "995dc9bbdf1939fa" {
+		t.Fatal("Error crc check value")
+	}
+	for _, v := range []string{"shake128_7", "shake256_x", "crc16", "sha3"} {
+		_, err := PackHashEncode(src, v)
+		if err == nil {
+			t.Fatal("Error pack hash encode: invalid algorithm should fail", v)
+		}
+	}
+}
+
+func TestPackHashEncodeWithKey(t *testing.T) {
+	src := "hello,world!"
+	dest, err := PackHashEncodeWithKey(src, "secret", "blake2b256")
+	if err != nil || dest != "dc7b6b7e1ec16860bab4fefc203087632917068535086028bca0e77af7cf1de4" {
+		t.Fatal("Error pack hash encode with key:", dest, err)
+	}
+	mac, err := Blake2bMAC(src, "secret", 32)
+	if err != nil || mac != dest {
+		t.Fatal("Error blake2b mac:", mac, err)
+	}
+	dest, err = PackHashEncodeWithKey(src, "secret", "BLAKE2S256")
+	if err != nil || dest != "f426fb14fe5667ab943a4538e69109e1628c891d386c3c7076f2db198971b8fc" {
+		t.Fatal("Error pack hash encode with key:", dest, err)
+	}
+	result, err := PackHashCheckWithKey(src, HMAC_SHA256(src, "secret"), "secret", "hmac_sha256")
+	if err != nil || !result {
+		t.Fatal("Error pack hash check with key:", err)
+	}
+	_, err = PackHashEncodeWithKey(src, "secret", "sha256")
+	if err == nil {
+		t.Fatal("Error pack hash encode with key: unkeyed algorithm should fail")
+	}
+}
@@ -6,6 +6,14 @@ import (
 	"crypto/sha256"
 	"crypto/sha512"
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"os"
+	. "qora/global"
+	"strings"
 )
 
 // HMAC_SHA1 function
@@ -37,3 +45,103 @@ func HMAC_SHA512(src, key string) string {
 	m.Write([]byte(src))
 	return hex.EncodeToString(m.Sum(nil))
 }
+
+// HMAC function
+// input hash algorithm, key and data, output mac
+// algorithm now support 'md5', 'sha1', 'sha224', 'sha256', 'sha384', 'sha512', 'sha512_256', 'sha3_256', 'sha3_512',
+// 'blake2b256', 'blake2b512' and 'blake2s256', with or without prefix 'hmac_', you can send both up case and low case
+// key should not be empty
+// return mac the raw mac bytes, err indicate the success or failure function execute
+func HMAC(algorithm string, key []byte, data []byte) (mac []byte, err error) {
+	h, err := newHMAC(algorithm, key)
+	if err != nil {
+		return mac, err
+	}
+	h.Write(data)
+	return h.Sum(nil), err
+}
+
+// HMACVerify function
+// input hash algorithm, key, data and mac which is received, like the signature of webhook
+// the mac is compared by hmac.Equal in constant time
+// return b indicate verify pass or failed, err indicate the success or failure function execute
+func HMACVerify(algorithm string, key []byte, data []byte, mac []byte) (b bool, err error) {
+	r, err := HMAC(algorithm, key, data)
+	if err != nil {
+		return b, err
+	}
+	return hmac.Equal(r, mac), err
+}
+
+// HMACReader function
+// it is common with function HMAC, just the data is read from stream by fixed buffer
+// return mac the raw mac bytes, err indicate the success or failure function execute
+func HMACReader(algorithm string, key []byte, src io.Reader) (mac []byte, err error) {
+	h, err := newHMAC(algorithm, key)
+	if err != nil {
+		return mac, err
+	}
+	_, err = io.CopyBuffer(h, src, make([]byte, HashBufferSize))
+	if err != nil {
+		log.Println("Error read stream:", err)
+		return mac, err
+	}
+	return h.Sum(nil), err
+}
+
+// HMACFile function
+// it is common with function HMAC, just the data is streamed from src file without reading it into memory
+// return mac the raw mac bytes, err indicate the success or failure function execute
+func HMACFile(algorithm string, key []byte, src string) (mac []byte, err error) {
+	file, err := os.Open(src)
+	if err != nil {
+		log.Println("Error open file:", err)
+		return mac, err
+	}
+	defer file.Close()
+	return HMACReader(algorithm, key, file)
+}
+
+// HMACVerifyFile function
+// it is common with function HMACVerify, just the data is streamed from src file
+// return b indicate verify pass or failed, err indicate the success or failure function execute
+func HMACVerifyFile(algorithm string, key []byte, src string, mac []byte) (b bool, err error) {
+	r, err := HMACFile(algorithm, key, src)
+	if err != nil {
+		return b, err
+	}
+	return hmac.Equal(r, mac), err
+}
+
+// newHMAC function
+// it returns the hmac of algorithm with key, empty key is refused
+func newHMAC(algorithm string, key []byte) (h hash.Hash, err error) {
+	if len(key) == 0 {
+		err = errors.New("HMAC key is empty.")
+		return h, err
+	}
+	f, err := hmacBase(algorithm)
+	if err != nil {
+		return h, err
+	}
+	return hmac.New(f, key), err
+}
+
+// hmacBase function
+// it returns the hash constructor which hmac is based on, algorithm could have prefix 'hmac_'
+// shake and crc are refused, shake has no fixed block and crc is not cryptographic
+func hmacBase(algorithm string) (f func() hash.Hash, err error) {
+	name := strings.TrimPrefix(strings.ToLower(algorithm), "hmac_")
+	switch name {
+	case "md5", "sha1", "sha224", "sha256", "sha384", "sha512", "sha512_256", "sha3_256", "sha3_512", "blake2b256", "blake2b512", "blake2s256":
+	default:
+		s := fmt.Sprintf("Undefined hmac algorithm: %v", algorithm)
+		err = errors.New(s)
+		return f, err
+	}
+	f = func() hash.Hash {
+		h, _ := newHash(name, nil)
+		return h
+	}
+	return f, err
+}
@@ -1,6 +1,10 @@
 package pack
 
 import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 )
 
@@ -75,3 +79,66 @@ func BenchmarkHMAC_SHA512(b *testing.B) {
 		}
 	}
 }
+
+// TestHMAC function
+func TestHMAC(t *testing.T) {
+	src := []byte("hello,world!")
+	key := []byte("Alopex6414")
+	vectors := map[string]string{
+		"sha1":        "e6a6dd9149277e8a5a189e2041a4e5ef50b5bca0",
+		"SHA224":      "0b51c15b38bfce8664aa9efc14644f33a087a486107bb32a1e6d1d5d",
+		"hmac_sha256": "8cd5d33d63218ab784b2ad2585adaee7f33493f12908b7b7b8d67a842905f45d",
+		"sha384":      "9868d4f2d2f1de8ef510ff12ca5c5c9828358f385be78b46a1760996a2d6932e294842312419d421f6562cdc2768bff0",
+		"sha512_256":  "d4c4d470d585694296dbec66d0e08cd20b39cb59945e8090bcc4527d901359d3",
+		"sha3_256":    "52a265a2b7aa546478c6655545d4bdf3b353d062cf4b6e555eae2d0f67373976",
+		"sha3_512":    "fc3ac7d7c3a4625e531625e5fe5ebb6a60f33492b54c1fd615777ab9b0ea9c887c9d71114373aa29c5199b0ba2ca2f64d4324a25f95d145b6df87d6cda2001f2",
+		"blake2b256":  "f624fa399402355364b1bf6eed75bfef4b53602d99805d0b4e8796593a4a2716",
+		"blake2b512":  "96695f0876398d2938d833988077c2d786f778a53a6d79fd4c54a2ca06f6cf246af734bb8fe7dae64a8e2ddaa02689188c7663756b0b00898775f1a87ceea75c",
+		"blake2s256":  "f04d240084a683e82b67f42f8fd807d3a4c206e9eb6bcd29d9ae210e325550aa",
+	}
+	for k, v := range vectors {
+		mac, err := HMAC(k, key, src)
+		if err != nil || hex.EncodeToString(mac) != v {
+			t.Fatal("Error HMAC:", k, err)
+		}
+		b, err := HMACVerify(k, key, src, mac)
+		if err != nil || !b {
+			t.Fatal("Error HMAC Verify:", k, err)
+		}
+		mac[0] ^= 0x01
+		b, _ = HMACVerify(k, key, src, mac)
+		if b {
+			t.Fatal("Error HMAC Verify: wrong mac should fail", k)
+		}
+	}
+	for _, v := range []string{"shake128", "crc32c", "sha3"} {
+		_, err := HMAC(v, key, src)
+		if err == nil {
+			t.Fatal("Error HMAC: unsupported algorithm should fail", v)
+		}
+	}
+	_, err := HMAC("sha256", nil, src)
+	if err == nil {
+		t.Fatal("Error HMAC: empty key should fail")
+	}
+}
+
+// TestHMACFile function
+func TestHMACFile(t *testing.T) {
+	key := []byte("Alopex6414")
+	data := bytes.Repeat([]byte("hello,world!"), 100000)
+	src := filepath.Join(t.TempDir(), "file.txt")
+	_ = ioutil.WriteFile(src, data, 0644)
+	mac, err := HMACFile("sha3_256", key, src)
+	if err != nil {
+		t.Fatal("Error HMAC File:", err)
+	}
+	r, _ := HMAC("sha3_256", key, data)
+	if !bytes.Equal(mac, r) {
+		t.Fatal("Error HMAC File result")
+	}
+	b, err := HMACVerifyFile("sha3_256", key, src, r)
+	if err != nil || !b {
+		t.Fatal("Error HMAC Verify File:", err)
+	}
+}
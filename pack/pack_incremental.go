@@ -0,0 +1,93 @@
+package pack
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	. "qora/global"
+	"qora/unpack"
+)
+
+// PackIncremental function
+// This function is mainly used for pack the files which are changed since base package.
+// input src file list, base package path, output dest file path, return error info
+// current files are compared with the manifest of base package by name, size and sha256,
+// only changed and new files are packed, deleted files are recorded in manifest as tombstones,
+// the files which only change modify time or permission are recorded in manifest without being packed
+// base could be a full package or another incremental package, algorithm of base package is used,
+// changed entry of mixed package keeps its algorithm in base package
+// restore the chain of full package and its incremental packages with unpack.UnpackChain
+// return err indicate the success or failure function execute
+func PackIncremental(src []string, base string, dest string) (err error) {
+	return PackIncrementalWithOption(src, base, dest, PackOption{})
+}
+
+// PackIncrementalWithOption function
+// it is common with function PackIncremental, opt.TableKey is also used to open the sealed manifest of base package
+// return err indicate the success or failure function execute
+func PackIncrementalWithOption(src []string, base string, dest string, opt PackOption) (err error) {
+	// first, read the manifest of base package
+	p, err := unpack.ParsePackageWithOption(base, unpack.UnpackOption{TableKey: opt.TableKey})
+	if err != nil {
+		log.Println("Error parse package:", err)
+		return err
+	}
+	if p.Manifest == nil || p.UUID == "" {
+		s := fmt.Sprintf("Base package has no manifest: %v", base)
+		err = errors.New(s)
+		return err
+	}
+	old := make(map[string]unpack.TUnpackManifestFile, len(p.Manifest.Files))
+	for _, v := range p.Manifest.Files {
+		old[v.Name] = v
+	}
+	// changed entry of mixed package keeps its algorithm when it is in base package
+	algorithm := make(map[string]string)
+	if p.Type == MixedType {
+		for _, v := range p.Entries {
+			algorithm[v.Name] = v.Type
+		}
+	}
+	// second, compare current files with manifest
+	m := TPackManifest{Base: p.UUID}
+	var entries []Entry
+	names := make(map[string]bool, len(src))
+	for _, v := range src {
+		data, err := ioutil.ReadFile(v)
+		if err != nil {
+			log.Println("Error read file:", err)
+			return err
+		}
+		fi, err := os.Stat(v)
+		if err != nil {
+			log.Println("Error stat file:", err)
+			return err
+		}
+		_, name := filepath.Split(v)
+		names[name] = true
+		digest := sha256.Sum256(data)
+		f, ok := old[name]
+		if ok && f.Size == int64(len(data)) && bytes.Equal(f.Digest, digest[:]) {
+			m.Files = append(m.Files, TPackManifestFile{Name: name, Size: f.Size, ModTime: fi.ModTime(), Mode: fi.Mode().Perm(), Digest: f.Digest})
+			continue
+		}
+		entries = append(entries, Entry{Name: name, Bytes: data, Mode: fi.Mode().Perm(), ModTime: fi.ModTime(), Algorithm: algorithm[name]})
+	}
+	for _, v := range p.Manifest.Files {
+		if !names[v.Name] {
+			m.Deleted = append(m.Deleted, v.Name)
+		}
+	}
+	// finally, pack the changed files
+	var buf bytes.Buffer
+	err = packToWriter(entries, &buf, p.Type, opt, m, nil)
+	if err != nil {
+		return err
+	}
+	return writeOutput(buf.Bytes(), dest, opt)
+}
@@ -0,0 +1,128 @@
+package pack
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"qora/unpack"
+	"testing"
+	"time"
+)
+
+// TestPackIncremental function
+func TestPackIncremental(t *testing.T) {
+	key := []byte("Satellite-266414")
+	for _, opt := range []PackOption{{}, {TableKey: key}} {
+		dir := t.TempDir()
+		src := filepath.Join(dir, "src")
+		_ = os.MkdirAll(src, 0755)
+		files := map[string][]byte{"a.txt": []byte("alpha"), "b.txt": []byte("bravo"), "c.txt": []byte("charlie")}
+		for k, v := range files {
+			_ = ioutil.WriteFile(filepath.Join(src, k), v, 0644)
+		}
+		list := func() (r []string) {
+			for k := range files {
+				r = append(r, filepath.Join(src, k))
+			}
+			return r
+		}
+		full := filepath.Join(dir, "full.pak")
+		err := PackWithOption(list(), full, "AES", opt)
+		if err != nil {
+			t.Fatal("Error Pack With Option:", err)
+		}
+		// first increment changes a, deletes b and adds d
+		files["a.txt"] = []byte("alpha changed")
+		delete(files, "b.txt")
+		files["d.txt"] = []byte("delta")
+		_ = ioutil.WriteFile(filepath.Join(src, "a.txt"), files["a.txt"], 0644)
+		_ = ioutil.WriteFile(filepath.Join(src, "d.txt"), files["d.txt"], 0600)
+		inc1 := filepath.Join(dir, "inc1.pak")
+		err = PackIncrementalWithOption(list(), full, inc1, opt)
+		if err != nil {
+			t.Fatal("Error Pack Incremental:", err)
+		}
+		p, err := unpack.ParsePackageWithOption(inc1, unpack.UnpackOption{TableKey: opt.TableKey})
+		if err != nil || len(p.Entries) != 2 || len(p.Manifest.Deleted) != 1 || len(p.Manifest.Files) != 3 {
+			t.Fatal("Error Pack Incremental entries:", err)
+		}
+		// second increment only changes modify time of c and adds b back
+		mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		_ = os.Chtimes(filepath.Join(src, "c.txt"), mtime, mtime)
+		files["b.txt"] = []byte("bravo again")
+		_ = ioutil.WriteFile(filepath.Join(src, "b.txt"), files["b.txt"], 0644)
+		inc2 := filepath.Join(dir, "inc2.pak")
+		err = PackIncrementalWithOption(list(), inc1, inc2, opt)
+		if err != nil {
+			t.Fatal("Error Pack Incremental:", err)
+		}
+		// restore the chain in any order
+		out := filepath.Join(dir, "out") + "/"
+		_ = os.MkdirAll(out, 0755)
+		err = unpack.UnpackChainWithOption([]string{inc2, full, inc1}, out, unpack.UnpackOption{TableKey: opt.TableKey})
+		if err != nil {
+			t.Fatal("Error Unpack Chain:", err)
+		}
+		r, _ := ioutil.ReadDir(out)
+		if len(r) != len(files) {
+			t.Fatal("Error Unpack Chain file number:", len(r))
+		}
+		for k, v := range files {
+			b, _ := ioutil.ReadFile(out + k)
+			if !bytes.Equal(b, v) {
+				t.Fatal("Error Unpack Chain content:", k)
+			}
+		}
+		fi, err := os.Stat(out + "c.txt")
+		if err != nil || !fi.ModTime().Equal(mtime) {
+			t.Fatal("Error Unpack Chain modify time:", err)
+		}
+		fi, err = os.Stat(out + "d.txt")
+		if err != nil || fi.Mode().Perm() != 0600 {
+			t.Fatal("Error Unpack Chain mode:", err)
+		}
+		// broken chain should fail
+		err = unpack.UnpackChainWithOption([]string{full, inc2}, out, unpack.UnpackOption{TableKey: opt.TableKey})
+		if err == nil {
+			t.Fatal("Error Unpack Chain: broken chain should fail")
+		}
+	}
+}
+
+// TestUnpackChainName function
+func TestUnpackChainName(t *testing.T) {
+	dir := t.TempDir()
+	full := filepath.Join(dir, "full.pak")
+	err := PackEntriesWithOption([]Entry{{Name: "a.txt", Bytes: []byte("alpha")}}, full, "AES", PackOption{})
+	if err != nil {
+		t.Fatal("Error Pack Entries With Option:", err)
+	}
+	p, _ := unpack.ParsePackage(full)
+	victim := filepath.Join(dir, "victim.txt")
+	out := filepath.Join(dir, "out") + "/"
+	_ = os.MkdirAll(out, 0755)
+	// crafted increment of unsealed package deletes or writes outside dest
+	for _, m := range []TPackManifest{
+		{Base: p.UUID, Deleted: []string{"../victim.txt"}},
+		{Base: p.UUID, Deleted: []string{victim}},
+		{Base: p.UUID, Files: []TPackManifestFile{{Name: "../victim.txt"}}},
+	} {
+		_ = ioutil.WriteFile(victim, []byte("victim"), 0644)
+		var buf bytes.Buffer
+		err = packToWriter([]Entry{{Name: "b.txt", Bytes: []byte("bravo")}}, &buf, "AES", PackOption{}, m, nil)
+		if err != nil {
+			t.Fatal("Error pack crafted increment:", err)
+		}
+		inc := filepath.Join(dir, "inc.pak")
+		_ = ioutil.WriteFile(inc, buf.Bytes(), 0644)
+		err = unpack.UnpackChain([]string{full, inc}, out)
+		data, _ := ioutil.ReadFile(victim)
+		if err == nil || string(data) != "victim" {
+			t.Fatal("Error Unpack Chain: invalid file name should fail", m, err)
+		}
+		if _, e := os.Stat(filepath.Join(out, "a.txt")); e == nil {
+			t.Fatal("Error Unpack Chain: nothing should be written", m)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package pack
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/pbkdf2"
+	"hash"
+	"io"
+	"log"
+	. "qora/global"
+	"strings"
+)
+
+// DeriveKey function
+// This function is mainly used for derive key material from secret, like per-tenant keys from one master secret.
+// input kdf algorithm, secret and option, return the derived key and error info
+// algorithm now support 'hkdf', 'pbkdf2', 'scrypt', 'argon2id' and 'argon2i', you can send both up case and low case
+// 'hkdf' is for secret which already has high entropy, like random master key, opt.Info separates the derived keys,
+// 'pbkdf2', 'scrypt', 'argon2id' and 'argon2i' are for password, they need random salt and cost params
+// zero option uses the default of global, params lower than minimum fail, see HKDF, PBKDF2, Scrypt, Argon2id and Argon2i
+// the same secret and option always derive the same key
+// return key the derived key, err indicate the success or failure function execute
+func DeriveKey(algorithm string, secret []byte, opt KDFOption) (key []byte, err error) {
+	size := opt.Size
+	if size == 0 {
+		size = KDFKeySize
+	}
+	p := opt.Params
+	switch strings.ToLower(algorithm) {
+	case "hkdf":
+		return HKDF(opt.Hash, secret, opt.Salt, opt.Info, size)
+	case "pbkdf2":
+		iterations := opt.Iterations
+		if iterations == 0 {
+			iterations = KDFIterations
+		}
+		return PBKDF2(opt.Hash, secret, opt.Salt, iterations, size)
+	case "scrypt":
+		if p.N == 0 && p.R == 0 && p.P == 0 {
+			p.N, p.R, p.P = PasswordScryptN, PasswordScryptR, PasswordScryptP
+		}
+		return Scrypt(secret, opt.Salt, p.N, p.R, p.P, size)
+	case "argon2id", "argon2i":
+		if p.Time == 0 && p.Memory == 0 && p.Threads == 0 {
+			p.Time, p.Memory, p.Threads = PasswordArgon2Time, PasswordArgon2Memory, PasswordArgon2Threads
+		}
+		if strings.ToLower(algorithm) == "argon2i" {
+			return Argon2i(secret, opt.Salt, p.Time, p.Memory, p.Threads, size)
+		}
+		return Argon2id(secret, opt.Salt, p.Time, p.Memory, p.Threads, size)
+	default:
+		s := fmt.Sprintf("Undefined kdf algorithm: %v", algorithm)
+		err = errors.New(s)
+		return key, err
+	}
+}
+
+// HKDF function
+// input hash algorithm, secret, salt, context info and output size, return the derived key and error info
+// it is HKDFExtract followed by HKDFExpand of RFC 5869, empty algorithm is 'sha256'
+// secret should have at least KDFMinSecretSize bytes, salt is optional, output is no more than 255 hash size
+// return key the derived key, err indicate the success or failure function execute
+func HKDF(algorithm string, secret []byte, salt []byte, info []byte, size int) (key []byte, err error) {
+	prk, err := HKDFExtract(algorithm, secret, salt)
+	if err != nil {
+		return key, err
+	}
+	return HKDFExpand(algorithm, prk, info, size)
+}
+
+// HKDFExtract function
+// input hash algorithm, secret and salt, return the pseudorandom key of hash size and error info
+// algorithm now support the algorithms of HMAC except 'md5', empty algorithm is 'sha256', like 'sha256' and 'sha512'
+// secret should have at least KDFMinSecretSize bytes, empty salt is hash size of zero bytes
+// return prk the pseudorandom key, err indicate the success or failure function execute
+func HKDFExtract(algorithm string, secret []byte, salt []byte) (prk []byte, err error) {
+	f, _, err := kdfHash(algorithm)
+	if err != nil {
+		return prk, err
+	}
+	if len(secret) < KDFMinSecretSize {
+		s := fmt.Sprintf("HKDF secret size %v is less than %v.", len(secret), KDFMinSecretSize)
+		err = errors.New(s)
+		return prk, err
+	}
+	return hkdf.Extract(f, secret, salt), err
+}
+
+// HKDFExpand function
+// input hash algorithm, pseudorandom key of HKDFExtract, context info and output size, return the key and error info
+// prk should have at least hash size bytes, output size is from KDFMinKeySize to 255 hash size
+// different info derives independent keys from the same prk, like 'tenant:42/encryption'
+// return key the derived key, err indicate the success or failure function execute
+func HKDFExpand(algorithm string, prk []byte, info []byte, size int) (key []byte, err error) {
+	f, n, err := kdfHash(algorithm)
+	if err != nil {
+		return key, err
+	}
+	if len(prk) < n || size < KDFMinKeySize || size > 255*n {
+		s := fmt.Sprintf("Invalid hkdf params: prk size %v, output size %v, hash size %v", len(prk), size, n)
+		err = errors.New(s)
+		return key, err
+	}
+	key = make([]byte, size)
+	_, err = io.ReadFull(hkdf.Expand(f, prk, info), key)
+	if err != nil {
+		log.Println("Error expand hkdf key:", err)
+	}
+	return key, err
+}
+
+// PBKDF2 function
+// input prf hash algorithm, password, salt, iterations and output size, return the derived key and error info
+// prf is hmac of algorithm, it supports the algorithms of HMAC except 'md5', empty algorithm is 'sha256',
+// 'sha1' is kept for legacy formats only
+// salt should have at least KDFMinSaltSize bytes, iterations are at least KDFMinIterations,
+// output size is from KDFMinKeySize to KDFMaxKeySize
+// return key the derived key, err indicate the success or failure function execute
+func PBKDF2(algorithm string, password []byte, salt []byte, iterations int, size int) (key []byte, err error) {
+	f, _, err := kdfHash(algorithm)
+	if err != nil {
+		return key, err
+	}
+	err = checkKDF("pbkdf2", password, salt, size)
+	if err != nil {
+		return key, err
+	}
+	if iterations < KDFMinIterations {
+		s := fmt.Sprintf("PBKDF2 iterations %v is less than %v.", iterations, KDFMinIterations)
+		err = errors.New(s)
+		return key, err
+	}
+	return pbkdf2.Key(password, salt, iterations, size, f), err
+}
+
+// Scrypt function
+// input password, salt, cost params and output size, return the derived key and error info
+// n is power of two, 128 * n * r is no more than PasswordMaxMemory bytes, r * p is less than 2^30,
+// salt should have at least KDFMinSaltSize bytes, output size is from KDFMinKeySize to KDFMaxKeySize
+// return key the derived key, err indicate the success or failure function execute
+func Scrypt(password []byte, salt []byte, n int, r int, p int, size int) (key []byte, err error) {
+	err = checkKDF("scrypt", password, salt, size)
+	if err != nil {
+		return key, err
+	}
+	params := PasswordParams{N: n, R: r, P: p, SaltSize: len(salt), KeySize: size}
+	err = checkPassword("scrypt", params)
+	if err != nil {
+		return key, err
+	}
+	return derivePassword("scrypt", password, params, salt)
+}
+
+// Argon2id function
+// input password, salt, iterations, memory in KiB, parallelism and output size, return the derived key and error info
+// iterations are from 1 to PasswordMaxTime, memory is from 8 * threads KiB to PasswordMaxMemory bytes,
+// salt should have at least KDFMinSaltSize bytes, output size is from KDFMinKeySize to KDFMaxKeySize
+// return key the derived key, err indicate the success or failure function execute
+func Argon2id(password []byte, salt []byte, time uint32, memory uint32, threads uint8, size int) (key []byte, err error) {
+	return argon2Key("argon2id", password, salt, time, memory, threads, size)
+}
+
+// Argon2i function
+// it is common with function Argon2id, argon2i is data independent and resists side channel better,
+// but it is weaker against trade-off attack, prefer Argon2id unless side channel is the concern
+// return key the derived key, err indicate the success or failure function execute
+func Argon2i(password []byte, salt []byte, time uint32, memory uint32, threads uint8, size int) (key []byte, err error) {
+	return argon2Key("argon2i", password, salt, time, memory, threads, size)
+}
+
+// argon2Key function
+// check the params and derive the key by argon2id or argon2i
+func argon2Key(algorithm string, password []byte, salt []byte, time uint32, memory uint32, threads uint8, size int) (key []byte, err error) {
+	err = checkKDF(algorithm, password, salt, size)
+	if err != nil {
+		return key, err
+	}
+	err = checkPassword("argon2id", PasswordParams{Time: time, Memory: memory, Threads: threads, SaltSize: len(salt), KeySize: size})
+	if err != nil {
+		return key, err
+	}
+	if algorithm == "argon2i" {
+		return argon2.Key(password, salt, time, memory, threads, uint32(size)), err
+	}
+	return argon2.IDKey(password, salt, time, memory, threads, uint32(size)), err
+}
+
+// checkKDF function
+// check the password, salt and output size of password based kdf
+func checkKDF(algorithm string, password []byte, salt []byte, size int) (err error) {
+	if len(password) == 0 || len(salt) < KDFMinSaltSize || size < KDFMinKeySize || size > KDFMaxKeySize {
+		s := fmt.Sprintf("Invalid %v params: password size %v, salt size %v, output size %v", algorithm, len(password), len(salt), size)
+		err = errors.New(s)
+	}
+	return err
+}
+
+// kdfHash function
+// it returns the hash constructor and hash size of hkdf and pbkdf2, empty algorithm is KDFHash
+func kdfHash(algorithm string) (f func() hash.Hash, size int, err error) {
+	if algorithm == "" {
+		algorithm = KDFHash
+	}
+	if strings.TrimPrefix(strings.ToLower(algorithm), "hmac_") == "md5" {
+		s := fmt.Sprintf("Hash algorithm is too weak for kdf: %v", algorithm)
+		err = errors.New(s)
+		return f, size, err
+	}
+	f, err = hmacBase(algorithm)
+	if err != nil {
+		return f, size, err
+	}
+	return f, f().Size(), err
+}
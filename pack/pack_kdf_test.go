@@ -0,0 +1,111 @@
+package pack
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// TestHKDF function
+func TestHKDF(t *testing.T) {
+	// RFC 5869 test case 1
+	ikm := bytes.Repeat([]byte{0x0b}, 22)
+	salt, _ := hex.DecodeString("000102030405060708090a0b0c")
+	info, _ := hex.DecodeString("f0f1f2f3f4f5f6f7f8f9")
+	prk, err := HKDFExtract("sha256", ikm, salt)
+	if err != nil || hex.EncodeToString(prk) != "077709362c2e32df0ddc3f0dc47bba6390b6c73bb50f9c3122ec844ad7c2b3e5" {
+		t.Fatal("Error HKDF Extract:", hex.EncodeToString(prk), err)
+	}
+	key, err := HKDFExpand("sha256", prk, info, 42)
+	if err != nil || hex.EncodeToString(key) != "3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865" {
+		t.Fatal("Error HKDF Expand:", hex.EncodeToString(key), err)
+	}
+	r, err := DeriveKey("HKDF", ikm, KDFOption{Salt: salt, Info: info, Size: 42})
+	if err != nil || !bytes.Equal(r, key) {
+		t.Fatal("Error Derive Key hkdf:", err)
+	}
+	// different info and hash derive independent keys
+	a, _ := HKDF("sha512", ikm, nil, []byte("tenant:1"), 32)
+	b, _ := HKDF("sha512", ikm, nil, []byte("tenant:2"), 32)
+	if len(a) != 32 || bytes.Equal(a, b) || bytes.Equal(a, key[:32]) {
+		t.Fatal("Error HKDF: keys of different info should differ")
+	}
+	for _, v := range []struct {
+		algorithm string
+		secret    []byte
+		size      int
+	}{{"md5", ikm, 32}, {"shake128", ikm, 32}, {"sha256", ikm[:8], 32}, {"sha256", ikm, 8}, {"sha256", ikm, 255*32 + 1}} {
+		_, err = HKDF(v.algorithm, v.secret, nil, nil, v.size)
+		if err == nil {
+			t.Fatal("Error HKDF: invalid params should fail", v)
+		}
+	}
+	_, err = HKDFExpand("sha256", prk[:16], info, 32)
+	if err == nil {
+		t.Fatal("Error HKDF Expand: short prk should fail")
+	}
+}
+
+// TestPasswordKDF function
+func TestPasswordKDF(t *testing.T) {
+	password := []byte("password")
+	salt := []byte("saltsaltsaltsalt")
+	for _, v := range []struct {
+		algorithm string
+		size      int
+		dest      string
+	}{
+		{"sha512", 64, "ef5e6ba88af97573953e9061aaab2e825d37ef34f96d6253598999b4870af210678ac2a9c1f63b92892fc230eb347a87845e743dbecc0fa1ef909c220d0c38c3"},
+		{"", 32, "f275fb870144cc807c68f6a325360af3078741ce4d833d2915500abd2bb88d00"},
+	} {
+		key, err := PBKDF2(v.algorithm, password, salt, 1000, v.size)
+		if err != nil || hex.EncodeToString(key) != v.dest {
+			t.Fatal("Error PBKDF2:", v.algorithm, hex.EncodeToString(key), err)
+		}
+	}
+	key, err := Scrypt(password, salt, 1024, 8, 1, 32)
+	if err != nil || hex.EncodeToString(key) != "0553112aa7625588a428068f475c2e72c2942afc384ee3cb91d118b684876ace" {
+		t.Fatal("Error Scrypt:", hex.EncodeToString(key), err)
+	}
+	r, err := DeriveKey("scrypt", password, KDFOption{Salt: salt, Params: PasswordParams{N: 1024, R: 8, P: 1}})
+	if err != nil || !bytes.Equal(r, key) {
+		t.Fatal("Error Derive Key scrypt:", err)
+	}
+	a, err := Argon2id(password, salt, 1, 1024, 1, 32)
+	if err != nil || len(a) != 32 {
+		t.Fatal("Error Argon2id:", err)
+	}
+	b, err := Argon2i(password, salt, 1, 1024, 1, 32)
+	if err != nil || bytes.Equal(a, b) {
+		t.Fatal("Error Argon2i:", err)
+	}
+	r, err = DeriveKey("argon2i", password, KDFOption{Salt: salt, Params: PasswordParams{Time: 1, Memory: 1024, Threads: 1}})
+	if err != nil || !bytes.Equal(r, b) {
+		t.Fatal("Error Derive Key argon2i:", err)
+	}
+	// minimum params
+	_, err = PBKDF2("sha256", password, salt, 999, 32)
+	if err == nil {
+		t.Fatal("Error PBKDF2: few iterations should fail")
+	}
+	_, err = PBKDF2("sha256", password, salt[:8], 1000, 32)
+	if err == nil {
+		t.Fatal("Error PBKDF2: short salt should fail")
+	}
+	_, err = Scrypt(password, salt, 1000, 8, 1, 32)
+	if err == nil {
+		t.Fatal("Error Scrypt: n should be power of two")
+	}
+	_, err = Argon2id(nil, salt, 1, 1024, 1, 32)
+	if err == nil {
+		t.Fatal("Error Argon2id: empty password should fail")
+	}
+	_, err = Argon2id(password, salt, 1, 4, 1, 32)
+	if err == nil {
+		t.Fatal("Error Argon2id: low memory should fail")
+	}
+	_, err = DeriveKey("bcrypt", password, KDFOption{Salt: salt})
+	if err == nil {
+		t.Fatal("Error Derive Key: undefined algorithm should fail")
+	}
+}
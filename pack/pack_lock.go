@@ -0,0 +1,39 @@
+package pack
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	. "qora/global"
+	"time"
+)
+
+// LockPackage function
+// input package path, output unlock function and error information
+// it acquires an advisory lock for the package by exclusively creating 'dest.lock' beside it
+// another editor will wait LockRetryTimes*LockRetryInterval milliseconds at most before give up
+// the lock file records the holder pid, remove it by hand if the holder was killed
+// return unlock function which release the lock, err indicate the success or failure function execute
+func LockPackage(dest string) (unlock func() error, err error) {
+	lock := dest + ".lock"
+	for i := 0; i < LockRetryTimes; i++ {
+		file, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, _ = fmt.Fprintf(file, "%d\n", os.Getpid())
+			_ = file.Close()
+			unlock = func() error {
+				return os.Remove(lock)
+			}
+			return unlock, nil
+		}
+		if !os.IsExist(err) {
+			log.Println("Error create lock file:", err)
+			return unlock, err
+		}
+		time.Sleep(LockRetryInterval * time.Millisecond)
+	}
+	s := fmt.Sprintf("Package is locked by another editor: %v", lock)
+	err = errors.New(s)
+	return unlock, err
+}
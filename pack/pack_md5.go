@@ -12,7 +12,7 @@ import (
 // return bool indicate true or false two string equal with MD5
 func MD5Check(src string, dest string) bool {
 	b := false
-	if MD5Encode(src) == dest {
+	if hashEqual(MD5Encode(src), dest) {
 		b = true
 	}
 	return b
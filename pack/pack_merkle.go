@@ -0,0 +1,187 @@
+package pack
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	. "qora/global"
+	"runtime"
+	"sync"
+)
+
+// MerkleFile function
+// This function is mainly used for hash the large file by all cores, the single sha256 is limited by one core.
+// input src file path and chunk size, return the merkle tree and error info
+// file is split into chunks of chunk size, 0 is MerkleChunkSize, every chunk is hashed in goroutine as one leaf,
+// then leaves are combined into the root in RFC 6962 way: leaf is sha256(0x00|chunk), node is sha256(0x01|left|right),
+// the left subtree of n leaves has the largest power of two leaves which is less than n
+// chunks are read by batch of cpu number, the file is never read into memory as a whole
+// the root is different from sha256 of the file, both sides should use the same chunk size
+// return t the merkle tree, err indicate the success or failure function execute
+func MerkleFile(src string, chunk int) (t *TMerkleTree, err error) {
+	file, err := os.Open(src)
+	if err != nil {
+		log.Println("Error open file:", err)
+		return t, err
+	}
+	defer file.Close()
+	return MerkleReader(file, chunk)
+}
+
+// MerkleReader function
+// it is common with function MerkleFile, just the data is read from stream
+// return t the merkle tree, err indicate the success or failure function execute
+func MerkleReader(src io.Reader, chunk int) (t *TMerkleTree, err error) {
+	if chunk == 0 {
+		chunk = MerkleChunkSize
+	}
+	if chunk < 0 {
+		s := fmt.Sprintf("Invalid merkle chunk size: %v", chunk)
+		err = errors.New(s)
+		return t, err
+	}
+	t = &TMerkleTree{ChunkSize: chunk}
+	// first, hash the chunks by batch through goroutine
+	core := runtime.NumCPU()
+	buf := make([][]byte, core)
+	for k := range buf {
+		buf[k] = make([]byte, chunk)
+	}
+	for eof := false; !eof; {
+		wg := &sync.WaitGroup{}
+		rr := make([][sha256.Size]byte, core)
+		n := 0
+		for ; n < core && !eof; n++ {
+			m, err := io.ReadFull(src, buf[n])
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				eof = true
+			} else if err != nil {
+				log.Println("Error read stream:", err)
+				return t, err
+			}
+			if m == 0 {
+				break
+			}
+			t.Size += int64(m)
+			wg.Add(1)
+			go MerkleLeafGo(buf[n][:m], &rr[n], wg)
+		}
+		wg.Wait()
+		for i := 0; i < n; i++ {
+			t.Leaves = append(t.Leaves, append([]byte{}, rr[i][:]...))
+		}
+	}
+	// finally, combine the leaves into root
+	t.Root = merkleRoot(t.Leaves)
+	return t, err
+}
+
+// MerkleLeafGo function
+// hash one chunk as merkle leaf with goroutine
+func MerkleLeafGo(data []byte, r *[sha256.Size]byte, wg *sync.WaitGroup) {
+	defer wg.Done()
+	*r = MerkleLeaf(data)
+}
+
+// MerkleLeaf function
+// hash one chunk as merkle leaf, it is sha256(0x00|chunk)
+func MerkleLeaf(data []byte) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write([]byte{MerkleLeafByte})
+	h.Write(data)
+	var r [sha256.Size]byte
+	copy(r[:], h.Sum(nil))
+	return r
+}
+
+// MerkleProof function
+// This function is mainly used for prove that one chunk belongs to the root, like verify partial download chunk by chunk.
+// input the merkle tree and chunk index, return the audit path from leaf to root
+// return proof the sibling hashes from bottom to top, err indicate the success or failure function execute
+func MerkleProof(t *TMerkleTree, index int) (proof [][]byte, err error) {
+	if index < 0 || index >= len(t.Leaves) {
+		s := fmt.Sprintf("Merkle chunk index out of range: %v", index)
+		err = errors.New(s)
+		return proof, err
+	}
+	return merklePath(index, t.Leaves), err
+}
+
+// MerkleVerify function
+// input root, chunk data, chunk index, chunk number of tree and the proof from MerkleProof
+// the chunk is hashed as leaf, then combined with proof to the root as RFC 9162 audit path verification
+// return b indicate the chunk belongs to the root or not
+func MerkleVerify(root []byte, chunk []byte, index int, count int, proof [][]byte) (b bool) {
+	if index < 0 || index >= count {
+		return false
+	}
+	leaf := MerkleLeaf(chunk)
+	r := leaf[:]
+	fn, sn := index, count-1
+	for _, v := range proof {
+		if sn == 0 {
+			return false
+		}
+		if fn&1 == 1 || fn == sn {
+			r = merkleNode(v, r)
+			for fn&1 == 0 && fn != 0 {
+				fn, sn = fn>>1, sn>>1
+			}
+		} else {
+			r = merkleNode(r, v)
+		}
+		fn, sn = fn>>1, sn>>1
+	}
+	return sn == 0 && bytes.Equal(r, root)
+}
+
+// merkleNode function
+// hash two children as merkle node, it is sha256(0x01|left|right)
+func merkleNode(left []byte, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{MerkleNodeByte})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// merkleSplit function
+// return the largest power of two which is less than n
+func merkleSplit(n int) int {
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
+
+// merkleRoot function
+// combine the leaves into root, the root of no leaf is sha256 of empty data
+func merkleRoot(leaves [][]byte) []byte {
+	switch len(leaves) {
+	case 0:
+		r := sha256.Sum256(nil)
+		return r[:]
+	case 1:
+		return leaves[0]
+	}
+	k := merkleSplit(len(leaves))
+	return merkleNode(merkleRoot(leaves[:k]), merkleRoot(leaves[k:]))
+}
+
+// merklePath function
+// return the audit path of leaf m
+func merklePath(m int, leaves [][]byte) (proof [][]byte) {
+	if len(leaves) <= 1 {
+		return proof
+	}
+	k := merkleSplit(len(leaves))
+	if m < k {
+		return append(merklePath(m, leaves[:k]), merkleRoot(leaves[k:]))
+	}
+	return append(merklePath(m-k, leaves[k:]), merkleRoot(leaves[:k]))
+}
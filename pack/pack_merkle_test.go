@@ -0,0 +1,86 @@
+package pack
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// TestMerkleFile function
+func TestMerkleFile(t *testing.T) {
+	var data []byte
+	for i := 0; i < 40; i++ {
+		for j := 0; j < 256; j++ {
+			data = append(data, byte(j))
+		}
+	}
+	src := filepath.Join(t.TempDir(), "file.bin")
+	_ = ioutil.WriteFile(src, data, 0644)
+	tree, err := MerkleFile(src, 1000)
+	if err != nil || len(tree.Leaves) != 11 || tree.Size != int64(len(data)) {
+		t.Fatal("Error Merkle File:", err)
+	}
+	if hex.EncodeToString(tree.Root) != "72378b5cc6ec8771e5e5e63a9d1b3fc119f0d6224c9ed6224a3f220b2ebf451a" {
+		t.Fatal("Error Merkle File root:", hex.EncodeToString(tree.Root))
+	}
+	// empty data and one chunk
+	tree, err = MerkleReader(bytes.NewReader(nil), 0)
+	empty := sha256.Sum256(nil)
+	if err != nil || len(tree.Leaves) != 0 || !bytes.Equal(tree.Root, empty[:]) {
+		t.Fatal("Error Merkle Reader empty:", err)
+	}
+	tree, _ = MerkleReader(bytes.NewReader(data), 0)
+	leaf := MerkleLeaf(data)
+	if len(tree.Leaves) != 1 || !bytes.Equal(tree.Root, leaf[:]) {
+		t.Fatal("Error Merkle Reader one chunk")
+	}
+}
+
+// TestMerkleProof function
+func TestMerkleProof(t *testing.T) {
+	for n := 1; n <= 17; n++ {
+		var data []byte
+		for i := 0; i < n*70; i++ {
+			data = append(data, byte(i%251))
+		}
+		tree, err := MerkleReader(bytes.NewReader(data), 7*10)
+		if err != nil || len(tree.Leaves) != n {
+			t.Fatal("Error Merkle Reader:", n, err)
+		}
+		for i := 0; i < n; i++ {
+			proof, err := MerkleProof(tree, i)
+			if err != nil {
+				t.Fatal("Error Merkle Proof:", n, i, err)
+			}
+			chunk := data[i*70 : (i+1)*70]
+			if !MerkleVerify(tree.Root, chunk, i, n, proof) {
+				t.Fatal("Error Merkle Verify:", n, i)
+			}
+			if MerkleVerify(tree.Root, append([]byte{1}, chunk[1:]...), i, n, proof) {
+				t.Fatal("Error Merkle Verify: tampered chunk should fail", n, i)
+			}
+			if n > 1 && MerkleVerify(tree.Root, chunk, (i+1)%n, n, proof) {
+				t.Fatal("Error Merkle Verify: wrong index should fail", n, i)
+			}
+		}
+	}
+	tree, _ := MerkleReader(bytes.NewReader([]byte("merkle")), 4)
+	_, err := MerkleProof(tree, 2)
+	if err == nil {
+		t.Fatal("Error Merkle Proof: index out of range should fail")
+	}
+}
+
+// BenchmarkMerkleReader function
+func BenchmarkMerkleReader(b *testing.B) {
+	data := bytes.Repeat([]byte("merkle!"), 1<<20)
+	for i := 0; i < b.N; i++ {
+		_, err := MerkleReader(bytes.NewReader(data), 0)
+		if err != nil {
+			b.Fatal("Error Merkle Reader:", err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package pack
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"qora/unpack"
+	"testing"
+)
+
+// TestPackMixed function
+func TestPackMixed(t *testing.T) {
+	key := []byte("Satellite-266414")
+	bulk := bytes.Repeat([]byte("bulk asset data "), 4096)
+	entries := []Entry{
+		{Name: "bulk.bin", Bytes: bulk},
+		{Name: "secret.txt", Bytes: []byte("rsa wrapped secret"), Algorithm: "RSA"},
+		{Name: "legacy.txt", Bytes: []byte("des entry"), Algorithm: "des"},
+		{Name: "note.txt", Bytes: []byte("base64 entry"), Algorithm: "BASE64"},
+	}
+	for _, opt := range []PackOption{{}, {TableKey: key}} {
+		dir := t.TempDir()
+		dest := filepath.Join(dir, "file_mixed.pak")
+		err := PackEntriesWithOption(entries, dest, "MIXED", opt)
+		if err != nil {
+			t.Fatal("Error Pack Mixed:", err)
+		}
+		uo := unpack.UnpackOption{TableKey: opt.TableKey}
+		var names, types []string
+		var sz []int
+		err = unpack.ExtractEntryInfoWithOption(dest, &names, &sz, &types, uo)
+		if err != nil || len(types) != 4 || types[0] != "aes" || types[1] != "rsa" || types[2] != "des" || types[3] != "base64" {
+			t.Fatal("Error Extract Entry Info:", types, err)
+		}
+		var algorithm string
+		names, sz = nil, nil
+		err = unpack.ExtractInfoWithOption(dest, &names, &sz, &algorithm, uo)
+		if err != nil || algorithm != "mixed" || sz[0] != len(bulk) {
+			t.Fatal("Error Extract Info:", algorithm, err)
+		}
+		r, err := unpack.UnpackAllToMemoryWithOption(dest, uo)
+		if err != nil {
+			t.Fatal("Error Unpack Mixed:", err)
+		}
+		for _, v := range entries {
+			if !bytes.Equal(r[v.Name], v.Bytes) {
+				t.Fatal("Error Unpack Mixed content:", v.Name)
+			}
+		}
+	}
+	// plain mixed package is dispatched per entry by Unpack and could be edited
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "file_mixed.pak")
+	err := PackEntriesWithOption(entries, dest, "mixed", PackOption{})
+	if err != nil {
+		t.Fatal("Error Pack Mixed:", err)
+	}
+	src := filepath.Join(dir, "secret.txt")
+	_ = ioutil.WriteFile(src, []byte("rsa wrapped secret v2"), 0644)
+	err = Replace(dest, []string{src})
+	if err != nil {
+		t.Fatal("Error Replace Mixed:", err)
+	}
+	err = unpack.Unpack(dest, dir+"/")
+	if err != nil {
+		t.Fatal("Error Unpack Mixed:", err)
+	}
+	b, _ := ioutil.ReadFile(filepath.Join(dir, "secret.txt"))
+	if string(b) != "rsa wrapped secret v2" {
+		t.Fatal("Error Unpack Mixed replaced entry")
+	}
+	p, err := unpack.ParsePackage(dest)
+	if err != nil || p.Entries[1].Type != "RSA" {
+		t.Fatal("Error Replace Mixed: entry algorithm is not kept", err)
+	}
+	// mixed package could be patched
+	entries[0].Bytes = append([]byte("head "), bulk...)
+	newer := filepath.Join(dir, "file_newer.pak")
+	err = PackEntriesWithOption(entries, newer, "MIXED", PackOption{})
+	if err != nil {
+		t.Fatal("Error Pack Mixed:", err)
+	}
+	patch := filepath.Join(dir, "file.qpatch")
+	err = Diff(dest, newer, patch)
+	if err != nil {
+		t.Fatal("Error Diff Mixed:", err)
+	}
+	err = unpack.Patch(dest, patch, dest)
+	if err != nil {
+		t.Fatal("Error Patch Mixed:", err)
+	}
+	r, err := unpack.UnpackAllToMemory(dest)
+	if err != nil || !bytes.Equal(r["bulk.bin"], entries[0].Bytes) {
+		t.Fatal("Error Patch Mixed content:", err)
+	}
+	// entry algorithm should match package algorithm of other package
+	err = PackEntriesWithOption(entries, filepath.Join(dir, "file_aes.pak"), "AES", PackOption{})
+	if err == nil {
+		t.Fatal("Error Pack: entry algorithm mismatch should fail")
+	}
+}
@@ -0,0 +1,424 @@
+package pack
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	. "qora/global"
+	"qora/unpack"
+	. "qora/utils"
+	"runtime"
+	"sort"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// PackWithOption function
+// input src file list, output dest file path, algorithm which used in pack and pack option, return error info
+// it writes package format v2, which has a header extension behind the header
+// when opt.TableKey is set, the whole entry table(names, keys and sizes) is sealed by aes-gcm,
+// only key holders can list or unpack the package, unpack it with unpack.UnpackWithOption
+// when opt.PadSize or opt.PadPow2 is set, entry data is padded to size bucket before encrypt, so size leaks less
+// when opt.VolumeSize is set, package is written as parts 'name.q01', 'name.q02', ... instead of dest itself
+// when opt.Parity is set, reed-solomon parity file is written beside the package or every part, see GenerateParity
+// opt.Meta is stored in header extension and listed by unpack.ExtractMeta without key,
+// empty author, creator and create time are filled with default values
+// when opt.Version is 1, legacy package without header extension is written, only opt.Meta.Author is recorded in header,
+// volume and parity are still supported, other option needs format v2
+// the manifest of files(size, modify time, permission and sha256) is stored as well, see PackIncremental
+// when opt.Seed is set, package is deterministic: entries are sorted by name, create time and modify time are fixed
+// to SOURCE_DATE_EPOCH(or unix epoch), entry keys, nonces and uuid are derived from seed by hkdf,
+// so packing the same input with the same seed gives byte-identical package, rsa is not supported in this mode
+// algorithm now support 'AES', 'DES', '3DES', 'RSA', 'BASE64', 'AESGCM' and 'MIXED', you can send both up case and low case
+// every 'AESGCM' entry is authenticated, broken or tampered entry fails to unpack instead of giving wrong data
+// every entry of 'MIXED' package records its own algorithm, see Entry.Algorithm, files are packed by 'AES'
+// return err indicate the success or failure function execute
+func PackWithOption(src []string, dest string, algorithm string, opt PackOption) (err error) {
+	var entries []Entry
+	for _, v := range src {
+		data, err := ioutil.ReadFile(v)
+		if err != nil {
+			log.Println("Error read file:", err)
+			return err
+		}
+		fi, err := os.Stat(v)
+		if err != nil {
+			log.Println("Error stat file:", err)
+			return err
+		}
+		_, name := filepath.Split(v)
+		entries = append(entries, Entry{Name: name, Bytes: data, Mode: fi.Mode().Perm(), ModTime: fi.ModTime()})
+	}
+	return PackEntriesWithOption(entries, dest, algorithm, opt)
+}
+
+// PackEntriesWithOption function
+// it is common with function PackWithOption, just the entry data come from memory or reader instead of files
+// return err indicate the success or failure function execute
+func PackEntriesWithOption(entries []Entry, dest string, algorithm string, opt PackOption) (err error) {
+	var buf bytes.Buffer
+	err = PackToWriterWithOption(entries, &buf, algorithm, opt)
+	if err != nil {
+		return err
+	}
+	return writeOutput(buf.Bytes(), dest, opt)
+}
+
+// writeOutput function
+// it will write package data into dest file, or volume parts when opt.VolumeSize is set,
+// then write parity files when opt.Parity is set
+func writeOutput(data []byte, dest string, opt PackOption) (err error) {
+	if opt.VolumeSize > 0 {
+		parts, err := writeVolume(data, dest, opt.VolumeSize, opt.Seed)
+		if err != nil || opt.Parity == 0 {
+			return err
+		}
+		for _, v := range parts {
+			err = GenerateParity(v, opt.Parity)
+			if err != nil {
+				return err
+			}
+		}
+		return err
+	}
+	err = ioutil.WriteFile(dest, data, 0644)
+	if err != nil {
+		log.Println("Error write package file:", err)
+		return err
+	}
+	if opt.Parity > 0 {
+		err = writeParity(data, dest, opt.Parity)
+	}
+	return err
+}
+
+// PackToWriterWithOption function
+// input entry list, output writer, algorithm which used in pack and pack option, return error info
+// package layout is header, extension size, gob encoded extension, then entries
+// the entries of sealed package are bodies only, their headers are kept in sealed table of extension
+// return err indicate the success or failure function execute
+func PackToWriterWithOption(entries []Entry, w io.Writer, algorithm string, opt PackOption) (err error) {
+	if opt.Version == 1 {
+		err = checkLegacyOption(opt)
+		if err != nil {
+			return err
+		}
+		return packLegacy(entries, w, algorithm, packMeta(opt.Meta).Author)
+	}
+	return packToWriter(entries, w, algorithm, opt, TPackManifest{}, nil)
+}
+
+// checkLegacyOption function
+// package format v1 only records author in header, the option which needs header extension is refused
+func checkLegacyOption(opt PackOption) (err error) {
+	m := opt.Meta
+	if opt.TableKey != nil || opt.PadSize != 0 || opt.PadPow2 || opt.MetaDigest || opt.Seed != nil ||
+		m.Creator != "" || m.Version != "" || !m.Created.IsZero() || m.Comment != "" || m.Labels != nil {
+		err = errors.New("Package format v1 only records author, other option needs format v2.")
+	}
+	return err
+}
+
+// packToWriter function
+// it is common with function PackToWriterWithOption, m is the manifest of unchanged files for incremental package
+// records of the packed entries are added into m, then m is stored in header extension
+// id is the package uuid which is kept by rekey, nil makes a new one
+func packToWriter(entries []Entry, w io.Writer, algorithm string, opt PackOption, m TPackManifest, id []byte) (err error) {
+	// start multi-cpu
+	core := runtime.NumCPU()
+	runtime.GOMAXPROCS(core)
+	// clear global variable
+	atomic.StoreInt64(&Done, 0)
+	// first, check the entry names and option
+	err = checkEntries(entries)
+	if err != nil {
+		return err
+	}
+	algorithm = packType(algorithm)
+	if algorithm != MixedType {
+		_, err = unpack.EntryKeySize(algorithm)
+		if err != nil {
+			return err
+		}
+	}
+	meta := packMeta(opt.Meta)
+	if opt.Seed != nil {
+		entries, meta, m, err = deterministic(entries, meta, m)
+		if err != nil {
+			return err
+		}
+	}
+	types, err := entryTypes(entries, algorithm)
+	if err != nil {
+		return err
+	}
+	pad, err := checkOption(opt, types)
+	if err != nil {
+		return err
+	}
+	// second, pack every entry through goroutine
+	r, files, err := packEntries(entries, algorithm, types, pad, opt.Seed)
+	if err != nil {
+		return err
+	}
+	m.Files = append(m.Files, files...)
+	sort.Slice(m.Files, func(i, j int) bool { return m.Files[i].Name < m.Files[j].Name })
+	// third, fill the header
+	head := TPackAES{}
+	head.Name = make([]byte, 32)
+	head.Author = make([]byte, 16)
+	head.Type = make([]byte, 8)
+	head.Number = make([]byte, 4)
+	if id == nil && opt.Seed != nil {
+		digest := sha256.Sum256(bytes.Join(r, []byte("")))
+		id, err = seedUUID(opt.Seed, digest[:])
+	} else if id == nil {
+		id, err = NewUUID()
+	}
+	if err != nil {
+		log.Println("Error new package uuid:", err)
+		return err
+	}
+	BytesCopy(&(head.Name), id)
+	BytesCopy(&(head.Author), []byte(truncate(meta.Author, 16)))
+	BytesCopy(&(head.Type), []byte(algorithm))
+	head.Type[PackVersionOffset-48] = PackVersion2
+	if opt.TableKey == nil {
+		BytesCopy(&(head.Number), IntToBytes(len(entries)))
+	}
+	h := bytes.Join([][]byte{head.Name, head.Author, head.Type, head.Number}, []byte(""))
+	// fourth, seal the entry table
+	ext := TPackExtend{}
+	if opt.TableKey != nil {
+		var table [][]byte
+		for k, v := range r {
+			n, _ := unpack.EntryHeadSize(types[k], algorithm == MixedType)
+			table = append(table, v[:n])
+			r[k] = v[n:]
+		}
+		ext.Sealed = true
+		ext.Table, err = sealBytesSeed(opt.TableKey, bytes.Join(table, []byte("")), h, opt.Seed)
+		if err != nil {
+			log.Println("Error seal entry table:", err)
+			return err
+		}
+	}
+	// fifth, encode the metadata
+	ext.Meta, err = encodeMeta(meta)
+	if err != nil {
+		return err
+	}
+	if opt.MetaDigest {
+		ext.MetaDigest, ext.MetaKeyed = metaDigest(ext.Meta, opt.TableKey)
+	}
+	// sixth, encode the manifest, file names are sealed as well as entry table
+	var buf bytes.Buffer
+	err = GobEncode(&buf, m)
+	if err != nil {
+		return err
+	}
+	ext.Manifest = buf.Bytes()
+	if opt.TableKey != nil {
+		ext.Manifest, err = sealBytesSeed(opt.TableKey, ext.Manifest, h, opt.Seed)
+		if err != nil {
+			log.Println("Error seal manifest:", err)
+			return err
+		}
+	}
+	// seventh, encode the extension
+	buf = bytes.Buffer{}
+	err = GobEncode(&buf, ext)
+	if err != nil {
+		return err
+	}
+	// finally, write to writer
+	s := [][]byte{h, IntToBytes(buf.Len()), buf.Bytes()}
+	s = append(s, r...)
+	_, err = w.Write(bytes.Join(s, []byte("")))
+	if err != nil {
+		log.Println("Error write package:", err)
+	}
+	return err
+}
+
+// checkOption function
+// it will check the pack option with algorithm of every entry and return the padding function
+func checkOption(opt PackOption, types []string) (pad func(int) int, err error) {
+	algorithm := make(map[string]bool)
+	for _, v := range types {
+		algorithm[v] = true
+	}
+	if opt.Version != 0 && opt.Version != 2 {
+		s := fmt.Sprintf("Invalid package format version: %v, only PackToWriterWithOption writes format v1", opt.Version)
+		err = errors.New(s)
+		return pad, err
+	}
+	if opt.TableKey != nil {
+		switch len(opt.TableKey) {
+		case 16, 24, 32:
+		default:
+			s := fmt.Sprintf("Invalid table key length: %v", len(opt.TableKey))
+			err = errors.New(s)
+			return pad, err
+		}
+	}
+	if opt.VolumeSize < 0 || (opt.VolumeSize > 0 && opt.VolumeSize <= VolumeHeadSize) {
+		s := fmt.Sprintf("Invalid volume size: %v", opt.VolumeSize)
+		err = errors.New(s)
+		return pad, err
+	}
+	if opt.Seed != nil && algorithm["RSA"] {
+		err = errors.New("Deterministic package does not support rsa, its key generation and padding are random.")
+		return pad, err
+	}
+	if opt.Parity < 0 || opt.Parity > 100 {
+		s := fmt.Sprintf("Invalid parity percent: %v", opt.Parity)
+		err = errors.New(s)
+		return pad, err
+	}
+	if opt.PadSize < 0 {
+		s := fmt.Sprintf("Invalid pad size: %v", opt.PadSize)
+		err = errors.New(s)
+		return pad, err
+	}
+	if opt.PadSize == 0 && !opt.PadPow2 {
+		return pad, err
+	}
+	// padding hides nothing when sizes are plain in entry table
+	if opt.TableKey == nil {
+		err = errors.New("Padding needs sealed entry table, please set table key.")
+		return pad, err
+	}
+	// base64 entry does not record origin size, padding could not be removed
+	if algorithm["BASE64"] {
+		err = errors.New("Padding is not supported by base64.")
+		return pad, err
+	}
+	pad = func(size int) int {
+		if opt.PadPow2 {
+			n := 1
+			for n < size {
+				n <<= 1
+			}
+			size = n
+		}
+		if opt.PadSize > 0 && size%opt.PadSize != 0 {
+			size += opt.PadSize - size%opt.PadSize
+		}
+		return size
+	}
+	return pad, err
+}
+
+// packMeta function
+// it fills the default values of metadata
+func packMeta(meta TPackMeta) TPackMeta {
+	if meta.Author == "" {
+		meta.Author = PackAuthor
+	}
+	if meta.Creator == "" {
+		meta.Creator = QoraName
+		meta.Version = QoraVersion
+	}
+	if meta.Created.IsZero() {
+		meta.Created = time.Now()
+	}
+	meta.Created = meta.Created.UTC()
+	return meta
+}
+
+// metaLabel struct
+// metadata label as it is encoded in header extension
+type metaLabel struct {
+	K string // label name
+	V string // label value
+}
+
+// metaGob struct
+// metadata as it is encoded in header extension, gob encodes map in random order,
+// so labels are encoded as a name sorted list and the same metadata always gives the same bytes
+type metaGob struct {
+	Author    string
+	Creator   string
+	Version   string
+	Created   time.Time
+	Comment   string
+	LabelList []metaLabel
+}
+
+// encodeMeta function
+// it gob encodes metadata with labels sorted by name
+// return err indicate the success or failure function execute
+func encodeMeta(meta TPackMeta) (data []byte, err error) {
+	g := metaGob{Author: meta.Author, Creator: meta.Creator, Version: meta.Version, Created: meta.Created, Comment: meta.Comment}
+	for k, v := range meta.Labels {
+		g.LabelList = append(g.LabelList, metaLabel{K: k, V: v})
+	}
+	sort.Slice(g.LabelList, func(i, j int) bool { return g.LabelList[i].K < g.LabelList[j].K })
+	var buf bytes.Buffer
+	err = GobEncode(&buf, g)
+	if err != nil {
+		return data, err
+	}
+	data = buf.Bytes()
+	return data, err
+}
+
+// deterministic function
+// it sorts entries by name and fixes create time and modify time to source date epoch,
+// newer modify time is clamped to it
+func deterministic(entries []Entry, meta TPackMeta, m TPackManifest) ([]Entry, TPackMeta, TPackManifest, error) {
+	epoch := sourceDateEpoch()
+	meta.Created = epoch
+	r := make([]Entry, len(entries))
+	copy(r, entries)
+	sort.Slice(r, func(i, j int) bool { return r[i].Name < r[j].Name })
+	for k, v := range r {
+		if v.ModTime.IsZero() || v.ModTime.After(epoch) {
+			r[k].ModTime = epoch
+		}
+		r[k].ModTime = r[k].ModTime.UTC()
+	}
+	files := make([]TPackManifestFile, len(m.Files))
+	for k, v := range m.Files {
+		if v.ModTime.IsZero() || v.ModTime.After(epoch) {
+			v.ModTime = epoch
+		}
+		v.ModTime = v.ModTime.UTC()
+		files[k] = v
+	}
+	m.Files = files
+	return r, meta, m, nil
+}
+
+// metaDigest function
+// it returns hmac-sha256 of metadata when key is not nil, otherwise sha256
+func metaDigest(meta []byte, key []byte) (digest []byte, keyed bool) {
+	if key != nil {
+		m := hmac.New(sha256.New, key)
+		m.Write(meta)
+		return m.Sum(nil), true
+	}
+	r := sha256.Sum256(meta)
+	return r[:], false
+}
+
+// truncate function
+// it cuts string to no more than size bytes without break utf-8 character
+func truncate(s string, size int) string {
+	if len(s) <= size {
+		return s
+	}
+	for size > 0 && !utf8.RuneStart(s[size]) {
+		size--
+	}
+	return s[:size]
+}
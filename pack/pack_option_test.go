@@ -0,0 +1,188 @@
+package pack
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"qora/unpack"
+	"testing"
+)
+
+// TestPackWithOption function
+func TestPackWithOption(t *testing.T) {
+	src := []string{"../test/data/pack/file_1.txt", "../test/data/pack/file_2.txt", "../test/data/pack/file_3.txt"}
+	key := []byte("Satellite-266414")
+	for _, algorithm := range []string{"AES", "DES", "3DES", "RSA", "BASE64"} {
+		dir := t.TempDir()
+		dest := filepath.Join(dir, "file_option.pak")
+		err := PackWithOption(src, dest, algorithm, PackOption{TableKey: key})
+		if err != nil {
+			t.Fatal("Error Pack With Option:", algorithm, err)
+		}
+		// entry names should not be visible without key
+		data, _ := ioutil.ReadFile(dest)
+		if bytes.Contains(data, []byte("file_1.txt")) {
+			t.Fatal("Error Pack With Option: entry name is plain", algorithm)
+		}
+		_, err = unpack.ParsePackage(dest)
+		if err != unpack.ErrSealedPackage {
+			t.Fatal("Error Pack With Option: sealed package should need key", algorithm, err)
+		}
+		_, err = unpack.ParsePackageWithOption(dest, unpack.UnpackOption{TableKey: []byte("Satellite-000000")})
+		if err == nil {
+			t.Fatal("Error Pack With Option: wrong key should fail", algorithm)
+		}
+		err = unpack.UnpackWithOption(dest, dir+"/", unpack.UnpackOption{TableKey: key})
+		if err != nil {
+			t.Fatal("Error Unpack With Option:", algorithm, err)
+		}
+		for _, v := range src {
+			r, _ := ioutil.ReadFile(filepath.Join(dir, filepath.Base(v)))
+			s, _ := ioutil.ReadFile(v)
+			if !bytes.Equal(r, s) {
+				t.Fatal("Error Unpack With Option content:", algorithm, v)
+			}
+		}
+	}
+}
+
+// TestPackWithOptionPadding function
+func TestPackWithOptionPadding(t *testing.T) {
+	key := []byte("Satellite-266414Satellite-266414")
+	entries := []Entry{{Name: "a.txt", Bytes: []byte("hello")}, {Name: "b.txt", Bytes: bytes.Repeat([]byte("q"), 3000)}}
+	dest := filepath.Join(t.TempDir(), "file_option.pak")
+	err := PackEntriesWithOption(entries, dest, "AES", PackOption{TableKey: key, PadSize: 1024})
+	if err != nil {
+		t.Fatal("Error Pack Entries With Option:", err)
+	}
+	p, err := unpack.ParsePackageWithOption(dest, unpack.UnpackOption{TableKey: key})
+	if err != nil {
+		t.Fatal("Error Parse Package With Option:", err)
+	}
+	if p.Entries[0].CryptSize != 1024 || p.Entries[1].CryptSize != 3072 {
+		t.Fatal("Error Pack With Option padding:", p.Entries[0].CryptSize, p.Entries[1].CryptSize)
+	}
+	r, err := unpack.UnpackAllToMemoryWithOption(dest, unpack.UnpackOption{TableKey: key})
+	if err != nil || string(r["a.txt"]) != "hello" || len(r["b.txt"]) != 3000 {
+		t.Fatal("Error Unpack padded entries:", err)
+	}
+	err = PackEntriesWithOption(entries, dest, "AES", PackOption{TableKey: key, PadPow2: true})
+	if err != nil {
+		t.Fatal("Error Pack Entries With Option:", err)
+	}
+	p, _ = unpack.ParsePackageWithOption(dest, unpack.UnpackOption{TableKey: key})
+	if p.Entries[1].CryptSize != 4096 {
+		t.Fatal("Error Pack With Option power of two padding:", p.Entries[1].CryptSize)
+	}
+	err = PackEntriesWithOption(entries, dest, "AES", PackOption{PadSize: 1024})
+	if err == nil {
+		t.Fatal("Error Pack With Option: padding without table key should fail")
+	}
+}
+
+// TestPackWithOptionPlain function
+func TestPackWithOptionPlain(t *testing.T) {
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "file_option.pak")
+	err := PackWithOption([]string{"../test/data/pack/file_1.txt"}, dest, "aes", PackOption{})
+	if err != nil {
+		t.Fatal("Error Pack With Option:", err)
+	}
+	err = unpack.Unpack(dest, dir+"/")
+	if err != nil {
+		t.Fatal("Error Unpack v2 package:", err)
+	}
+	var names []string
+	var sz []int
+	var algorithm string
+	err = unpack.ExtractInfo(dest, &names, &sz, &algorithm)
+	if err != nil || len(names) != 1 || names[0] != "file_1.txt" || algorithm != "aes" {
+		t.Fatal("Error Extract Info v2 package:", err)
+	}
+}
+
+// TestPackWithOptionMeta function
+func TestPackWithOptionMeta(t *testing.T) {
+	key := []byte("Satellite-266414")
+	entries := []Entry{{Name: "a.txt", Bytes: []byte("hello")}}
+	meta := TPackMeta{Author: "雪狐-Alopex6414-Qora", Comment: "nightly build", Labels: map[string]string{"env": "test"}}
+	dir := t.TempDir()
+	// plain table with sha256 digest
+	dest := filepath.Join(dir, "file_meta.pak")
+	err := PackEntriesWithOption(entries, dest, "AES", PackOption{Meta: meta, MetaDigest: true})
+	if err != nil {
+		t.Fatal("Error Pack Entries With Option:", err)
+	}
+	r, err := unpack.ExtractMeta(dest)
+	if err != nil {
+		t.Fatal("Error Extract Meta:", err)
+	}
+	if r.Author != meta.Author || r.Comment != meta.Comment || r.Labels["env"] != "test" || r.Creator != "Qora" || r.Created.IsZero() || !r.Verified {
+		t.Fatal("Error Extract Meta content:", r)
+	}
+	p, _ := unpack.ParsePackage(dest)
+	if p.Author != "雪狐-Alopex641" {
+		t.Fatal("Error Pack With Option header author:", p.Author)
+	}
+	// tampered metadata should be rejected
+	data, _ := ioutil.ReadFile(dest)
+	data = bytes.Replace(data, []byte("nightly"), []byte("hourly!"), 1)
+	_, err = unpack.ParsePackageBytes(data)
+	if err == nil {
+		t.Fatal("Error Parse Package: tampered metadata should fail")
+	}
+	// sealed table with keyed digest, metadata is readable without key
+	dest = filepath.Join(dir, "file_meta_sealed.pak")
+	err = PackEntriesWithOption(entries, dest, "AES", PackOption{TableKey: key, Meta: meta, MetaDigest: true})
+	if err != nil {
+		t.Fatal("Error Pack Entries With Option:", err)
+	}
+	r, err = unpack.ExtractMeta(dest)
+	if err != nil || r.Comment != meta.Comment || r.Verified {
+		t.Fatal("Error Extract Meta without key:", r, err)
+	}
+	r, err = unpack.ExtractMetaWithOption(dest, unpack.UnpackOption{TableKey: key})
+	if err != nil || !r.Verified {
+		t.Fatal("Error Extract Meta with key:", r, err)
+	}
+}
+
+// TestPackWithOptionLegacy function
+func TestPackWithOptionLegacy(t *testing.T) {
+	src := []string{"../test/data/pack/file_1.txt", "../test/data/pack/file_2.txt"}
+	opt := PackOption{Version: 1, Meta: TPackMeta{Author: "Satellite-266414-Qora"}}
+	for _, algorithm := range []string{"AES", "DES", "3DES", "RSA", "BASE64"} {
+		dir := t.TempDir()
+		dest := filepath.Join(dir, "file_legacy.pak")
+		err := PackWithOption(src, dest, algorithm, opt)
+		if err != nil {
+			t.Fatal("Error Pack With Option legacy:", algorithm, err)
+		}
+		p, err := unpack.ParsePackage(dest)
+		if err != nil || p.Version != 1 || p.Author != "Satellite-266414" {
+			t.Fatal("Error Parse Package legacy author:", algorithm, err)
+		}
+		// unpack of format v1 accepts any author
+		err = unpack.Unpack(dest, dir+"/")
+		if err != nil {
+			t.Fatal("Error Unpack legacy:", algorithm, err)
+		}
+		for _, v := range src {
+			r, _ := ioutil.ReadFile(filepath.Join(dir, filepath.Base(v)))
+			s, _ := ioutil.ReadFile(v)
+			if !bytes.Equal(r, s) {
+				t.Fatal("Error Unpack legacy content:", algorithm, v)
+			}
+		}
+	}
+	for _, v := range []PackOption{
+		{Version: 1, TableKey: []byte("Satellite-266414")},
+		{Version: 1, Meta: TPackMeta{Comment: "nightly build"}},
+		{Version: 3},
+	} {
+		err := PackEntriesWithOption([]Entry{{Name: "a.txt", Bytes: []byte("hello")}}, filepath.Join(t.TempDir(), "a.pak"), "AES", v)
+		if err == nil {
+			t.Fatal("Error Pack With Option: invalid legacy option should fail", v.Version)
+		}
+	}
+}
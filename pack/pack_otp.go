@@ -0,0 +1,233 @@
+package pack
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	. "qora/global"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HOTP function
+// This function is mainly used for generate the counter based one-time password of RFC 4226.
+// input secret, counter and option, return the code and error info
+// opt.Algorithm now support 'sha1', 'sha256' and 'sha512', you can send both up case and low case, empty is 'sha1'
+// secret should have at least OTPMinSecretSize bytes, code has opt.Digits digits with leading zero, like '050471'
+// return code the one-time password, err indicate the success or failure function execute
+func HOTP(secret []byte, counter uint64, opt OTPOption) (code string, err error) {
+	opt, err = otpOption(opt)
+	if err != nil {
+		return code, err
+	}
+	if len(secret) < OTPMinSecretSize {
+		s := fmt.Sprintf("OTP secret size %v is less than %v.", len(secret), OTPMinSecretSize)
+		err = errors.New(s)
+		return code, err
+	}
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+	mac, err := HMAC(opt.Algorithm, secret, msg)
+	if err != nil {
+		return code, err
+	}
+	// dynamic truncation
+	offset := mac[len(mac)-1] & 0x0f
+	v := binary.BigEndian.Uint32(mac[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < opt.Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", opt.Digits, v%mod), err
+}
+
+// VerifyHOTP function
+// input secret, code, expected counter and option, return the matched counter, whether code matches and error info
+// counters from counter to counter + opt.Skew are tried, so the token which is pressed without login is resynchronized,
+// opt.Replay is called with the matched counter, store matched + 1 as the next expected counter when ok
+// codes are compared in constant time
+// return matched the counter of code, ok indicate check pass or failed, err indicate the success or failure function execute
+func VerifyHOTP(secret []byte, code string, counter uint64, opt OTPOption) (matched uint64, ok bool, err error) {
+	opt, err = otpOption(opt)
+	if err != nil {
+		return matched, ok, err
+	}
+	return otpMatch(secret, code, counter, opt.Skew+1, opt)
+}
+
+// TOTP function
+// This function is mainly used for generate the time based one-time password of RFC 6238, like the 2fa of login.
+// input secret, time and option, return the code and error info
+// time step is unix time divided by opt.Period, empty period is OTPPeriod, other option is the same as HOTP
+// return code the one-time password, err indicate the success or failure function execute
+func TOTP(secret []byte, t time.Time, opt OTPOption) (code string, err error) {
+	step, err := otpStep(t, opt)
+	if err != nil {
+		return code, err
+	}
+	return HOTP(secret, step, opt)
+}
+
+// VerifyTOTP function
+// input secret, code, time and option, return the matched time step, whether code matches and error info
+// steps from current - opt.Skew to current + opt.Skew are tried, so the clock skew of device is tolerated,
+// opt.Replay is called with the matched step, the same code could be used only once when the hook rejects
+// the step which is not greater than the last accepted one
+// codes are compared in constant time
+// return matched the time step of code, ok indicate check pass or failed, err indicate the success or failure function execute
+func VerifyTOTP(secret []byte, code string, t time.Time, opt OTPOption) (matched uint64, ok bool, err error) {
+	opt, err = otpOption(opt)
+	if err != nil {
+		return matched, ok, err
+	}
+	step, err := otpStep(t, opt)
+	if err != nil {
+		return matched, ok, err
+	}
+	first := step - uint64(opt.Skew)
+	if step < uint64(opt.Skew) {
+		first = 0
+	}
+	return otpMatch(secret, code, first, int(step-first)+opt.Skew+1, opt)
+}
+
+// OTPAuthURI function
+// This function is mainly used for enrol the secret into authenticator app, the uri is usually shown as qr code.
+// input type 'totp' or 'hotp', secret, issuer, account name, initial counter of hotp and option, return the uri and error info
+// the uri is key uri format like 'otpauth://totp/Qora:alice@example.com?algorithm=SHA1&digits=6&issuer=Qora&period=30&secret=...',
+// secret is base32 without padding, counter is only written for 'hotp'
+// return uri the otpauth uri, err indicate the success or failure function execute
+func OTPAuthURI(typ string, secret []byte, issuer string, account string, counter uint64, opt OTPOption) (uri string, err error) {
+	opt, err = otpOption(opt)
+	if err != nil {
+		return uri, err
+	}
+	typ = strings.ToLower(typ)
+	if typ != "totp" && typ != "hotp" {
+		s := fmt.Sprintf("Undefined otp type: %v", typ)
+		err = errors.New(s)
+		return uri, err
+	}
+	if account == "" || strings.Contains(issuer, ":") || len(secret) < OTPMinSecretSize {
+		s := fmt.Sprintf("Invalid otp uri params: issuer %q, account %q, secret size %v", issuer, account, len(secret))
+		err = errors.New(s)
+		return uri, err
+	}
+	label := account
+	v := url.Values{}
+	if issuer != "" {
+		label = issuer + ":" + account
+		v.Set("issuer", issuer)
+	}
+	v.Set("secret", base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secret))
+	v.Set("algorithm", strings.ToUpper(opt.Algorithm))
+	v.Set("digits", strconv.Itoa(opt.Digits))
+	if typ == "totp" {
+		v.Set("period", strconv.FormatInt(opt.Period, 10))
+	} else {
+		v.Set("counter", strconv.FormatUint(counter, 10))
+	}
+	u := url.URL{Scheme: "otpauth", Host: typ, Path: "/" + label, RawQuery: strings.ReplaceAll(v.Encode(), "+", "%20")}
+	return u.String(), err
+}
+
+// NewOTPSecret function
+// input secret size, return the random secret and error info, size 0 is OTPSecretSize
+// return secret the random bytes, err indicate the success or failure function execute
+func NewOTPSecret(size int) (secret []byte, err error) {
+	if size == 0 {
+		size = OTPSecretSize
+	}
+	if size < OTPMinSecretSize {
+		s := fmt.Sprintf("OTP secret size %v is less than %v.", size, OTPMinSecretSize)
+		err = errors.New(s)
+		return secret, err
+	}
+	secret = make([]byte, size)
+	_, err = rand.Read(secret)
+	if err != nil {
+		log.Println("Error generate random secret:", err)
+	}
+	return secret, err
+}
+
+// ParseOTPSecret function
+// input base32 secret, like the secret of otpauth uri, return the secret bytes and error info
+// padding, space and low case are accepted
+// return secret the secret bytes, err indicate the success or failure function execute
+func ParseOTPSecret(src string) (secret []byte, err error) {
+	src = strings.ToUpper(strings.TrimRight(strings.ReplaceAll(src, " ", ""), "="))
+	secret, err = base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(src)
+	if err != nil {
+		s := fmt.Sprintf("Invalid base32 otp secret: %v", err)
+		err = errors.New(s)
+	}
+	return secret, err
+}
+
+// otpOption function
+// fill the zero option with default and check it
+func otpOption(opt OTPOption) (r OTPOption, err error) {
+	r = opt
+	r.Algorithm = strings.TrimPrefix(strings.ToLower(r.Algorithm), "hmac_")
+	if r.Algorithm == "" {
+		r.Algorithm = OTPAlgorithm
+	}
+	if r.Digits == 0 {
+		r.Digits = OTPDigits
+	}
+	if r.Period == 0 {
+		r.Period = OTPPeriod
+	}
+	switch r.Algorithm {
+	case "sha1", "sha256", "sha512":
+	default:
+		s := fmt.Sprintf("Undefined otp algorithm: %v", opt.Algorithm)
+		err = errors.New(s)
+		return r, err
+	}
+	if r.Digits < 6 || r.Digits > 8 || r.Period < 1 || r.Skew < 0 || r.Skew > OTPMaxSkew {
+		s := fmt.Sprintf("Invalid otp option: digits %v, period %v, skew %v", r.Digits, r.Period, r.Skew)
+		err = errors.New(s)
+	}
+	return r, err
+}
+
+// otpMatch function
+// try n counters from first, call opt.Replay with the matched one
+func otpMatch(secret []byte, code string, first uint64, n int, opt OTPOption) (matched uint64, ok bool, err error) {
+	for i := 0; i < n; i++ {
+		r, err := HOTP(secret, first+uint64(i), opt)
+		if err != nil {
+			return matched, ok, err
+		}
+		if hashEqual(r, code) {
+			matched, ok = first+uint64(i), true
+			break
+		}
+	}
+	if ok && opt.Replay != nil {
+		ok = opt.Replay(matched)
+	}
+	return matched, ok, err
+}
+
+// otpStep function
+// return the totp time step of t
+func otpStep(t time.Time, opt OTPOption) (step uint64, err error) {
+	opt, err = otpOption(opt)
+	if err != nil {
+		return step, err
+	}
+	if t.Unix() < 0 {
+		s := fmt.Sprintf("OTP time is before unix epoch: %v", t)
+		err = errors.New(s)
+		return step, err
+	}
+	return uint64(t.Unix() / opt.Period), err
+}
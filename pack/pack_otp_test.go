@@ -0,0 +1,132 @@
+package pack
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHOTP function
+func TestHOTP(t *testing.T) {
+	// RFC 4226 appendix D
+	secret := []byte("12345678901234567890")
+	codes := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for k, v := range codes {
+		code, err := HOTP(secret, uint64(k), OTPOption{})
+		if err != nil || code != v {
+			t.Fatal("Error HOTP:", k, code, err)
+		}
+	}
+	// look ahead window resynchronizes the counter
+	matched, ok, err := VerifyHOTP(secret, codes[4], 2, OTPOption{Skew: 2})
+	if err != nil || !ok || matched != 4 {
+		t.Fatal("Error Verify HOTP:", matched, ok, err)
+	}
+	_, ok, _ = VerifyHOTP(secret, codes[5], 2, OTPOption{Skew: 2})
+	if ok {
+		t.Fatal("Error Verify HOTP: counter out of window should fail")
+	}
+	_, ok, _ = VerifyHOTP(secret, codes[1], 2, OTPOption{Skew: 2})
+	if ok {
+		t.Fatal("Error Verify HOTP: used counter should fail")
+	}
+	_, err = HOTP(secret[:10], 0, OTPOption{})
+	if err == nil {
+		t.Fatal("Error HOTP: short secret should fail")
+	}
+	for _, v := range []OTPOption{{Algorithm: "md5"}, {Digits: 5}, {Digits: 9}, {Skew: 11}} {
+		_, _, err = VerifyHOTP(secret, codes[0], 0, v)
+		if err == nil {
+			t.Fatal("Error Verify HOTP: invalid option should fail", v)
+		}
+	}
+}
+
+// TestTOTP function
+func TestTOTP(t *testing.T) {
+	// RFC 6238 appendix B
+	secrets := map[string][]byte{
+		"sha1":   []byte("12345678901234567890"),
+		"sha256": []byte("12345678901234567890123456789012"),
+		"sha512": []byte("1234567890123456789012345678901234567890123456789012345678901234"),
+	}
+	for _, v := range []struct {
+		t                    int64
+		sha1, sha256, sha512 string
+	}{
+		{59, "94287082", "46119246", "90693936"},
+		{1111111109, "07081804", "68084774", "25091201"},
+		{20000000000, "65353130", "77737706", "47863826"},
+	} {
+		for algorithm, code := range map[string]string{"sha1": v.sha1, "sha256": v.sha256, "SHA512": v.sha512} {
+			r, err := TOTP(secrets[strings.ToLower(algorithm)], time.Unix(v.t, 0), OTPOption{Algorithm: algorithm, Digits: 8})
+			if err != nil || r != code {
+				t.Fatal("Error TOTP:", algorithm, v.t, r, err)
+			}
+		}
+	}
+	// skew window and replay hook
+	secret, err := NewOTPSecret(0)
+	if err != nil || len(secret) != 20 {
+		t.Fatal("Error New OTP Secret:", err)
+	}
+	now := time.Unix(1700000000, 0)
+	code, _ := TOTP(secret, now.Add(-30*time.Second), OTPOption{})
+	_, ok, err := VerifyTOTP(secret, code, now, OTPOption{})
+	if err != nil || ok {
+		t.Fatal("Error Verify TOTP: previous step should fail without skew", err)
+	}
+	var last uint64
+	opt := OTPOption{Skew: 1, Replay: func(step uint64) bool {
+		if step <= last {
+			return false
+		}
+		last = step
+		return true
+	}}
+	matched, ok, err := VerifyTOTP(secret, code, now, opt)
+	if err != nil || !ok || matched != uint64(now.Unix()/30)-1 {
+		t.Fatal("Error Verify TOTP skew:", matched, ok, err)
+	}
+	_, ok, _ = VerifyTOTP(secret, code, now, opt)
+	if ok {
+		t.Fatal("Error Verify TOTP: replayed code should fail")
+	}
+	code, _ = TOTP(secret, now, OTPOption{})
+	_, ok, _ = VerifyTOTP(secret, code, now, opt)
+	if !ok {
+		t.Fatal("Error Verify TOTP: next step should pass")
+	}
+	_, err = TOTP(secret, time.Unix(-1, 0), OTPOption{})
+	if err == nil {
+		t.Fatal("Error TOTP: time before epoch should fail")
+	}
+}
+
+// TestOTPAuthURI function
+func TestOTPAuthURI(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	uri, err := OTPAuthURI("totp", secret, "Qora Admin", "alice@example.com", 0, OTPOption{})
+	if err != nil || uri != "otpauth://totp/Qora%20Admin:alice@example.com?algorithm=SHA1&digits=6&issuer=Qora%20Admin&period=30&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" {
+		t.Fatal("Error OTP Auth URI:", uri, err)
+	}
+	u, err := url.Parse(uri)
+	if err != nil || u.Path != "/Qora Admin:alice@example.com" {
+		t.Fatal("Error OTP Auth URI parse:", err)
+	}
+	r, err := ParseOTPSecret(strings.ToLower(u.Query().Get("secret")))
+	if err != nil || string(r) != string(secret) {
+		t.Fatal("Error Parse OTP Secret:", err)
+	}
+	uri, err = OTPAuthURI("HOTP", secret, "", "bob", 7, OTPOption{Algorithm: "sha256", Digits: 8})
+	if err != nil || uri != "otpauth://hotp/bob?algorithm=SHA256&counter=7&digits=8&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" {
+		t.Fatal("Error OTP Auth URI hotp:", uri, err)
+	}
+	for _, v := range []string{"motp", "totp"} {
+		_, err = OTPAuthURI(v, secret, "Qo:ra", "alice", 0, OTPOption{})
+		if err == nil {
+			t.Fatal("Error OTP Auth URI: invalid params should fail", v)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package pack
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	. "qora/global"
+	"qora/unpack"
+	. "qora/utils"
+)
+
+// GenerateParity function
+// input package path and parity percent, output error info
+// it writes reed-solomon parity file 'name.qpar' beside the package, package is split into 4KB shards,
+// every stripe of 128 shards gets percent of parity shards(at least one), for instance, 10 means 13 parity shards
+// unpack will repair at most that number of damaged or missing shards in every stripe by itself
+// Append, Remove, Replace and Merge generate parity file again, it should be generated again after other edit
+// return err indicate the success or failure function execute
+func GenerateParity(src string, percent int) (err error) {
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		log.Println("Error read file:", err)
+		return err
+	}
+	return writeParity(data, src, percent)
+}
+
+// writeParity function
+// it will compute the parity shards of package data and write parity file of dest
+func writeParity(data []byte, dest string, percent int) (err error) {
+	if percent <= 0 || percent > 100 {
+		s := fmt.Sprintf("Invalid parity percent: %v", percent)
+		err = errors.New(s)
+		return err
+	}
+	if len(data) == 0 {
+		err = errors.New("Package is empty.")
+		return err
+	}
+	// first, split package into zero padded shards
+	head := TPackParity{Size: len(data), ShardSize: ParityShardSize, StripeSize: ParityStripeSize, Percent: percent}
+	var shards [][]byte
+	for i := 0; i < len(data); i += ParityShardSize {
+		s := make([]byte, ParityShardSize)
+		copy(s, data[i:])
+		shards = append(shards, s)
+	}
+	// second, compute parity shards stripe by stripe
+	var parity [][]byte
+	for i := 0; i < len(shards); i += ParityStripeSize {
+		end := i + ParityStripeSize
+		if end > len(shards) {
+			end = len(shards)
+		}
+		r, err := RSEncode(shards[i:end], parityNumber(end-i, percent))
+		if err != nil {
+			log.Println("Error reed-solomon encode:", err)
+			return err
+		}
+		parity = append(parity, r...)
+	}
+	for _, v := range append(shards, parity...) {
+		digest := sha256.Sum256(v)
+		head.Digests = append(head.Digests, digest[:])
+	}
+	// third, encode the header
+	var buf bytes.Buffer
+	err = GobEncode(&buf, head)
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(buf.Bytes())
+	// finally, write parity file
+	r := [][]byte{[]byte(ParityMagic), IntToBytes(buf.Len()), digest[:], buf.Bytes()}
+	r = append(r, parity...)
+	err = ioutil.WriteFile(dest+ParitySuffix, bytes.Join(r, []byte("")), 0644)
+	if err != nil {
+		log.Println("Error write parity file:", err)
+	}
+	return err
+}
+
+// parityPercent function
+// return the parity percent of dest which is recorded in its parity file, 0 if parity file does not exist,
+// parity file whose header could not be read is removed and 0 is returned
+func parityPercent(dest string) (percent int, err error) {
+	par, err := ioutil.ReadFile(dest + ParitySuffix)
+	if os.IsNotExist(err) {
+		return percent, nil
+	}
+	if err != nil {
+		log.Println("Error read parity file:", err)
+		return percent, err
+	}
+	h := TPackParity{}
+	if len(par) < 40 || !bytes.HasPrefix(par, []byte(ParityMagic)) || len(par) < 40+BytesToInt(par[4:8]) ||
+		unpack.GobDecode(bytes.NewBuffer(par[40:40+BytesToInt(par[4:8])]), &h) != nil || h.Percent <= 0 || h.Percent > 100 {
+		err = os.Remove(dest + ParitySuffix)
+		if err != nil {
+			log.Println("Error remove parity file:", err)
+		}
+		return percent, err
+	}
+	return h.Percent, err
+}
+
+// parityNumber function
+// return parity shard number of stripe with n data shards
+func parityNumber(n int, percent int) int {
+	return (n*percent + 99) / 100
+}
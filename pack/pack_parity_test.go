@@ -0,0 +1,158 @@
+package pack
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"qora/unpack"
+	"testing"
+)
+
+// TestGenerateParity function
+func TestGenerateParity(t *testing.T) {
+	data := make([]byte, 300*1024)
+	rand.New(rand.NewSource(1)).Read(data)
+	entries := []Entry{{Name: "a.bin", Bytes: data}}
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "file_parity.pak")
+	err := PackEntriesWithOption(entries, dest, "AES", PackOption{Parity: 10})
+	if err != nil {
+		t.Fatal("Error Pack Entries With Option:", err)
+	}
+	origin, _ := ioutil.ReadFile(dest)
+	// flip bytes in several shards of the first stripe and cut the tail
+	damage := append([]byte{}, origin...)
+	for _, v := range []int{100, 4096*3 + 7, 4096*50 + 1000} {
+		damage[v] ^= 0xff
+	}
+	damage = damage[:len(damage)-5000]
+	_ = ioutil.WriteFile(dest, damage, 0644)
+	// unpack repairs in memory and keeps the package untouched
+	r, err := unpack.UnpackAllToMemory(dest)
+	if err != nil || !bytes.Equal(r["a.bin"], data) {
+		t.Fatal("Error Unpack damaged package:", err)
+	}
+	err = unpack.Unpack(dest, dir+"/")
+	if err != nil {
+		t.Fatal("Error Unpack damaged package:", err)
+	}
+	s, _ := ioutil.ReadFile(filepath.Join(dir, "a.bin"))
+	if !bytes.Equal(s, data) {
+		t.Fatal("Error Unpack damaged package content")
+	}
+	// repair rewrites the package
+	report, err := unpack.Repair(dest)
+	if err != nil {
+		t.Fatal("Error Repair:", err)
+	}
+	if len(report.Repaired) < 4 || report.Repaired[0] != 0 || report.Repaired[1] != 3 || report.Repaired[2] != 50 {
+		t.Fatal("Error Repair report:", report.Repaired)
+	}
+	s, _ = ioutil.ReadFile(dest)
+	if !bytes.Equal(s, origin) {
+		t.Fatal("Error Repair content")
+	}
+	// too many damaged shards could not be repaired
+	damage = append([]byte{}, origin...)
+	for i := 0; i < 20; i++ {
+		damage[i*4096] ^= 0xff
+	}
+	_ = ioutil.WriteFile(dest, damage, 0644)
+	_, err = unpack.Repair(dest)
+	if err == nil {
+		t.Fatal("Error Repair: too many damaged shards should fail")
+	}
+}
+
+// TestGenerateParityVolume function
+func TestGenerateParityVolume(t *testing.T) {
+	entries := []Entry{{Name: "a.txt", Bytes: bytes.Repeat([]byte("qora"), 4000)}}
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "file_parity.pak")
+	err := PackEntriesWithOption(entries, dest, "DES", PackOption{VolumeSize: 8192, Parity: 50})
+	if err != nil {
+		t.Fatal("Error Pack Entries With Option:", err)
+	}
+	part := filepath.Join(dir, "file_parity.q02")
+	data, _ := ioutil.ReadFile(part)
+	data[200] ^= 0xff
+	_ = ioutil.WriteFile(part, data, 0644)
+	// the whole part could be recovered when parity is enough
+	_ = ioutil.WriteFile(filepath.Join(dir, "file_parity.q03"), nil, 0644)
+	r, err := unpack.UnpackAllToMemory(part)
+	if err != nil || !bytes.Equal(r["a.txt"], entries[0].Bytes) {
+		t.Fatal("Error Unpack damaged volume:", err)
+	}
+}
+
+// TestEditParity function
+func TestEditParity(t *testing.T) {
+	rd := rand.New(rand.NewSource(2))
+	plain := make(map[string][]byte)
+	var entries []Entry
+	for _, v := range []string{"a.txt", "b.txt", "c.txt"} {
+		plain[v] = make([]byte, 6000)
+		rd.Read(plain[v])
+		entries = append(entries, Entry{Name: v, Bytes: plain[v]})
+	}
+	in := t.TempDir()
+	for _, v := range []string{"b.txt", "d.txt"} {
+		data := make([]byte, 5000)
+		rd.Read(data)
+		_ = ioutil.WriteFile(filepath.Join(in, v), data, 0644)
+	}
+	for _, version := range []int{2, 1} {
+		dest := filepath.Join(t.TempDir(), "file_edit.pak")
+		err := PackEntriesWithOption(entries, dest, "AES", PackOption{Parity: 10, Version: version})
+		if err != nil {
+			t.Fatal("Error Pack Entries With Option:", version, err)
+		}
+		want := make(map[string][]byte)
+		for k, v := range plain {
+			want[k] = v
+		}
+		stale, _ := ioutil.ReadFile(dest + ".qpar")
+		for _, edit := range []string{"append", "remove", "replace"} {
+			switch edit {
+			case "append":
+				err = Append(dest, []string{filepath.Join(in, "d.txt")})
+				want["d.txt"], _ = ioutil.ReadFile(filepath.Join(in, "d.txt"))
+			case "remove":
+				err = Remove(dest, []string{"c.txt"})
+				delete(want, "c.txt")
+			case "replace":
+				err = Replace(dest, []string{filepath.Join(in, "b.txt")})
+				want["b.txt"], _ = ioutil.ReadFile(filepath.Join(in, "b.txt"))
+			}
+			if err != nil {
+				t.Fatal("Error edit package with parity:", version, edit, err)
+			}
+			// parity is generated again, so damage of edited package is still repaired
+			origin, _ := ioutil.ReadFile(dest)
+			damage := append([]byte{}, origin...)
+			damage[len(damage)-1000] ^= 0xff
+			_ = ioutil.WriteFile(dest, damage, 0644)
+			r, err := unpack.UnpackAllToMemory(dest)
+			if err != nil || len(r) != len(want) {
+				t.Fatal("Error Unpack edited package with parity:", version, edit, err)
+			}
+			for k, v := range want {
+				if !bytes.Equal(r[k], v) {
+					t.Fatal("Error Unpack edited package content:", version, edit, k)
+				}
+			}
+			_ = ioutil.WriteFile(dest, origin, 0644)
+		}
+		// stale parity of the first package never turns the edited package back
+		_ = ioutil.WriteFile(dest+".qpar", stale, 0644)
+		r, err := unpack.UnpackAllToMemory(dest)
+		if err != nil || len(r) != len(want) || r["c.txt"] != nil {
+			t.Fatal("Error Unpack edited package with stale parity:", version, err)
+		}
+		_, err = unpack.Repair(dest)
+		if err != unpack.ErrStaleParity {
+			t.Fatal("Error Repair: stale parity should fail", version, err)
+		}
+	}
+}
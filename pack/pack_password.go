@@ -0,0 +1,293 @@
+package pack
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+	"log"
+	"math/bits"
+	. "qora/global"
+	"strconv"
+	"strings"
+)
+
+// HashPassword function
+// This function is mainly used for store the password, fast hash like SHA256Encode is easy to brute force and should not be used.
+// input algorithm, password and params, return the encoded hash and error info
+// algorithm now support 'argon2id', 'scrypt' and 'bcrypt', you can send both up case and low case
+// the encoded hash is PHC string with random salt, salt and hash are base64 without padding, like
+// '$argon2id$v=19$m=65536,t=3,p=4$salt$hash' and '$scrypt$ln=15,r=8,p=1$salt$hash', ln is log2 of N,
+// bcrypt keeps its own modular crypt format like '$2a$12$...'
+// zero params use the default of global, params lower than minimum or over max memory fail,
+// bcrypt password is no more than 72 bytes
+// check the password by VerifyPassword, upgrade the stored hash when NeedsRehash tells
+// return dest the encoded hash, err indicate the success or failure function execute
+func HashPassword(algorithm string, password string, params PasswordParams) (dest string, err error) {
+	algorithm = strings.ToLower(algorithm)
+	p, err := passwordParams(algorithm, params)
+	if err != nil {
+		return dest, err
+	}
+	if algorithm == "bcrypt" {
+		b, err := bcrypt.GenerateFromPassword([]byte(password), p.Cost)
+		if err != nil {
+			log.Println("Error generate bcrypt hash:", err)
+			return dest, err
+		}
+		return string(b), err
+	}
+	salt := make([]byte, p.SaltSize)
+	_, err = rand.Read(salt)
+	if err != nil {
+		log.Println("Error generate random salt:", err)
+		return dest, err
+	}
+	key, err := derivePassword(algorithm, []byte(password), p, salt)
+	if err != nil {
+		return dest, err
+	}
+	b64 := base64.RawStdEncoding
+	switch algorithm {
+	case "argon2id":
+		dest = fmt.Sprintf("$argon2id$v=%v$m=%v,t=%v,p=%v$%v$%v", argon2.Version, p.Memory, p.Time, p.Threads, b64.EncodeToString(salt), b64.EncodeToString(key))
+	case "scrypt":
+		dest = fmt.Sprintf("$scrypt$ln=%v,r=%v,p=%v$%v$%v", bits.TrailingZeros(uint(p.N)), p.R, p.P, b64.EncodeToString(salt), b64.EncodeToString(key))
+	}
+	return dest, err
+}
+
+// VerifyPassword function
+// input encoded hash of HashPassword and password, return whether the password matches and error info
+// the algorithm and params are read from encoded hash, hash is compared in constant time
+// malformed encoded hash, unsupported algorithm or params over the limit of HashPassword fail with error
+// return b indicate check pass or failed, err indicate the success or failure function execute
+func VerifyPassword(encoded string, password string) (b bool, err error) {
+	algorithm, p, salt, key, err := parsePassword(encoded)
+	if err != nil {
+		return b, err
+	}
+	if algorithm == "bcrypt" {
+		err = bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	r, err := derivePassword(algorithm, []byte(password), p, salt)
+	if err != nil {
+		return b, err
+	}
+	return subtle.ConstantTimeCompare(r, key) == 1, err
+}
+
+// VerifyPasswordWithLimit function
+// it is common with function VerifyPassword, just the cost of encoded hash should not be over limit,
+// it is checked before anything is derived, so the encoded hash from untrusted caller could not spend more time or memory than limit,
+// like the hash over the default params sent to the http service, zero limit use the default of global as HashPassword does
+// return b indicate check pass or failed, err indicate the success or failure function execute
+func VerifyPasswordWithLimit(encoded string, password string, limit PasswordParams) (b bool, err error) {
+	algorithm, p, _, _, err := parsePassword(encoded)
+	if err != nil {
+		return b, err
+	}
+	max, err := passwordParams(algorithm, limit)
+	if err != nil {
+		return b, err
+	}
+	var over bool
+	switch algorithm {
+	case "argon2id":
+		over = p.Time > max.Time || p.Memory > max.Memory || p.Threads > max.Threads
+	case "scrypt":
+		over = p.N > max.N || p.R > max.R || p.P > max.P
+	case "bcrypt":
+		over = p.Cost > max.Cost
+	}
+	if over {
+		s := fmt.Sprintf("Password hash cost is over the limit: %+v", p)
+		err = errors.New(s)
+		return b, err
+	}
+	return VerifyPassword(encoded, password)
+}
+
+// NeedsRehash function
+// This function is mainly used for upgrade the stored hash after the params are raised, like after login succeeds.
+// input encoded hash of HashPassword and params, return whether the hash should be calculated again
+// params of the encoded algorithm are compared, zero params use the default of global as HashPassword does,
+// any difference, include salt and hash size, needs rehash
+// return b indicate rehash is needed, err indicate the success or failure function execute
+func NeedsRehash(encoded string, params PasswordParams) (b bool, err error) {
+	algorithm, p, _, _, err := parsePassword(encoded)
+	if err != nil {
+		return true, err
+	}
+	want, err := passwordParams(algorithm, params)
+	if err != nil {
+		return true, err
+	}
+	switch algorithm {
+	case "argon2id":
+		b = p.Time != want.Time || p.Memory != want.Memory || p.Threads != want.Threads
+	case "scrypt":
+		b = p.N != want.N || p.R != want.R || p.P != want.P
+	case "bcrypt":
+		return p.Cost != want.Cost, err
+	}
+	return b || p.SaltSize != want.SaltSize || p.KeySize != want.KeySize, err
+}
+
+// passwordAlgorithm function
+// return the lower case algorithm name of encoded hash, empty when it is malformed
+func passwordAlgorithm(encoded string) string {
+	algorithm, _, _, _, err := parsePassword(encoded)
+	if err != nil {
+		return ""
+	}
+	return algorithm
+}
+
+// passwordParams function
+// fill the zero params of algorithm with default and check them
+func passwordParams(algorithm string, params PasswordParams) (p PasswordParams, err error) {
+	p = params
+	switch algorithm {
+	case "argon2id":
+		if p.Time == 0 && p.Memory == 0 && p.Threads == 0 {
+			p.Time, p.Memory, p.Threads = PasswordArgon2Time, PasswordArgon2Memory, PasswordArgon2Threads
+		}
+	case "scrypt":
+		if p.N == 0 && p.R == 0 && p.P == 0 {
+			p.N, p.R, p.P = PasswordScryptN, PasswordScryptR, PasswordScryptP
+		}
+	case "bcrypt":
+		if p.Cost == 0 {
+			p.Cost = PasswordBcryptCost
+		}
+		p.SaltSize, p.KeySize = 16, 23
+	default:
+		s := fmt.Sprintf("Undefined password algorithm: %v", algorithm)
+		err = errors.New(s)
+		return p, err
+	}
+	if p.SaltSize == 0 {
+		p.SaltSize = PasswordSaltSize
+	}
+	if p.KeySize == 0 {
+		p.KeySize = PasswordKeySize
+	}
+	return p, checkPassword(algorithm, p)
+}
+
+// checkPassword function
+// check the params of algorithm are not lower than minimum and not over max memory
+func checkPassword(algorithm string, p PasswordParams) (err error) {
+	ok := p.SaltSize >= PasswordMinSaltSize && p.KeySize >= PasswordMinKeySize
+	switch algorithm {
+	case "argon2id":
+		ok = ok && p.Time >= 1 && p.Time <= PasswordMaxTime && p.Threads >= 1 && p.Memory >= 8*uint32(p.Threads) && uint64(p.Memory)<<10 <= PasswordMaxMemory
+	case "scrypt":
+		ok = ok && p.N > 1 && p.N&(p.N-1) == 0 && p.R >= 1 && p.P >= 1 && uint64(p.R)*uint64(p.P) < 1<<30 && p.N <= PasswordMaxMemory/128/p.R
+	case "bcrypt":
+		ok = ok && p.Cost >= bcrypt.MinCost && p.Cost <= bcrypt.MaxCost
+	}
+	if !ok {
+		s := fmt.Sprintf("Invalid %v password params: %+v", algorithm, p)
+		err = errors.New(s)
+	}
+	return err
+}
+
+// derivePassword function
+// derive the hash of password with algorithm, params and salt
+func derivePassword(algorithm string, password []byte, p PasswordParams, salt []byte) (key []byte, err error) {
+	switch algorithm {
+	case "argon2id":
+		key = argon2.IDKey(password, salt, p.Time, p.Memory, p.Threads, uint32(p.KeySize))
+	case "scrypt":
+		key, err = scrypt.Key(password, salt, p.N, p.R, p.P, p.KeySize)
+		if err != nil {
+			log.Println("Error derive scrypt hash:", err)
+		}
+	}
+	return key, err
+}
+
+// parsePassword function
+// parse the encoded hash of HashPassword, return algorithm, params, salt and hash
+func parsePassword(encoded string) (algorithm string, p PasswordParams, salt []byte, key []byte, err error) {
+	parts := strings.Split(encoded, "$")
+	s := "Invalid password hash format."
+	if len(parts) < 4 || parts[0] != "" {
+		return algorithm, p, salt, key, errors.New(s)
+	}
+	algorithm = parts[1]
+	var v map[string]int
+	switch {
+	case algorithm == "2a" || algorithm == "2b" || algorithm == "2y":
+		algorithm = "bcrypt"
+		p.Cost, err = bcrypt.Cost([]byte(encoded))
+		if err != nil {
+			return algorithm, p, salt, key, errors.New(s)
+		}
+		p.SaltSize, p.KeySize = 16, 23
+		return algorithm, p, salt, key, checkPassword(algorithm, p)
+	case algorithm == "argon2id" && len(parts) == 6:
+		if parts[2] != fmt.Sprintf("v=%v", argon2.Version) {
+			s := fmt.Sprintf("Unsupported argon2id version: %v", parts[2])
+			return algorithm, p, salt, key, errors.New(s)
+		}
+		v, err = parsePasswordParams(parts[3], "m", "t", "p")
+		if err != nil || v["t"] > PasswordMaxTime || v["m"] > PasswordMaxMemory>>10 || v["p"] > 255 {
+			return algorithm, p, salt, key, errors.New(s)
+		}
+		p.Memory, p.Time, p.Threads = uint32(v["m"]), uint32(v["t"]), uint8(v["p"])
+	case algorithm == "scrypt" && len(parts) == 5:
+		v, err = parsePasswordParams(parts[2], "ln", "r", "p")
+		if err != nil || v["ln"] >= 63 {
+			return algorithm, p, salt, key, errors.New(s)
+		}
+		p.N, p.R, p.P = 1<<uint(v["ln"]), v["r"], v["p"]
+	default:
+		s := fmt.Sprintf("Undefined password algorithm: %v", algorithm)
+		return algorithm, p, salt, key, errors.New(s)
+	}
+	b64 := base64.RawStdEncoding
+	salt, err = b64.DecodeString(parts[len(parts)-2])
+	if err != nil {
+		return algorithm, p, salt, key, errors.New(s)
+	}
+	key, err = b64.DecodeString(parts[len(parts)-1])
+	if err != nil {
+		return algorithm, p, salt, key, errors.New(s)
+	}
+	p.SaltSize, p.KeySize = len(salt), len(key)
+	return algorithm, p, salt, key, checkPassword(algorithm, p)
+}
+
+// parsePasswordParams function
+// parse the PHC params like 'm=65536,t=3,p=4', names are required in order
+func parsePasswordParams(src string, names ...string) (v map[string]int, err error) {
+	fields := strings.Split(src, ",")
+	if len(fields) != len(names) {
+		s := fmt.Sprintf("Invalid password params: %v", src)
+		err = errors.New(s)
+		return v, err
+	}
+	v = make(map[string]int, len(names))
+	for k, f := range fields {
+		n, err := strconv.Atoi(strings.TrimPrefix(f, names[k]+"="))
+		if err != nil || !strings.HasPrefix(f, names[k]+"=") || n < 0 {
+			s := fmt.Sprintf("Invalid password params: %v", src)
+			err = errors.New(s)
+			return v, err
+		}
+		v[names[k]] = n
+	}
+	return v, err
+}
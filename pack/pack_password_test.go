@@ -0,0 +1,112 @@
+package pack
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestHashPassword function
+func TestHashPassword(t *testing.T) {
+	password := "Satellite-266414"
+	params := PasswordParams{Time: 1, Memory: 1024, Threads: 1, N: 1024, R: 8, P: 1, Cost: 4}
+	for _, v := range []struct{ algorithm, prefix string }{
+		{"argon2id", "$argon2id$v=19$m=1024,t=1,p=1$"},
+		{"SCRYPT", "$scrypt$ln=10,r=8,p=1$"},
+		{"bcrypt", "$2a$04$"},
+	} {
+		dest, err := HashPassword(v.algorithm, password, params)
+		if err != nil || !strings.HasPrefix(dest, v.prefix) {
+			t.Fatal("Error Hash Password:", v.algorithm, dest, err)
+		}
+		again, _ := HashPassword(v.algorithm, password, params)
+		if again == dest {
+			t.Fatal("Error Hash Password: salt should be random", v.algorithm)
+		}
+		b, err := VerifyPassword(dest, password)
+		if err != nil || !b {
+			t.Fatal("Error Verify Password:", v.algorithm, err)
+		}
+		b, err = VerifyPassword(dest, "Satellite-000000")
+		if err != nil || b {
+			t.Fatal("Error Verify Password: wrong password should fail", v.algorithm, err)
+		}
+		b, err = NeedsRehash(dest, params)
+		if err != nil || b {
+			t.Fatal("Error Needs Rehash:", v.algorithm, err)
+		}
+		b, err = NeedsRehash(dest, PasswordParams{})
+		if err != nil || !b {
+			t.Fatal("Error Needs Rehash: default params should rehash", v.algorithm, err)
+		}
+		b, err = PackHashCheck(password, dest, v.algorithm)
+		if err != nil || !b {
+			t.Fatal("Error Pack Hash Check:", v.algorithm, err)
+		}
+	}
+	// dispatch with default params
+	dest, err := PackHashEncode(password, "scrypt")
+	if err != nil || !strings.HasPrefix(dest, "$scrypt$ln=15,r=8,p=1$") {
+		t.Fatal("Error Pack Hash Encode:", dest, err)
+	}
+	b, err := PackHashCheck(password, dest, "argon2id")
+	if err != nil || b {
+		t.Fatal("Error Pack Hash Check: algorithm mismatch should fail", err)
+	}
+	// known scrypt hash of 'password', N=1024, r=8, p=1, salt 'saltsaltsaltsalt'
+	b, err = VerifyPassword("$scrypt$ln=10,r=8,p=1$c2FsdHNhbHRzYWx0c2FsdA$BVMRKqdiVYikKAaPR1wucsKUKvw4TuPLkdEYtoSHas4", "password")
+	if err != nil || !b {
+		t.Fatal("Error Verify Password known hash:", err)
+	}
+	// cost over limit is refused before derive
+	dest, err = HashPassword("scrypt", password, PasswordParams{N: 2048, R: 8, P: 1})
+	if err != nil {
+		t.Fatal("Error Hash Password:", err)
+	}
+	b, err = VerifyPasswordWithLimit(dest, password, PasswordParams{N: 2048, R: 8, P: 1})
+	if err != nil || !b {
+		t.Fatal("Error Verify Password With Limit:", err)
+	}
+	_, err = VerifyPasswordWithLimit(dest, password, PasswordParams{N: 1024, R: 8, P: 1})
+	if err == nil {
+		t.Fatal("Error Verify Password With Limit: cost over limit should fail")
+	}
+	for _, v := range []string{"$argon2id$v=19$m=1048576,t=64,p=4$c2FsdHNhbHRzYWx0c2FsdA$BVMRKqdiVYikKAaPR1wucsKUKvw4TuPLkdEYtoSHas4", "$2a$31$" + strings.Repeat("a", 53)} {
+		_, err = VerifyPasswordWithLimit(v, password, PasswordParams{})
+		if err == nil || !strings.Contains(err.Error(), "over the limit") {
+			t.Fatal("Error Verify Password With Limit: cost over default should fail", v, err)
+		}
+		_, err = PackHashCheck(password, v, passwordAlgorithm(v))
+		if err == nil || !strings.Contains(err.Error(), "over the limit") {
+			t.Fatal("Error Pack Hash Check: cost over default should fail", v, err)
+		}
+	}
+	// invalid params and malformed hash
+	for _, v := range []PasswordParams{{Time: 1, Memory: 4, Threads: 1}, {SaltSize: 4}, {KeySize: 8}, {Time: 1, Memory: 2 << 20, Threads: 1}} {
+		_, err = HashPassword("argon2id", password, v)
+		if err == nil {
+			t.Fatal("Error Hash Password: invalid params should fail", v)
+		}
+	}
+	for _, v := range []PasswordParams{{N: 1000, R: 8, P: 1}, {N: 1 << 21, R: 8, P: 1}} {
+		_, err = HashPassword("scrypt", password, v)
+		if err == nil {
+			t.Fatal("Error Hash Password: invalid scrypt params should fail", v)
+		}
+	}
+	_, err = HashPassword("bcrypt", strings.Repeat("a", 73), PasswordParams{Cost: 4})
+	if err == nil {
+		t.Fatal("Error Hash Password: bcrypt password over 72 bytes should fail")
+	}
+	_, err = HashPassword("sha256", password, params)
+	if err == nil {
+		t.Fatal("Error Hash Password: undefined algorithm should fail")
+	}
+	for _, v := range []string{"", "argon2id", "$argon2id$v=16$m=1024,t=1,p=1$c2FsdHNhbHQ$aGFzaGhhc2hoYXNoaGFzaA",
+		"$argon2id$v=19$m=1024,p=1,t=1$c2FsdHNhbHQ$aGFzaGhhc2hoYXNoaGFzaA", "$argon2id$v=19$m=4194304,t=1,p=1$c2FsdHNhbHQ$aGFzaGhhc2hoYXNoaGFzaA",
+		"$scrypt$ln=40,r=8,p=1$c2FsdHNhbHQ$aGFzaGhhc2hoYXNoaGFzaA", "$scrypt$ln=10,r=8,p=1$!!$aGFzaGhhc2hoYXNoaGFzaA", "$md5$x$y"} {
+		_, err = VerifyPassword(v, password)
+		if err == nil {
+			t.Fatal("Error Verify Password: malformed hash should fail", v)
+		}
+	}
+}
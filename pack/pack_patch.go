@@ -0,0 +1,191 @@
+package pack
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"io/ioutil"
+	"log"
+	. "qora/global"
+	"qora/unpack"
+	. "qora/utils"
+)
+
+// Diff function
+// This function is mainly used for make a binary delta patch which turns old package into new package.
+// input old package path, new package path and output patch path, return error info
+// the delta is made over plain entries in bsdiff style, entry of new package is diffed with old entry of the same name,
+// so one small change of entry only costs the changed bytes, whatever the entries are encrypted by new random keys
+// the patch keeps header and entry keys of new package, then it is compressed and sealed by aes-gcm,
+// the patch key is derived from old package, only the one who has the exact old package could open the patch
+// rebuild the new package with unpack.Patch, volume parts should be joined by unpack.JoinVolume first
+// return err indicate the success or failure function execute
+func Diff(old string, new string, patch string) (err error) {
+	return DiffWithOption(old, new, patch, PackOption{})
+}
+
+// DiffWithOption function
+// it is common with function Diff, opt.TableKey is used to open the sealed packages and also mixed into patch key
+// return err indicate the success or failure function execute
+func DiffWithOption(old string, new string, patch string, opt PackOption) (err error) {
+	// first, read both packages
+	od, err := ioutil.ReadFile(old)
+	if err != nil {
+		log.Println("Error read file:", err)
+		return err
+	}
+	nd, err := ioutil.ReadFile(new)
+	if err != nil {
+		log.Println("Error read file:", err)
+		return err
+	}
+	if unpack.IsVolume(od) || unpack.IsVolume(nd) {
+		err = errors.New("Volume part is not supported by diff, please join volume first.")
+		return err
+	}
+	uo := unpack.UnpackOption{TableKey: opt.TableKey}
+	op, err := unpack.ParsePackageBytesWithOption(od, uo)
+	if err != nil {
+		log.Println("Error parse package:", err)
+		return err
+	}
+	np, err := unpack.ParsePackageBytesWithOption(nd, uo)
+	if err != nil {
+		log.Println("Error parse package:", err)
+		return err
+	}
+	// second, decrypt the old entries
+	plain := make(map[string][]byte, len(op.Entries))
+	for _, v := range op.Entries {
+		plain[v.Name], err = unpack.DecryptEntry(v)
+		if err != nil {
+			return err
+		}
+	}
+	// third, make delta of every new entry
+	r := TPackPatch{Head: np.Head, Sealed: np.Extend.Sealed}
+	if np.Version == 2 {
+		r.Extend = nd[60 : 64+BytesToInt(nd[60:64])]
+	}
+	digest := sha256.Sum256(nd)
+	r.Digest = digest[:]
+	for _, v := range np.Entries {
+		data, err := unpack.DecryptEntry(v)
+		if err != nil {
+			return err
+		}
+		e := TPackPatchEntry{Head: v.Head}
+		base, ok := plain[v.Name]
+		if ok {
+			e.Base = v.Name
+		}
+		e.Ctrl, e.Diff, e.Extra = diffBytes(base, data)
+		digest := sha256.Sum256(data)
+		e.Digest = digest[:]
+		r.Entries = append(r.Entries, e)
+	}
+	// fourth, encode, compress and seal the patch
+	var buf bytes.Buffer
+	err = GobEncode(&buf, r)
+	if err != nil {
+		return err
+	}
+	var zbuf bytes.Buffer
+	zw, err := flate.NewWriter(&zbuf, flate.BestCompression)
+	if err != nil {
+		return err
+	}
+	_, err = zw.Write(buf.Bytes())
+	if err == nil {
+		err = zw.Close()
+	}
+	if err != nil {
+		log.Println("Error compress patch:", err)
+		return err
+	}
+	sealed, err := SealBytes(patchKey(od, opt.TableKey), zbuf.Bytes(), []byte(PatchMagic))
+	if err != nil {
+		log.Println("Error seal patch:", err)
+		return err
+	}
+	// finally, write the patch file
+	err = ioutil.WriteFile(patch, append([]byte(PatchMagic), sealed...), 0644)
+	if err != nil {
+		log.Println("Error write patch file:", err)
+	}
+	return err
+}
+
+// patchKey function
+// it derives the aes-256 patch key from sha256 of old package and table key
+func patchKey(old []byte, key []byte) []byte {
+	digest := sha256.Sum256(old)
+	m := hmac.New(sha256.New, append([]byte(PatchMagic), key...))
+	m.Write(digest[:])
+	return m.Sum(nil)
+}
+
+// diffBytes function
+// it makes bsdiff style delta from old to new, new is rebuilt by control triples one by one:
+// add diff bytes to old data of diff length, append extra bytes of extra length, then seek old data to next offset
+// exact matches are found by block index of old data, then the delta is extended forward while most bytes still match
+func diffBytes(old []byte, new []byte) (ctrl []int, diff []byte, extra []byte) {
+	// first, index the blocks of old data
+	index := make(map[string][]int)
+	for i := 0; i+PatchBlockSize <= len(old); i += PatchBlockSize {
+		k := string(old[i : i+PatchBlockSize])
+		if len(index[k]) < 8 {
+			index[k] = append(index[k], i)
+		}
+	}
+	// new[scan:] is not written yet, it is aligned with old[pos:]
+	scan, pos := 0, 0
+	emit := func(end int, next int) {
+		s, best, n := 0, 0, 0
+		for i := 0; scan+i < end && pos+i < len(old); {
+			if old[pos+i] == new[scan+i] {
+				s++
+			}
+			i++
+			if s*2-i > best*2-n {
+				best, n = s, i
+			}
+		}
+		for i := 0; i < n; i++ {
+			diff = append(diff, new[scan+i]-old[pos+i])
+		}
+		extra = append(extra, new[scan+n:end]...)
+		ctrl = append(ctrl, n, end-scan-n, next)
+		scan, pos = end, next
+	}
+	// second, find the longest match of every position, a new triple starts when the alignment changes
+	for i := 0; i+PatchBlockSize <= len(new); {
+		mi, mp, ml := 0, 0, 0
+		for _, p := range index[string(new[i:i+PatchBlockSize])] {
+			b := 0
+			for i-b > scan && p-b > 0 && old[p-b-1] == new[i-b-1] {
+				b++
+			}
+			f := 0
+			for i+f < len(new) && p+f < len(old) && old[p+f] == new[i+f] {
+				f++
+			}
+			if b+f > ml {
+				mi, mp, ml = i-b, p-b, b+f
+			}
+		}
+		if ml == 0 {
+			i++
+			continue
+		}
+		if mp-mi != pos-scan {
+			emit(mi, mp)
+		}
+		i = mi + ml
+	}
+	// finally, write the rest data
+	emit(len(new), 0)
+	return ctrl, diff, extra
+}
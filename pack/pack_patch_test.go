@@ -0,0 +1,62 @@
+package pack
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"qora/unpack"
+	"testing"
+)
+
+// TestDiff function
+func TestDiff(t *testing.T) {
+	key := []byte("Satellite-266414")
+	asset := make([]byte, 64*1024)
+	rand.New(rand.NewSource(1)).Read(asset)
+	changed := append(append(append([]byte{}, asset[:30000]...), []byte("changed asset")...), asset[30100:]...)
+	for _, algorithm := range []string{"AES", "DES", "3DES", "RSA", "BASE64"} {
+		for _, opt := range []PackOption{{}, {TableKey: key}} {
+			dir := t.TempDir()
+			old := filepath.Join(dir, "old.pak")
+			new := filepath.Join(dir, "new.pak")
+			err := PackEntriesWithOption([]Entry{{Name: "asset.bin", Bytes: asset}, {Name: "readme.txt", Bytes: []byte("v1")}}, old, algorithm, opt)
+			if err != nil {
+				t.Fatal("Error Pack Entries:", algorithm, err)
+			}
+			err = PackEntriesWithOption([]Entry{{Name: "asset.bin", Bytes: changed}, {Name: "readme.txt", Bytes: []byte("v2")}, {Name: "new.txt", Bytes: []byte("new")}}, new, algorithm, opt)
+			if err != nil {
+				t.Fatal("Error Pack Entries:", algorithm, err)
+			}
+			patch := filepath.Join(dir, "new.qpatch")
+			err = DiffWithOption(old, new, patch, opt)
+			if err != nil {
+				t.Fatal("Error Diff:", algorithm, err)
+			}
+			fi, _ := os.Stat(patch)
+			if fi.Size() > 8*1024 {
+				t.Fatal("Error Diff: patch is too large", algorithm, fi.Size())
+			}
+			out := filepath.Join(dir, "out.pak")
+			err = unpack.PatchWithOption(old, patch, out, unpack.UnpackOption{TableKey: opt.TableKey})
+			if err != nil {
+				t.Fatal("Error Patch:", algorithm, err)
+			}
+			a, _ := ioutil.ReadFile(new)
+			b, _ := ioutil.ReadFile(out)
+			if algorithm != "RSA" && !bytes.Equal(a, b) {
+				t.Fatal("Error Patch: output differs from new package", algorithm)
+			}
+			r, err := unpack.UnpackAllToMemoryWithOption(out, unpack.UnpackOption{TableKey: opt.TableKey})
+			if err != nil || !bytes.Equal(r["asset.bin"], changed) || string(r["new.txt"]) != "new" {
+				t.Fatal("Error Patch: output content", algorithm, err)
+			}
+			// patch could only be opened with the exact old package
+			err = unpack.PatchWithOption(new, patch, filepath.Join(dir, "bad.pak"), unpack.UnpackOption{TableKey: opt.TableKey})
+			if err == nil {
+				t.Fatal("Error Patch: wrong old package should fail", algorithm)
+			}
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package pack
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	. "qora/global"
+	"qora/unpack"
+	. "qora/utils"
+	"strings"
+)
+
+// Rekey function
+// This function is mainly used for encrypt the package again with new algorithm or new keys, like migrate 'DES' to 'AESGCM'.
+// input old package path, output dest package path, old table key and rekey option, return error info
+// every entry is decrypted by its reader only when it is packed and the decrypted data is released once it is read to the end,
+// plain data is never written to disk, but the whole old package is read into memory and entries are packed concurrently,
+// so memory use still grows with the package size
+// integrity is checked on the way: every plain entry is checked by sha256 of old manifest,
+// then new package is written to 'dest.tmp', parsed again with new table key and every entry is compared with the old plain digest
+// uuid, manifest(permission, modify time and base of incremental package) and metadata are kept,
+// so the rekeyed incremental package still follows its base in chain
+// dest could be the same as src, it is replaced through temporary file, stale parity file of dest is removed
+// return err indicate the success or failure function execute
+func Rekey(src string, dest string, oldKey []byte, opt RekeyOption) (err error) {
+	// first, parse the old package
+	p, err := unpack.ParsePackageWithOption(src, unpack.UnpackOption{TableKey: oldKey})
+	if err != nil {
+		log.Println("Error parse package:", err)
+		return err
+	}
+	algorithm := packType(opt.Algorithm)
+	if algorithm == "" {
+		algorithm = p.Type
+	}
+	// second, every entry is decrypted by its reader and checked with old manifest
+	files := make(map[string]unpack.TUnpackManifestFile)
+	m := TPackManifest{}
+	if p.Manifest != nil {
+		for _, v := range p.Manifest.Files {
+			files[v.Name] = v
+		}
+		m.Base, m.Deleted = p.Manifest.Base, p.Manifest.Deleted
+	}
+	entries := make([]Entry, len(p.Entries))
+	readers := make([]*rekeyReader, len(p.Entries))
+	for k, v := range p.Entries {
+		f, ok := files[v.Name]
+		delete(files, v.Name)
+		readers[k] = &rekeyReader{e: v}
+		if ok {
+			readers[k].f = &f
+		}
+		entries[k] = Entry{Name: v.Name, Reader: readers[k], Mode: f.Mode, ModTime: f.ModTime}
+		if algorithm == MixedType {
+			entries[k].Algorithm = v.Type
+		}
+	}
+	// the files which are only in base package stay in manifest
+	for _, v := range files {
+		m.Files = append(m.Files, TPackManifestFile{Name: v.Name, Size: v.Size, ModTime: v.ModTime, Mode: v.Mode, Digest: v.Digest})
+	}
+	// third, pack the new package into temporary file
+	o := opt.Option
+	if o.Meta.Author == "" && o.Meta.Creator == "" && o.Meta.Version == "" && o.Meta.Created.IsZero() && o.Meta.Comment == "" && o.Meta.Labels == nil {
+		if p.Meta != nil {
+			o.Meta = TPackMeta{Author: p.Meta.Author, Creator: p.Meta.Creator, Version: p.Meta.Version, Created: p.Meta.Created, Comment: p.Meta.Comment, Labels: p.Meta.Labels}
+		} else {
+			o.Meta.Author = p.Author
+		}
+	}
+	var id []byte
+	if p.UUID != "" {
+		id = []byte(p.UUID)
+	}
+	unlock, err := LockPackage(dest)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	tmp := dest + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		log.Println("Error create package file:", err)
+		return err
+	}
+	err = packToWriter(entries, file, algorithm, o, m, id)
+	if e := file.Close(); err == nil && e != nil {
+		log.Println("Error write package file:", e)
+		err = e
+	}
+	if err == nil {
+		digests := make(map[string][]byte, len(readers))
+		for _, v := range readers {
+			digests[v.e.Name] = v.digest
+		}
+		err = rekeyVerify(tmp, src, digests, o.TableKey)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	// finally, replace dest by the new package
+	if o.VolumeSize > 0 || o.Parity > 0 {
+		data, err := ioutil.ReadFile(tmp)
+		if err != nil {
+			log.Println("Error read package file:", err)
+			_ = os.Remove(tmp)
+			return err
+		}
+		if o.VolumeSize > 0 {
+			err = writeOutput(data, dest, o)
+			_ = os.Remove(tmp)
+			return err
+		}
+		err = os.Rename(tmp, dest)
+		if err != nil {
+			log.Println("Error rename package file:", err)
+			_ = os.Remove(tmp)
+			return err
+		}
+		return writeParity(data, dest, o.Parity)
+	}
+	err = os.Rename(tmp, dest)
+	if err != nil {
+		log.Println("Error rename package file:", err)
+		_ = os.Remove(tmp)
+		return err
+	}
+	err = os.Remove(dest + ParitySuffix)
+	if os.IsNotExist(err) {
+		err = nil
+	}
+	return err
+}
+
+// rekeyReader struct
+// it decrypts the old entry when it is read first, plain data is checked with the old manifest record f
+// digest records sha256 of the plain data for verify of the new package, done is set when plain data is released at EOF
+type rekeyReader struct {
+	e      unpack.TUnpackEntry
+	f      *unpack.TUnpackManifestFile
+	r      *bytes.Reader
+	digest []byte
+	done   bool
+}
+
+// Read function
+// it is io.Reader of rekeyReader
+func (r *rekeyReader) Read(p []byte) (n int, err error) {
+	if r.done {
+		return n, io.EOF
+	}
+	if r.r == nil {
+		data, err := unpack.DecryptEntry(r.e)
+		if err != nil {
+			return n, err
+		}
+		digest := sha256.Sum256(data)
+		if r.f != nil && (r.f.Size != int64(len(data)) || !bytes.Equal(r.f.Digest, digest[:])) {
+			s := fmt.Sprintf("Package entry digest mismatch with manifest: %v", r.e.Name)
+			err = errors.New(s)
+			return n, err
+		}
+		r.digest = digest[:]
+		r.r = bytes.NewReader(data)
+	}
+	n, err = r.r.Read(p)
+	// release plain data as soon as it is read to the end
+	if r.r.Len() == 0 {
+		r.r, r.done = nil, true
+	}
+	return n, err
+}
+
+// rekeyVerify function
+// it parses the new package with new table key, then decrypts and checks every entry one by one with the old plain digest
+// return err indicate the success or failure function execute
+func rekeyVerify(tmp string, src string, digests map[string][]byte, key []byte) (err error) {
+	np, err := unpack.ParsePackageWithOption(tmp, unpack.UnpackOption{TableKey: key})
+	if err != nil || len(np.Entries) != len(digests) {
+		s := fmt.Sprintf("Rekey verify failed, new package is invalid: %v", src)
+		err = errors.New(s)
+		return err
+	}
+	for _, v := range np.Entries {
+		data, err := unpack.DecryptEntry(v)
+		digest := sha256.Sum256(data)
+		if err != nil || !bytes.Equal(digests[v.Name], digest[:]) {
+			s := fmt.Sprintf("Rekey verify failed, entry mismatch: %v", v.Name)
+			err = errors.New(s)
+			return err
+		}
+	}
+	return err
+}
+
+// RekeyDir function
+// This function is mainly used for migrate every package in directory by Rekey, like when the old key is compromised.
+// input src directory, dest directory, old table key and rekey option, return the report and error info
+// packages are written into dest with the same relative path, dest could be the same as src,
+// parity, lock and temporary files are skipped, volume set is rekeyed once from its first part
+// one failed package does not stop the others, it is recorded in report and the error tells the failed number
+// return err indicate the success or failure function execute
+func RekeyDir(src string, dest string, oldKey []byte, opt RekeyOption) (r *TRekeyReport, err error) {
+	r = &TRekeyReport{}
+	var paths []string
+	err = filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		for _, v := range []string{ParitySuffix, ".lock", ".tmp"} {
+			if strings.HasSuffix(path, v) {
+				return nil
+			}
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		log.Println("Error walk directory:", err)
+		return r, err
+	}
+	for _, v := range paths {
+		rel, err := filepath.Rel(src, v)
+		if err != nil {
+			return r, err
+		}
+		target, ok, err := rekeyTarget(v, filepath.Join(dest, rel))
+		if err == nil && !ok {
+			continue
+		}
+		if err == nil {
+			err = os.MkdirAll(filepath.Dir(target), 0755)
+		}
+		if err == nil {
+			err = Rekey(v, target, oldKey, opt)
+		}
+		if err != nil {
+			r.Failed = append(r.Failed, TRekeyFail{Path: rel, Reason: err.Error()})
+			continue
+		}
+		r.Done = append(r.Done, rel)
+	}
+	if len(r.Failed) > 0 {
+		s := fmt.Sprintf("%v of %v packages failed to rekey.", len(r.Failed), len(r.Failed)+len(r.Done))
+		err = errors.New(s)
+	}
+	return r, err
+}
+
+// rekeyTarget function
+// return the dest path of package, ok is false for the volume part which is not the first one,
+// the first part is written as the whole package name which is recorded in volume header
+func rekeyTarget(src string, dest string) (target string, ok bool, err error) {
+	file, err := os.Open(src)
+	if err != nil {
+		log.Println("Error open file:", err)
+		return target, ok, err
+	}
+	defer file.Close()
+	head := make([]byte, VolumeHeadSize)
+	n, _ := file.Read(head)
+	if !unpack.IsVolume(head[:n]) {
+		return dest, true, err
+	}
+	if n < VolumeHeadSize || BytesToInt(head[20:24]) != 1 {
+		return target, ok, err
+	}
+	name := string(bytes.TrimRight(head[64:96], "\x00"))
+	return filepath.Join(filepath.Dir(dest), name), true, err
+}
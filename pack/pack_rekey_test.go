@@ -0,0 +1,121 @@
+package pack
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"qora/unpack"
+	"testing"
+)
+
+// TestRekey function
+func TestRekey(t *testing.T) {
+	oldKey := []byte("Satellite-266414")
+	newKey := []byte("Satellite-266414-Satellite-26641")
+	entries := []Entry{
+		{Name: "a.txt", Bytes: []byte("alpha"), Mode: 0600},
+		{Name: "b.bin", Bytes: bytes.Repeat([]byte("bravo "), 1000)},
+	}
+	dir := t.TempDir()
+	src := filepath.Join(dir, "old.pak")
+	err := PackEntriesWithOption(entries, src, "DES", PackOption{TableKey: oldKey, Meta: TPackMeta{Comment: "legacy"}})
+	if err != nil {
+		t.Fatal("Error Pack With Option:", err)
+	}
+	// rekey des package with new table key into aes-gcm
+	dest := filepath.Join(dir, "new.pak")
+	opt := RekeyOption{Algorithm: "aesgcm", Option: PackOption{TableKey: newKey}}
+	err = Rekey(src, dest, oldKey, opt)
+	if _, e := os.Stat(dest + ".tmp"); err != nil || e == nil {
+		t.Fatal("Error Rekey:", err)
+	}
+	uo := unpack.UnpackOption{TableKey: newKey}
+	p, err := unpack.ParsePackageWithOption(dest, uo)
+	if err != nil || p.Type != "AESGCM" || p.Meta == nil || p.Meta.Comment != "legacy" {
+		t.Fatal("Error Rekey package:", err)
+	}
+	old, _ := unpack.ParsePackageWithOption(src, unpack.UnpackOption{TableKey: oldKey})
+	if p.UUID != old.UUID || p.Manifest.Files[0].Mode != 0600 {
+		t.Fatal("Error Rekey manifest:", p.UUID)
+	}
+	r, err := unpack.UnpackAllToMemoryWithOption(dest, uo)
+	if err != nil {
+		t.Fatal("Error Unpack All To Memory:", err)
+	}
+	for _, v := range entries {
+		if !bytes.Equal(r[v.Name], v.Bytes) {
+			t.Fatal("Error Rekey content:", v.Name)
+		}
+	}
+	_, err = unpack.ParsePackageWithOption(dest, unpack.UnpackOption{TableKey: oldKey})
+	if err == nil {
+		t.Fatal("Error Rekey: old key should not open new package")
+	}
+	// wrong old key fails without writing dest
+	err = Rekey(src, filepath.Join(dir, "wrong.pak"), newKey, opt)
+	if _, e := os.Stat(filepath.Join(dir, "wrong.pak")); err == nil || e == nil {
+		t.Fatal("Error Rekey: wrong old key should fail")
+	}
+	// tampered aes-gcm entry fails to unpack
+	data, _ := ioutil.ReadFile(dest)
+	data[len(data)-1] ^= 0x01
+	tamper := filepath.Join(dir, "tamper.pak")
+	_ = ioutil.WriteFile(tamper, data, 0644)
+	_, err = unpack.UnpackAllToMemoryWithOption(tamper, uo)
+	if err == nil {
+		t.Fatal("Error Unpack: tampered aes-gcm entry should fail")
+	}
+}
+
+// TestRekeyReader function
+func TestRekeyReader(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "old.pak")
+	err := PackEntriesWithOption([]Entry{{Name: "a.txt", Bytes: []byte("alpha")}}, src, "AES", PackOption{})
+	if err != nil {
+		t.Fatal("Error Pack With Option:", err)
+	}
+	p, _ := unpack.ParsePackage(src)
+	r := &rekeyReader{e: p.Entries[0]}
+	data, err := ioutil.ReadAll(r)
+	if err != nil || string(data) != "alpha" || r.digest == nil {
+		t.Fatal("Error rekey reader:", err)
+	}
+	// plain data is released at EOF and never decrypted again
+	n, err := r.Read(make([]byte, 8))
+	if r.r != nil || n != 0 || err != io.EOF {
+		t.Fatal("Error rekey reader: plain data should be released at EOF")
+	}
+}
+
+// TestRekeyDir function
+func TestRekeyDir(t *testing.T) {
+	key := []byte("Satellite-266414")
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	_ = os.MkdirAll(filepath.Join(src, "sub"), 0755)
+	entries := []Entry{{Name: "a.txt", Bytes: []byte("alpha")}}
+	for _, v := range []string{"one.pak", "sub/two.pak"} {
+		err := PackEntriesWithOption(entries, filepath.Join(src, v), "3DES", PackOption{})
+		if err != nil {
+			t.Fatal("Error Pack Entries With Option:", err)
+		}
+	}
+	err := PackEntriesWithOption(entries, filepath.Join(src, "three.pak"), "AES", PackOption{VolumeSize: 200})
+	if err != nil {
+		t.Fatal("Error Pack Entries With Option:", err)
+	}
+	_ = ioutil.WriteFile(filepath.Join(src, "note.txt"), []byte("not a package"), 0644)
+	dest := filepath.Join(dir, "dest")
+	r, err := RekeyDir(src, dest, nil, RekeyOption{Algorithm: "AESGCM", Option: PackOption{TableKey: key}})
+	if err == nil || len(r.Done) != 3 || len(r.Failed) != 1 || r.Failed[0].Path != "note.txt" {
+		t.Fatal("Error Rekey Dir:", r, err)
+	}
+	for _, v := range []string{"one.pak", "sub/two.pak", "three.pak"} {
+		m, err := unpack.UnpackAllToMemoryWithOption(filepath.Join(dest, v), unpack.UnpackOption{TableKey: key})
+		if err != nil || string(m["a.txt"]) != "alpha" {
+			t.Fatal("Error Rekey Dir content:", v, err)
+		}
+	}
+}
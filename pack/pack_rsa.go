@@ -2,10 +2,6 @@ package pack
 
 import (
 	"bytes"
-	"crypto/rand"
-	"crypto/rsa"
-	"crypto/x509"
-	"encoding/pem"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -53,13 +49,13 @@ func PackRSA(src []string, dest string) (err error) {
 	head.Author = make([]byte, 16)
 	head.Type = make([]byte, 8)
 	head.Number = make([]byte, 4)
-	_, name := filepath.Split(dest)
-	if len([]byte(name)) > 32 {
-		log.Println("Error dest file name length:", err)
-		return
+	id, err := NewUUID()
+	if err != nil {
+		log.Println("Error new package uuid:", err)
+		return err
 	}
-	BytesCopy(&(head.Name), []byte(name))
-	BytesCopy(&(head.Author), []byte("Alopex6414"))
+	BytesCopy(&(head.Name), id)
+	BytesCopy(&(head.Author), []byte(PackAuthor))
 	BytesCopy(&(head.Type), []byte("RSA"))
 	BytesCopy(&(head.Number), IntToBytes(len(src)))
 	r[0] = head.Name
@@ -143,7 +139,15 @@ func PackRSAOne(src string) (r []byte, err error) {
 		log.Println("Error read file:", err)
 		return r, err
 	}
-	// third, generate rsa key
+	_, name := filepath.Split(src)
+	return PackRSAOneData(name, data)
+}
+
+// PackRSAOneData function
+// it common with function PackRSAOne, just take name and data from memory instead of file
+// name is the entry name in package, it should not be longer than 32 bytes
+func PackRSAOneData(name string, data []byte) (r []byte, err error) {
+	// first, generate rsa key
 	var pri []byte
 	var pub []byte
 	err = GenRSAKey2Memory(&pri, &pub, 1024)
@@ -151,13 +155,13 @@ func PackRSAOne(src string) (r []byte, err error) {
 		log.Println("Error generate rsa key:", err)
 		return r, err
 	}
-	// fourth, split the data slice
+	// second, split the data slice
 	ss, err := SplitByte(data, RSAPacketSize)
 	if err != nil {
 		log.Println("Error split bytes:", err)
 		return r, err
 	}
-	// fifth, we can call RSAEncrypt function
+	// third, we can call RSAEncrypt function
 	wg := &sync.WaitGroup{}
 	rr := make([][]byte, len(ss))
 	for k, v := range ss {
@@ -166,8 +170,7 @@ func PackRSAOne(src string) (r []byte, err error) {
 	}
 	wg.Wait()
 	dest := bytes.Join(rr, []byte(""))
-	// sixth, fill the packet struct
-	_, name := filepath.Split(src)
+	// fourth, fill the packet struct
 	if len([]byte(name)) > 32 {
 		log.Println("Error source file name length:", err)
 		return
@@ -214,19 +217,5 @@ func RSAEncryptGo(src, key []byte, dest *[]byte, wg *sync.WaitGroup) (err error)
 // RSAEncrypt function
 // it the base function of RSAEncryptGo
 func RSAEncrypt(src, key []byte) (dest []byte, err error) {
-	block, _ := pem.Decode(key)
-	if block == nil {
-		err = errors.New("RSA Public Key Error")
-		return dest, err
-	}
-	pi, err := x509.ParsePKIXPublicKey(block.Bytes)
-	if err != nil {
-		return dest, err
-	}
-	pub := pi.(*rsa.PublicKey)
-	dest, err = rsa.EncryptPKCS1v15(rand.Reader, pub, src)
-	if err != nil {
-		return dest, err
-	}
-	return dest, err
+	return EntryEncrypt("RSA", key, nil, src)
 }
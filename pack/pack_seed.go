@@ -0,0 +1,53 @@
+package pack
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"golang.org/x/crypto/hkdf"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// seedBytes function
+// it derives size bytes from seed by hkdf-sha256, salt binds the output to content and info tells the usage
+func seedBytes(seed []byte, salt []byte, info string, size int) (r []byte, err error) {
+	r = make([]byte, size)
+	_, err = io.ReadFull(hkdf.New(sha256.New, seed, salt, []byte(info)), r)
+	if err != nil {
+		log.Println("Error derive bytes from seed:", err)
+	}
+	return r, err
+}
+
+// seedUUID function
+// it derives the package uuid from seed and package content, in the same format as NewUUID
+func seedUUID(seed []byte, salt []byte) (r []byte, err error) {
+	b, err := seedBytes(seed, salt, "qora package uuid", 16)
+	if err != nil {
+		return r, err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	r = make([]byte, 32)
+	hex.Encode(r, b)
+	return r, err
+}
+
+// sourceDateEpoch function
+// return the fixed timestamp of deterministic package, it is SOURCE_DATE_EPOCH when set, otherwise unix epoch
+func sourceDateEpoch() (t time.Time) {
+	t = time.Unix(0, 0).UTC()
+	s := os.Getenv("SOURCE_DATE_EPOCH")
+	if s == "" {
+		return t
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		log.Println("Error parse SOURCE_DATE_EPOCH:", err)
+		return t
+	}
+	return time.Unix(n, 0).UTC()
+}
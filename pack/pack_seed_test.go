@@ -0,0 +1,82 @@
+package pack
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"qora/unpack"
+	"testing"
+	"time"
+)
+
+// TestPackWithOptionSeed function
+func TestPackWithOptionSeed(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	var src []string
+	in := t.TempDir()
+	for _, v := range []string{"file_3.txt", "file_1.txt", "file_2.txt"} {
+		data, _ := ioutil.ReadFile(filepath.Join("../test/data/pack", v))
+		_ = ioutil.WriteFile(filepath.Join(in, v), data, 0644)
+		src = append(src, filepath.Join(in, v))
+	}
+	seed := []byte("reproducible build seed")
+	key := []byte("Satellite-266414")
+	// gob encodes map in random order, labels should not break it
+	labels := map[string]string{"env": "test", "arch": "amd64", "os": "linux", "build": "42", "branch": "main", "owner": "qora"}
+	for _, algorithm := range []string{"AES", "DES", "3DES", "BASE64"} {
+		for _, opt := range []PackOption{{Seed: seed}, {Seed: seed, TableKey: key, PadPow2: algorithm != "BASE64", MetaDigest: true, Meta: TPackMeta{Labels: labels}}} {
+			dir := t.TempDir()
+			var r [][]byte
+			for i, v := range [][]string{src, {src[1], src[2], src[0]}} {
+				dest := filepath.Join(dir, "file_seed.pak")
+				if i == 1 {
+					// modify time of input should not matter
+					now := time.Now()
+					_ = os.Chtimes(v[0], now, now)
+				}
+				err := PackWithOption(v, dest, algorithm, opt)
+				if err != nil {
+					t.Fatal("Error Pack With Option:", algorithm, err)
+				}
+				data, _ := ioutil.ReadFile(dest)
+				r = append(r, data)
+			}
+			if !bytes.Equal(r[0], r[1]) {
+				t.Fatal("Error Pack With Option: package is not deterministic", algorithm)
+			}
+			dest := filepath.Join(dir, "file_other.pak")
+			other := opt
+			other.Seed = []byte("another seed")
+			err := PackWithOption(src, dest, algorithm, other)
+			data, _ := ioutil.ReadFile(dest)
+			if err != nil || bytes.Equal(data, r[0]) {
+				t.Fatal("Error Pack With Option: different seed should give different package", algorithm, err)
+			}
+			meta, err := unpack.ExtractMetaWithOption(filepath.Join(dir, "file_seed.pak"), unpack.UnpackOption{TableKey: opt.TableKey})
+			if err != nil || meta.Created.Unix() != 1700000000 {
+				t.Fatal("Error Pack With Option: create time", algorithm, err)
+			}
+			for k, v := range opt.Meta.Labels {
+				if meta.Labels[k] != v || len(meta.Labels) != len(opt.Meta.Labels) {
+					t.Fatal("Error Pack With Option: labels", algorithm, meta.Labels)
+				}
+			}
+			err = unpack.UnpackWithOption(filepath.Join(dir, "file_seed.pak"), dir+"/", unpack.UnpackOption{TableKey: opt.TableKey})
+			if err != nil {
+				t.Fatal("Error Unpack With Option:", algorithm, err)
+			}
+			for _, v := range src {
+				a, _ := ioutil.ReadFile(v)
+				b, _ := ioutil.ReadFile(filepath.Join(dir, filepath.Base(v)))
+				if !bytes.Equal(a, b) {
+					t.Fatal("Error Unpack With Option: content", algorithm, v)
+				}
+			}
+		}
+	}
+	err := PackWithOption(src, filepath.Join(t.TempDir(), "file_rsa.pak"), "RSA", PackOption{Seed: seed})
+	if err == nil {
+		t.Fatal("Error Pack With Option: rsa should not support seed")
+	}
+}
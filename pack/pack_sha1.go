@@ -11,7 +11,7 @@ import (
 // return bool indicate the success or failure function execute
 func SHA1Check(src string, dest string) bool {
 	b := false
-	if SHA1Encode(src) == dest {
+	if hashEqual(SHA1Encode(src), dest) {
 		b = true
 	}
 	return b
@@ -11,7 +11,7 @@ import (
 // return bool indicate the success or failure function execute
 func SHA256Check(src string, dest string) bool {
 	b := false
-	if SHA256Encode(src) == dest {
+	if hashEqual(SHA256Encode(src), dest) {
 		b = true
 	}
 	return b
@@ -37,3 +37,22 @@ func SHA256EncryptGo(data []byte, r *[sha256.Size]byte, wg *sync.WaitGroup) {
 func SHA256Encrypt(data []byte) [sha256.Size]byte {
 	return sha256.Sum256(data)
 }
+
+// SHA224Check function
+// input src string and output dest string with sha224
+// return bool indicate the success or failure function execute
+func SHA224Check(src string, dest string) bool {
+	b := false
+	if hashEqual(SHA224Encode(src), dest) {
+		b = true
+	}
+	return b
+}
+
+// SHA224Encode function
+// encode src string output dest string with sha224
+func SHA224Encode(src string) string {
+	h := sha256.New224()
+	h.Write([]byte(src))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,106 @@
+package pack
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/sha3"
+	"hash"
+	. "qora/global"
+)
+
+// SHA3Check256 function
+// input src string and output dest string with sha3-256
+// return bool indicate the success or failure function execute
+func SHA3Check256(src string, dest string) bool {
+	b := false
+	if hashEqual(SHA3Encode256(src), dest) {
+		b = true
+	}
+	return b
+}
+
+// SHA3Encode256 function
+// encode src string output dest string with sha3-256
+func SHA3Encode256(src string) string {
+	h := sha3.New256()
+	h.Write([]byte(src))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SHA3Check512 function
+// input src string and output dest string with sha3-512
+// return bool indicate the success or failure function execute
+func SHA3Check512(src string, dest string) bool {
+	b := false
+	if hashEqual(SHA3Encode512(src), dest) {
+		b = true
+	}
+	return b
+}
+
+// SHA3Encode512 function
+// encode src string output dest string with sha3-512
+func SHA3Encode512(src string) string {
+	h := sha3.New512()
+	h.Write([]byte(src))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SHAKEEncode128 function
+// encode src string output dest string with shake128, size is the output bytes, 0 is 32 bytes
+// return err indicate the success or failure function execute
+func SHAKEEncode128(src string, size int) (dest string, err error) {
+	h, err := newShake(sha3.NewShake128(), size)
+	if err != nil {
+		return dest, err
+	}
+	h.Write([]byte(src))
+	return hex.EncodeToString(h.Sum(nil)), err
+}
+
+// SHAKEEncode256 function
+// encode src string output dest string with shake256, size is the output bytes, 0 is 64 bytes
+// return err indicate the success or failure function execute
+func SHAKEEncode256(src string, size int) (dest string, err error) {
+	h, err := newShake(sha3.NewShake256(), size)
+	if err != nil {
+		return dest, err
+	}
+	h.Write([]byte(src))
+	return hex.EncodeToString(h.Sum(nil)), err
+}
+
+// shakeHash is the shake with fixed output size, so it could be used as hash.Hash
+type shakeHash struct {
+	sha3.ShakeHash
+	size int
+}
+
+// newShake function
+// it wraps shake as hash.Hash of size output bytes, 0 keeps the default size of shake
+func newShake(h sha3.ShakeHash, size int) (r hash.Hash, err error) {
+	if size == 0 {
+		return h, err
+	}
+	if size < 0 || size > ShakeMaxSize {
+		s := fmt.Sprintf("Invalid shake output size: %v", size)
+		err = errors.New(s)
+		return r, err
+	}
+	return &shakeHash{ShakeHash: h, size: size}, err
+}
+
+// Size function
+// return the output bytes of shake
+func (h *shakeHash) Size() int {
+	return h.size
+}
+
+// Sum function
+// append the output of shake to b, the state is not changed
+func (h *shakeHash) Sum(b []byte) []byte {
+	r := make([]byte, h.size)
+	_, _ = h.Clone().Read(r)
+	return append(b, r...)
+}
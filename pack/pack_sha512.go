@@ -11,7 +11,7 @@ import (
 // return bool indicate the success or failure function execute
 func SHA512Check(src string, dest string) bool {
 	b := false
-	if SHA512Encode(src) == dest {
+	if hashEqual(SHA512Encode(src), dest) {
 		b = true
 	}
 	return b
@@ -37,3 +37,41 @@ func SHA512EncryptGo(data []byte, r *[sha512.Size]byte, wg *sync.WaitGroup) {
 func SHA512Encrypt(data []byte) [sha512.Size]byte {
 	return sha512.Sum512(data)
 }
+
+// SHA384Check function
+// input src string and output dest string with sha384
+// return bool indicate the success or failure function execute
+func SHA384Check(src string, dest string) bool {
+	b := false
+	if hashEqual(SHA384Encode(src), dest) {
+		b = true
+	}
+	return b
+}
+
+// SHA384Encode function
+// encode src string output dest string with sha384
+func SHA384Encode(src string) string {
+	h := sha512.New384()
+	h.Write([]byte(src))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SHA512_256Check function
+// input src string and output dest string with sha512/256
+// return bool indicate the success or failure function execute
+func SHA512_256Check(src string, dest string) bool {
+	b := false
+	if hashEqual(SHA512_256Encode(src), dest) {
+		b = true
+	}
+	return b
+}
+
+// SHA512_256Encode function
+// encode src string output dest string with sha512/256, it is sha512 with different initial value truncated to 256 bits
+func SHA512_256Encode(src string) string {
+	h := sha512.New512_256()
+	h.Write([]byte(src))
+	return hex.EncodeToString(h.Sum(nil))
+}
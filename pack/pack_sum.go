@@ -0,0 +1,335 @@
+package pack
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	. "qora/global"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// GenerateManifest function
+// This function is mainly used for publish the checksum file of directory tree, like SHA256SUMS of release.
+// input root directory, hash algorithm and output manifest path, return error info
+// every regular file under root is hashed by HashFile in goroutines, no more than ConfineFiles files at the same time
+// the manifest is BSD tagged format of 'sha256sum --tag': 'SHA256 (path) = digest', path is relative to root with '/', sorted by path,
+// every line records its algorithm, so digests of the same length like 'sha256' and 'sha3_256' are never confused,
+// it could be checked by 'sha256sum -c' in root as well, 'blake2b512' gives the same manifest as 'b2sum --tag'
+// file name with backslash or newline is escaped as coreutils does, symbolic links are skipped
+// the manifest itself is skipped when it is written inside root
+// algorithm now support all algorithms of PackHashEncode except fuzzy hash 'ssdeep', you can send both up case and low case
+// return err indicate the success or failure function execute
+func GenerateManifest(root string, algorithm string, out string) (err error) {
+	// first, find the files under root
+	err = sumCheckAlgorithm(algorithm)
+	if err != nil {
+		return err
+	}
+	files, err := sumFiles(root, out)
+	if err != nil {
+		return err
+	}
+	// second, hash every file through goroutine
+	digests, errs := hashFiles(root, files, algorithm)
+	// finally, write the manifest
+	var buf bytes.Buffer
+	tag := sumTag(strings.ToLower(algorithm))
+	for k, v := range files {
+		if errs[k] != nil {
+			return errs[k]
+		}
+		name, escaped := sumEscape(v)
+		if escaped {
+			buf.WriteString("\\")
+		}
+		buf.WriteString(tag + " (" + name + ") = " + digests[k] + "\n")
+	}
+	err = ioutil.WriteFile(out, buf.Bytes(), 0644)
+	if err != nil {
+		log.Println("Error write manifest file:", err)
+	}
+	return err
+}
+
+// VerifyManifest function
+// This function is mainly used for check the directory tree with checksum file, like SHA256SUMS or B2SUMS.
+// input manifest path and root directory, the paths in manifest are relative to root, empty root is the directory of manifest
+// algorithm is decided by the tag of BSD tagged line like 'SHA256 (path) = digest',
+// untagged line of coreutils is decided by manifest name like 'SHA256SUMS', 'B2SUMS' or 'file.sha512', otherwise by digest length
+// the files which are mismatched, missing, unreadable or not in manifest are listed in report,
+// err is not nil when any file is not passed, the report is returned in that case as well
+// return r the verify report, err indicate the success or failure function execute
+func VerifyManifest(manifest string, root string) (r *TSumReport, err error) {
+	return VerifyManifestWithAlgorithm(manifest, root, "")
+}
+
+// VerifyManifestWithAlgorithm function
+// it is common with function VerifyManifest, just the hash algorithm is given by caller instead of the manifest,
+// empty algorithm is decided by manifest
+// return r the verify report, err indicate the success or failure function execute
+func VerifyManifestWithAlgorithm(manifest string, root string, algorithm string) (r *TSumReport, err error) {
+	// first, parse the manifest
+	data, err := ioutil.ReadFile(manifest)
+	if err != nil {
+		log.Println("Error read manifest file:", err)
+		return r, err
+	}
+	var files, digests, tags []string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for i := 1; sc.Scan(); i++ {
+		if sc.Text() == "" {
+			continue
+		}
+		tag, digest, name, err := sumParseLine(sc.Text())
+		if err != nil {
+			s := fmt.Sprintf("Manifest line %v is invalid: %v", i, err)
+			err = errors.New(s)
+			return r, err
+		}
+		if len(tags) > 0 && tag != tags[0] {
+			s := fmt.Sprintf("Manifest line %v has another hash algorithm: %v", i, tag)
+			err = errors.New(s)
+			return r, err
+		}
+		files = append(files, name)
+		digests = append(digests, strings.ToLower(digest))
+		tags = append(tags, tag)
+	}
+	if len(files) == 0 {
+		err = errors.New("Manifest has no file.")
+		return r, err
+	}
+	if algorithm == "" && tags[0] != "" {
+		algorithm = sumTagAlgorithm(tags[0])
+	}
+	if algorithm == "" {
+		algorithm = sumAlgorithm(manifest, len(digests[0]))
+	}
+	r = &TSumReport{Algorithm: strings.ToLower(algorithm)}
+	if r.Algorithm == "" {
+		s := fmt.Sprintf("Manifest hash algorithm is unknown, digest length %v", len(digests[0]))
+		err = errors.New(s)
+		return r, err
+	}
+	err = sumCheckAlgorithm(r.Algorithm)
+	if err != nil {
+		return r, err
+	}
+	// second, hash every file through goroutine
+	if root == "" {
+		root = filepath.Dir(manifest)
+	}
+	rr, errs := hashFiles(root, files, r.Algorithm)
+	listed := make(map[string]bool, len(files))
+	for k, v := range files {
+		listed[filepath.Clean(filepath.FromSlash(v))] = true
+		switch {
+		case os.IsNotExist(errs[k]):
+			r.Missing = append(r.Missing, v)
+		case errs[k] != nil:
+			r.Unreadable = append(r.Unreadable, v)
+		case !hashEqual(rr[k], digests[k]):
+			r.Mismatched = append(r.Mismatched, v)
+		default:
+			r.Passed = append(r.Passed, v)
+		}
+	}
+	// third, find the files which are not in manifest
+	all, err := sumFiles(root, manifest)
+	if err != nil {
+		return r, err
+	}
+	for _, v := range all {
+		if !listed[filepath.FromSlash(v)] {
+			r.Extra = append(r.Extra, v)
+		}
+	}
+	// finally, tell whether the tree matches the manifest
+	n := len(r.Mismatched) + len(r.Missing) + len(r.Unreadable) + len(r.Extra)
+	if n > 0 {
+		s := fmt.Sprintf("Manifest verify failed: %v mismatched, %v missing, %v unreadable, %v extra", len(r.Mismatched), len(r.Missing), len(r.Unreadable), len(r.Extra))
+		err = errors.New(s)
+	}
+	return r, err
+}
+
+// HashFileConfineGo function
+// hash one file with goroutine, ch restricts the concurrent files
+func HashFileConfineGo(src string, algorithm string, dest *string, e *error, wg *sync.WaitGroup, ch chan interface{}) {
+	defer wg.Done()
+	r, err := HashFile(src, algorithm)
+	*dest, *e = r[strings.ToLower(algorithm)], err
+	<-ch
+}
+
+// hashFiles function
+// hash the files under root through goroutine, files are relative paths with '/'
+func hashFiles(root string, files []string, algorithm string) (digests []string, errs []error) {
+	wg := &sync.WaitGroup{}
+	ch := make(chan interface{}, ConfineFiles)
+	digests = make([]string, len(files))
+	errs = make([]error, len(files))
+	for k, v := range files {
+		wg.Add(1)
+		ch <- struct{}{}
+		go HashFileConfineGo(filepath.Join(root, filepath.FromSlash(v)), algorithm, &digests[k], &errs[k], wg, ch)
+	}
+	wg.Wait()
+	return digests, errs
+}
+
+// sumFiles function
+// return the regular files under root as sorted relative paths with '/', skip is the manifest file which is not listed
+func sumFiles(root string, skip string) (files []string, err error) {
+	skip, _ = filepath.Abs(skip)
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		if abs, _ := filepath.Abs(path); abs == skip {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		log.Println("Error walk directory:", err)
+		return files, err
+	}
+	sort.Strings(files)
+	return files, err
+}
+
+// sumCheckAlgorithm function
+// check the hash algorithm of manifest, fuzzy hash is refused as manifest digest is hex
+func sumCheckAlgorithm(algorithm string) (err error) {
+	h, err := newHash(strings.ToLower(algorithm), nil)
+	if err != nil {
+		return err
+	}
+	if _, ok := h.(*ctph); ok {
+		s := fmt.Sprintf("Manifest does not support fuzzy hash algorithm: %v", algorithm)
+		err = errors.New(s)
+	}
+	return err
+}
+
+// sumTags is the BSD tag of hash algorithm which is not its upper case name, they are the same as coreutils
+var sumTags = [][2]string{{"blake2b512", "BLAKE2b"}, {"blake2b256", "BLAKE2b-256"}, {"blake2b128", "BLAKE2b-128"}, {"sha3_256", "SHA3-256"}, {"sha3_512", "SHA3-512"}}
+
+// sumTag function
+// return the BSD tag of lower case algorithm name, like 'SHA256' or 'BLAKE2b'
+func sumTag(algorithm string) string {
+	for _, v := range sumTags {
+		if v[0] == algorithm {
+			return v[1]
+		}
+	}
+	return strings.ToUpper(algorithm)
+}
+
+// sumTagAlgorithm function
+// return the lower case algorithm name of BSD tag, it is the reverse of sumTag
+func sumTagAlgorithm(tag string) string {
+	for _, v := range sumTags {
+		if v[1] == tag {
+			return v[0]
+		}
+	}
+	return strings.ToLower(tag)
+}
+
+// sumAlgorithm function
+// return the hash algorithm of untagged manifest by manifest name, like 'SHA256SUMS' or 'file.sha256', otherwise by hex digest length
+func sumAlgorithm(manifest string, size int) string {
+	name := strings.ToLower(filepath.Base(manifest))
+	for _, v := range [][2]string{{"md5", "md5"}, {"sha1", "sha1"}, {"sha224", "sha224"}, {"sha256", "sha256"}, {"sha384", "sha384"}, {"sha512", "sha512"}, {"b2", "blake2b512"}} {
+		if strings.HasPrefix(name, v[0]+"sum") || strings.HasSuffix(name, "."+v[0]) {
+			return v[1]
+		}
+	}
+	switch size {
+	case 32:
+		return "md5"
+	case 40:
+		return "sha1"
+	case 56:
+		return "sha224"
+	case 64:
+		return "sha256"
+	case 96:
+		return "sha384"
+	case 128:
+		return "sha512"
+	}
+	return ""
+}
+
+// sumEscape function
+// escape backslash, newline and carriage return of file name as coreutils does, escaped tells the line needs prefix '\'
+func sumEscape(name string) (r string, escaped bool) {
+	if !strings.ContainsAny(name, "\\\n\r") {
+		return name, false
+	}
+	return strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r").Replace(name), true
+}
+
+// sumParseLine function
+// parse one manifest line like 'TAG (name) = digest', 'digest  name' or 'digest *name', escaped line starts with '\'
+// tag is empty for untagged line
+func sumParseLine(line string) (tag string, digest string, name string, err error) {
+	escaped := strings.HasPrefix(line, "\\")
+	if escaped {
+		line = line[1:]
+	}
+	i := strings.Index(line, " ")
+	j := strings.LastIndex(line, ") = ")
+	switch {
+	case i > 0 && strings.HasPrefix(line[i:], " (") && j > i:
+		tag, name, digest = line[:i], line[i+2:j], line[j+4:]
+	case i > 0 && i+2 <= len(line) && (line[i+1] == ' ' || line[i+1] == '*'):
+		digest, name = line[:i], line[i+2:]
+	default:
+		err = errors.New("it should be 'TAG (name) = digest', 'digest  name' or 'digest *name'")
+		return tag, digest, name, err
+	}
+	if _, err = hex.DecodeString(digest); err != nil || digest == "" {
+		err = errors.New("digest is not hex")
+		return tag, digest, name, err
+	}
+	if escaped {
+		var b strings.Builder
+		for j := 0; j < len(name); j++ {
+			if name[j] != '\\' || j+1 == len(name) {
+				b.WriteByte(name[j])
+				continue
+			}
+			j++
+			switch name[j] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				b.WriteByte(name[j])
+			}
+		}
+		name = b.String()
+	}
+	if name == "" {
+		err = errors.New("file name is empty")
+	}
+	return tag, digest, name, err
+}
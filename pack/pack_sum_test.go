@@ -0,0 +1,72 @@
+package pack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestManifest function
+func TestManifest(t *testing.T) {
+	root := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(root, "sub"), 0755)
+	files := map[string]string{"a.txt": "alpha", "sub/b.txt": "bravo", "c\\d\nname.txt": "charlie"}
+	for k, v := range files {
+		_ = ioutil.WriteFile(filepath.Join(root, k), []byte(v), 0644)
+	}
+	out := filepath.Join(root, "SHA256SUMS")
+	err := GenerateManifest(root, "sha256", out)
+	if err != nil {
+		t.Fatal("Error Generate Manifest:", err)
+	}
+	data, _ := ioutil.ReadFile(out)
+	if !strings.Contains(string(data), "SHA256 (a.txt) = "+SHA256Encode("alpha")+"\n") || !strings.Contains(string(data), "\\SHA256 (c\\\\d\\nname.txt) = "+SHA256Encode("charlie")+"\n") {
+		t.Fatal("Error Generate Manifest content:", string(data))
+	}
+	r, err := VerifyManifest(out, "")
+	if err != nil || r.Algorithm != "sha256" || len(r.Passed) != len(files) {
+		t.Fatal("Error Verify Manifest:", r, err)
+	}
+	// modify, remove and add files
+	_ = ioutil.WriteFile(filepath.Join(root, "a.txt"), []byte("alpha changed"), 0644)
+	_ = os.Remove(filepath.Join(root, "sub/b.txt"))
+	_ = ioutil.WriteFile(filepath.Join(root, "sub/e.txt"), []byte("echo"), 0644)
+	r, err = VerifyManifest(out, "")
+	if err == nil || len(r.Passed) != 1 || len(r.Mismatched) != 1 || r.Mismatched[0] != "a.txt" {
+		t.Fatal("Error Verify Manifest mismatched:", r, err)
+	}
+	if len(r.Missing) != 1 || r.Missing[0] != "sub/b.txt" || len(r.Extra) != 1 || r.Extra[0] != "sub/e.txt" {
+		t.Fatal("Error Verify Manifest missing and extra:", r)
+	}
+	// manifest outside root, algorithm is decided by tag even if digest length is the same as sha512 or sha256
+	// SHA256SUMS in root is listed as well
+	for _, v := range []string{"blake2b512", "sha3_256"} {
+		out = filepath.Join(t.TempDir(), "MANIFEST")
+		err = GenerateManifest(root, v, out)
+		if err != nil {
+			t.Fatal("Error Generate Manifest:", v, err)
+		}
+		r, err = VerifyManifest(out, root)
+		if err != nil || r.Algorithm != v || len(r.Passed) != len(files)+1 {
+			t.Fatal("Error Verify Manifest:", v, r, err)
+		}
+		r, err = VerifyManifestWithAlgorithm(out, root, "sha512")
+		if err == nil || len(r.Passed) != 0 || len(r.Mismatched) != len(files)+1 {
+			t.Fatal("Error Verify Manifest: wrong algorithm should mismatch", v, r, err)
+		}
+	}
+	// untagged manifest of coreutils, algorithm is decided by name
+	out = filepath.Join(t.TempDir(), "B2SUMS")
+	digest, _ := PackHashEncode("echo", "blake2b512")
+	_ = ioutil.WriteFile(out, []byte(digest+"  e.txt\n"), 0644)
+	r, err = VerifyManifest(out, filepath.Join(root, "sub"))
+	if err != nil || r.Algorithm != "blake2b512" || len(r.Passed) != 1 {
+		t.Fatal("Error Verify Manifest untagged:", r, err)
+	}
+	err = GenerateManifest(root, "sha0", out)
+	if err == nil {
+		t.Fatal("Error Generate Manifest: undefined algorithm should fail")
+	}
+}
@@ -0,0 +1,98 @@
+package pack
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	. "qora/global"
+	. "qora/utils"
+	"strings"
+)
+
+// SplitVolume function
+// input package path and max part size, output part path list and error info
+// the package is split into 'name.q01', 'name.q02', ... beside it, the package itself is kept
+// every part has a header with set id, sequence number, total part number and payload digest
+// unpack accepts any part of the set and finds the rest by itself
+// return err indicate the success or failure function execute
+func SplitVolume(src string, size int64) (parts []string, err error) {
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		log.Println("Error read file:", err)
+		return parts, err
+	}
+	return writeVolume(data, src, size, nil)
+}
+
+// volumePath function
+// return the path of part seq for package dest, like 'name.q01'
+func volumePath(dest string, seq int) string {
+	return fmt.Sprintf("%v.q%02d", strings.TrimSuffix(dest, filepath.Ext(dest)), seq)
+}
+
+// writeVolume function
+// it will split package data into parts no more than size bytes and write them
+// set id is derived from seed and data when seed is not nil, otherwise it is random
+func writeVolume(data []byte, dest string, size int64, seed []byte) (parts []string, err error) {
+	// first, check the part size
+	n := size - VolumeHeadSize
+	if n <= 0 || n > 0xffffffff {
+		s := fmt.Sprintf("Invalid volume size: %v", size)
+		err = errors.New(s)
+		return parts, err
+	}
+	_, name := filepath.Split(dest)
+	if len([]byte(name)) > 32 {
+		s := fmt.Sprintf("Error dest file name length: %v", name)
+		err = errors.New(s)
+		return parts, err
+	}
+	total := (int64(len(data)) + n - 1) / n
+	if total == 0 {
+		total = 1
+	}
+	// second, new a set id shared by all parts
+	id := make([]byte, 16)
+	if seed != nil {
+		digest := sha256.Sum256(data)
+		id, err = seedBytes(seed, digest[:], "qora volume set id", 16)
+	} else {
+		_, err = rand.Read(id)
+	}
+	if err != nil {
+		log.Println("Error new volume set id:", err)
+		return parts, err
+	}
+	// finally, write every part
+	for i := int64(0); i < total; i++ {
+		end := (i + 1) * n
+		if end > int64(len(data)) {
+			end = int64(len(data))
+		}
+		payload := data[i*n : end]
+		digest := sha256.Sum256(payload)
+		head := TPackVolume{}
+		head.Magic = []byte(VolumeMagic)
+		head.SetID = id
+		head.Seq = IntToBytes(int(i + 1))
+		head.Total = IntToBytes(int(total))
+		head.Size = IntToBytes(len(payload))
+		head.Digest = digest[:]
+		head.Name = make([]byte, 32)
+		BytesCopy(&(head.Name), []byte(name))
+		r := [][]byte{head.Magic, head.SetID, head.Seq, head.Total, head.Size, head.Digest, head.Name, payload}
+		path := volumePath(dest, int(i+1))
+		err = ioutil.WriteFile(path, bytes.Join(r, []byte("")), 0644)
+		if err != nil {
+			log.Println("Error write volume file:", err)
+			return parts, err
+		}
+		parts = append(parts, path)
+	}
+	return parts, err
+}
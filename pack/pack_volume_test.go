@@ -0,0 +1,89 @@
+package pack
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"qora/unpack"
+	"strings"
+	"testing"
+)
+
+// TestPackWithOptionVolume function
+func TestPackWithOptionVolume(t *testing.T) {
+	entries := []Entry{{Name: "a.txt", Bytes: bytes.Repeat([]byte("qora"), 1000)}, {Name: "b.txt", Bytes: []byte("hello")}}
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "file_volume.pak")
+	err := PackEntriesWithOption(entries, dest, "AES", PackOption{VolumeSize: 1024})
+	if err != nil {
+		t.Fatal("Error Pack Entries With Option:", err)
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Fatal("Error Pack Entries With Option: whole package should not be written")
+	}
+	parts, _ := filepath.Glob(filepath.Join(dir, "file_volume.q*"))
+	if len(parts) < 4 {
+		t.Fatal("Error Pack Entries With Option part number:", parts)
+	}
+	for _, v := range parts {
+		fi, _ := os.Stat(v)
+		if fi.Size() > 1024 {
+			t.Fatal("Error Pack Entries With Option part size:", v, fi.Size())
+		}
+	}
+	// unpack from the first part
+	err = unpack.Unpack(parts[0], dir+"/")
+	if err != nil {
+		t.Fatal("Error Unpack volume:", err)
+	}
+	r, _ := ioutil.ReadFile(filepath.Join(dir, "a.txt"))
+	if !bytes.Equal(r, entries[0].Bytes) {
+		t.Fatal("Error Unpack volume content")
+	}
+	// missing and corrupt parts should be reported
+	data, _ := ioutil.ReadFile(parts[2])
+	data[len(data)-1] ^= 0xff
+	_ = ioutil.WriteFile(parts[2], data, 0644)
+	_ = os.Remove(parts[1])
+	err = unpack.Unpack(parts[0], dir+"/")
+	e, ok := err.(*unpack.VolumeError)
+	if !ok {
+		t.Fatal("Error Unpack broken volume:", err)
+	}
+	if len(e.Missing) != 1 || e.Missing[0] != parts[1] || len(e.Corrupt) != 1 || !strings.HasPrefix(e.Corrupt[0], parts[2]) {
+		t.Fatal("Error Unpack broken volume report:", e)
+	}
+}
+
+// TestSplitVolume function
+func TestSplitVolume(t *testing.T) {
+	src := []string{"../test/data/pack/file_1.txt", "../test/data/pack/file_2.txt"}
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "file_des.pak")
+	err := PackDES(src, dest)
+	if err != nil {
+		t.Fatal("Error Pack DES:", err)
+	}
+	parts, err := SplitVolume(dest, 200)
+	if err != nil || len(parts) < 2 {
+		t.Fatal("Error Split Volume:", parts, err)
+	}
+	var names []string
+	var sz []int
+	var algorithm string
+	err = unpack.ExtractInfo(parts[len(parts)-1], &names, &sz, &algorithm)
+	if err != nil || len(names) != 2 || algorithm != "des" {
+		t.Fatal("Error Extract Info of volume:", names, algorithm, err)
+	}
+	join := filepath.Join(dir, "file_join.pak")
+	err = unpack.JoinVolume(parts[0], join)
+	if err != nil {
+		t.Fatal("Error Join Volume:", err)
+	}
+	r, _ := ioutil.ReadFile(join)
+	s, _ := ioutil.ReadFile(dest)
+	if !bytes.Equal(r, s) {
+		t.Fatal("Error Join Volume content")
+	}
+}
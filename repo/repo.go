@@ -0,0 +1,487 @@
+package repo
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/dchest/blake2b"
+	"golang.org/x/crypto/scrypt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	. "qora/global"
+	"qora/pack"
+	"qora/unpack"
+	. "qora/utils"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Repo is an opened repository, it keeps the keys derived from password
+type Repo struct {
+	path   string
+	config TRepoConfig
+	encKey []byte       // aes key of chunks and snapshots
+	idKey  []byte       // blake2b key of chunk id
+	gear   *[256]uint64 // gear table of chunker
+}
+
+// Init function
+// input repository path, password and option, output error info
+// it creates an empty repository, files are split into content defined chunks and every chunk is stored once,
+// chunk is addressed by keyed blake2b of its content and sealed by aes-gcm, keys are derived from password by scrypt
+// return err indicate the success or failure function execute
+func Init(dir string, password []byte, opt RepoOption) (err error) {
+	// first, check the repository path
+	if _, err := os.Stat(filepath.Join(dir, "config")); err == nil {
+		s := fmt.Sprintf("Repository already exist: %v", dir)
+		err = errors.New(s)
+		return err
+	}
+	c := TRepoConfig{Version: 1, N: RepoScryptN, R: RepoScryptR, P: RepoScryptP, ChunkMin: RepoChunkMin, ChunkBits: RepoChunkBits, ChunkMax: RepoChunkMax}
+	if opt.ChunkMin > 0 {
+		c.ChunkMin = opt.ChunkMin
+	}
+	if opt.ChunkBits > 0 {
+		c.ChunkBits = opt.ChunkBits
+	}
+	if opt.ChunkMax > 0 {
+		c.ChunkMax = opt.ChunkMax
+	}
+	if opt.ScryptN > 0 {
+		c.N = opt.ScryptN
+	}
+	if c.ChunkMin > c.ChunkMax || c.ChunkBits > 30 {
+		s := fmt.Sprintf("Invalid chunk size: min %v, max %v, bits %v", c.ChunkMin, c.ChunkMax, c.ChunkBits)
+		err = errors.New(s)
+		return err
+	}
+	// second, derive the master key and seal the check value
+	c.Salt = make([]byte, 32)
+	_, err = rand.Read(c.Salt)
+	if err != nil {
+		log.Println("Error generate random salt:", err)
+		return err
+	}
+	r := &Repo{path: dir, config: c}
+	err = r.deriveKey(password)
+	if err != nil {
+		return err
+	}
+	r.config.Check, err = pack.SealBytes(r.encKey, []byte(RepoMagic), nil)
+	if err != nil {
+		return err
+	}
+	// finally, create the directories and config
+	for _, v := range []string{"chunks", "snapshots"} {
+		err = os.MkdirAll(filepath.Join(dir, v), 0755)
+		if err != nil {
+			log.Println("Error create repository dir:", err)
+			return err
+		}
+	}
+	var buf bytes.Buffer
+	err = pack.GobEncode(&buf, r.config)
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(dir, "config"), buf.Bytes())
+}
+
+// Open function
+// input repository path and password, output the opened repository
+// return err indicate the success or failure function execute, wrong password fails here
+func Open(dir string, password []byte) (r *Repo, err error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, "config"))
+	if err != nil {
+		log.Println("Error read repository config:", err)
+		return r, err
+	}
+	r = &Repo{path: dir}
+	err = unpack.GobDecode(bytes.NewBuffer(data), &r.config)
+	if err != nil {
+		return nil, err
+	}
+	err = r.deriveKey(password)
+	if err != nil {
+		return nil, err
+	}
+	check, err := unpack.OpenBytes(r.encKey, r.config.Check, nil)
+	if err != nil || string(check) != RepoMagic {
+		err = errors.New("Wrong repository password.")
+		return nil, err
+	}
+	return r, err
+}
+
+// Backup function
+// input source file or directory list, output snapshot id
+// every source is stored under its base name in snapshot, only the chunks which are not in repository are written
+// sources with the same base name, like '/a/data' and '/b/data', would collide in snapshot, so they are refused
+// symbolic links and other special files are skipped
+// return err indicate the success or failure function execute
+func (r *Repo) Backup(src []string) (id string, err error) {
+	unlock, err := pack.LockPackage(filepath.Join(r.path, "repo"))
+	if err != nil {
+		return id, err
+	}
+	defer unlock()
+	s := TSnapshot{Time: time.Now().UTC()}
+	// first, check the sources never collide in snapshot
+	names := make(map[string]string, len(src))
+	for _, v := range src {
+		abs, err := filepath.Abs(v)
+		if err != nil {
+			return id, err
+		}
+		name := filepath.Base(abs)
+		if p, ok := names[name]; ok {
+			e := fmt.Sprintf("Backup source collides in snapshot: %v and %v are both stored as %v", p, abs, name)
+			err = errors.New(e)
+			return id, err
+		}
+		names[name] = abs
+		s.Paths = append(s.Paths, abs)
+	}
+	// second, walk every source and store the files
+	for _, abs := range s.Paths {
+		base := filepath.Dir(abs)
+		err = filepath.Walk(abs, func(p string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(base, p)
+			if err != nil {
+				return err
+			}
+			node := TSnapshotNode{Path: filepath.ToSlash(rel), Mode: fi.Mode().Perm(), ModTime: fi.ModTime().UTC()}
+			switch {
+			case fi.IsDir():
+				node.Dir = true
+			case fi.Mode().IsRegular():
+				node.Size = fi.Size()
+				node.Chunks, err = r.storeFile(p)
+				if err != nil {
+					return err
+				}
+				s.Size += node.Size
+			default:
+				return nil
+			}
+			s.Nodes = append(s.Nodes, node)
+			return nil
+		})
+		if err != nil {
+			log.Println("Error backup path:", err)
+			return id, err
+		}
+	}
+	// finally, seal the snapshot
+	uuid, err := NewUUID()
+	if err != nil {
+		return id, err
+	}
+	s.ID = string(uuid)
+	var buf bytes.Buffer
+	err = pack.GobEncode(&buf, s)
+	if err != nil {
+		return id, err
+	}
+	data, err := pack.SealBytes(r.encKey, buf.Bytes(), []byte(s.ID))
+	if err != nil {
+		return id, err
+	}
+	err = writeFile(filepath.Join(r.path, "snapshots", s.ID), data)
+	return s.ID, err
+}
+
+// Restore function
+// input snapshot id and dest directory, output error info
+// every chunk is checked by its id after decrypt, the file mode and modify time are restored as well
+// return err indicate the success or failure function execute
+func (r *Repo) Restore(id string, dest string) (err error) {
+	s, err := r.Snapshot(id)
+	if err != nil {
+		return err
+	}
+	for _, v := range s.Nodes {
+		// node path is authenticated, check it anyway so nothing is written out of dest
+		p := path.Clean(v.Path)
+		if p != v.Path || path.IsAbs(p) || p == ".." || strings.HasPrefix(p, "../") {
+			e := fmt.Sprintf("Invalid snapshot path: %v", v.Path)
+			err = errors.New(e)
+			return err
+		}
+		file := filepath.Join(dest, filepath.FromSlash(p))
+		if v.Dir {
+			err = os.MkdirAll(file, 0755)
+			if err != nil {
+				log.Println("Error create dir:", err)
+				return err
+			}
+			continue
+		}
+		err = r.restoreFile(file, v)
+		if err != nil {
+			return err
+		}
+	}
+	// directory time is changed when its children are created, so set it at last
+	for k := len(s.Nodes) - 1; k >= 0; k-- {
+		v := s.Nodes[k]
+		if v.Dir {
+			file := filepath.Join(dest, filepath.FromSlash(v.Path))
+			_ = os.Chmod(file, v.Mode)
+			_ = os.Chtimes(file, v.ModTime, v.ModTime)
+		}
+	}
+	return err
+}
+
+// Snapshot function
+// input snapshot id, return the snapshot
+// return err indicate the success or failure function execute
+func (r *Repo) Snapshot(id string) (s *TSnapshot, err error) {
+	if id == "" || strings.ContainsAny(id, "/\\.") {
+		e := fmt.Sprintf("Invalid snapshot id: %v", id)
+		err = errors.New(e)
+		return s, err
+	}
+	data, err := ioutil.ReadFile(filepath.Join(r.path, "snapshots", id))
+	if err != nil {
+		log.Println("Error read snapshot:", err)
+		return s, err
+	}
+	data, err = unpack.OpenBytes(r.encKey, data, []byte(id))
+	if err != nil {
+		log.Println("Error open snapshot:", err)
+		return s, err
+	}
+	s = &TSnapshot{}
+	err = unpack.GobDecode(bytes.NewBuffer(data), s)
+	return s, err
+}
+
+// Snapshots function
+// return all snapshots in repository sorted by create time
+// return err indicate the success or failure function execute
+func (r *Repo) Snapshots() (s []TSnapshot, err error) {
+	files, err := ioutil.ReadDir(filepath.Join(r.path, "snapshots"))
+	if err != nil {
+		log.Println("Error read snapshot dir:", err)
+		return s, err
+	}
+	for _, v := range files {
+		// skip the temporary file of unfinished backup
+		if strings.HasSuffix(v.Name(), ".tmp") {
+			continue
+		}
+		ss, err := r.Snapshot(v.Name())
+		if err != nil {
+			return s, err
+		}
+		s = append(s, *ss)
+	}
+	sort.Slice(s, func(i, j int) bool { return s[i].Time.Before(s[j].Time) })
+	return s, err
+}
+
+// Forget function
+// input snapshot id list, output error info
+// it removes the snapshots only, chunks which are not referenced any more are removed by Prune
+// return err indicate the success or failure function execute
+func (r *Repo) Forget(id ...string) (err error) {
+	unlock, err := pack.LockPackage(filepath.Join(r.path, "repo"))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	for _, v := range id {
+		_, err = r.Snapshot(v)
+		if err != nil {
+			return err
+		}
+		err = os.Remove(filepath.Join(r.path, "snapshots", v))
+		if err != nil {
+			log.Println("Error remove snapshot:", err)
+			return err
+		}
+	}
+	return err
+}
+
+// Prune function
+// it removes every chunk which is not referenced by any snapshot
+// return report of referenced and removed chunks, err indicate the success or failure function execute
+func (r *Repo) Prune() (report TPruneReport, err error) {
+	unlock, err := pack.LockPackage(filepath.Join(r.path, "repo"))
+	if err != nil {
+		return report, err
+	}
+	defer unlock()
+	// first, collect the referenced chunks
+	s, err := r.Snapshots()
+	if err != nil {
+		return report, err
+	}
+	used := make(map[string]bool)
+	for _, v := range s {
+		for _, vv := range v.Nodes {
+			for _, c := range vv.Chunks {
+				used[c] = true
+			}
+		}
+	}
+	report.Chunks = len(used)
+	// second, remove the others
+	err = filepath.Walk(filepath.Join(r.path, "chunks"), func(p string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() || used[fi.Name()] {
+			return err
+		}
+		err = os.Remove(p)
+		if err != nil {
+			return err
+		}
+		report.Removed++
+		report.Freed += fi.Size()
+		return nil
+	})
+	if err != nil {
+		log.Println("Error prune chunks:", err)
+	}
+	return report, err
+}
+
+// deriveKey function
+// it derives aes key, chunk id key and gear key from password
+func (r *Repo) deriveKey(password []byte) (err error) {
+	c := r.config
+	key, err := scrypt.Key(password, c.Salt, c.N, c.R, c.P, 96)
+	if err != nil {
+		log.Println("Error derive repository key:", err)
+		return err
+	}
+	r.encKey = key[0:32]
+	r.idKey = key[32:64]
+	r.gear = newGear(key[64:96])
+	return err
+}
+
+// chunkID function
+// return the keyed blake2b of chunk
+func (r *Repo) chunkID(chunk []byte) string {
+	h := blake2b.NewMAC(32, r.idKey)
+	h.Write(chunk)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// chunkPath function
+// return the chunk file path, chunks are spread into 256 directories by the first byte of id
+func (r *Repo) chunkPath(id string) string {
+	return filepath.Join(r.path, "chunks", id[:2], id)
+}
+
+// storeFile function
+// it will split file into chunks and store the new chunks
+func (r *Repo) storeFile(src string) (chunks []string, err error) {
+	file, err := os.Open(src)
+	if err != nil {
+		log.Println("Error open file:", err)
+		return chunks, err
+	}
+	defer file.Close()
+	c := newChunker(file, r.gear, &r.config)
+	for {
+		chunk, err := c.Next()
+		if err == io.EOF {
+			return chunks, nil
+		}
+		if err != nil {
+			log.Println("Error read file:", err)
+			return chunks, err
+		}
+		id := r.chunkID(chunk)
+		chunks = append(chunks, id)
+		p := r.chunkPath(id)
+		if _, err := os.Stat(p); err == nil {
+			continue
+		}
+		data, err := pack.SealBytes(r.encKey, chunk, []byte(id))
+		if err != nil {
+			return chunks, err
+		}
+		err = os.MkdirAll(filepath.Dir(p), 0755)
+		if err != nil {
+			log.Println("Error create chunk dir:", err)
+			return chunks, err
+		}
+		err = writeFile(p, data)
+		if err != nil {
+			return chunks, err
+		}
+	}
+}
+
+// restoreFile function
+// it will join the chunks of node into file
+func (r *Repo) restoreFile(dest string, node TSnapshotNode) (err error) {
+	err = os.MkdirAll(filepath.Dir(dest), 0755)
+	if err != nil {
+		log.Println("Error create dir:", err)
+		return err
+	}
+	file, err := os.OpenFile(dest, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		log.Println("Error create file:", err)
+		return err
+	}
+	for _, id := range node.Chunks {
+		data, err := ioutil.ReadFile(r.chunkPath(id))
+		if err != nil {
+			log.Println("Error read chunk:", err)
+			_ = file.Close()
+			return err
+		}
+		chunk, err := unpack.OpenBytes(r.encKey, data, []byte(id))
+		if err != nil || r.chunkID(chunk) != id {
+			s := fmt.Sprintf("Chunk is damaged: %v", id)
+			_ = file.Close()
+			return errors.New(s)
+		}
+		_, err = file.Write(chunk)
+		if err != nil {
+			log.Println("Error write file:", err)
+			_ = file.Close()
+			return err
+		}
+	}
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+	_ = os.Chmod(dest, node.Mode)
+	return os.Chtimes(dest, node.ModTime, node.ModTime)
+}
+
+// writeFile function
+// it writes file through a temporary file, so reader never see a half written file
+func writeFile(dest string, data []byte) (err error) {
+	tmp := dest + ".tmp"
+	err = ioutil.WriteFile(tmp, data, 0644)
+	if err != nil {
+		log.Println("Error write file:", err)
+		return err
+	}
+	err = os.Rename(tmp, dest)
+	if err != nil {
+		log.Println("Error rename file:", err)
+		_ = os.Remove(tmp)
+	}
+	return err
+}
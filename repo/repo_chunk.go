@@ -0,0 +1,70 @@
+package repo
+
+import (
+	"bufio"
+	"encoding/binary"
+	"github.com/dchest/blake2b"
+	"io"
+)
+
+// chunker splits stream into content defined chunks
+// a gear rolling hash is updated with every byte, chunk is cut when the low ChunkBits bits of hash are zero,
+// so one insert or delete only changes the chunks near it, the chunks behind it are kept the same
+type chunker struct {
+	rd   *bufio.Reader
+	gear *[256]uint64
+	min  int
+	max  int
+	mask uint64
+}
+
+// newGear function
+// it derives the gear table from key, so chunk boundaries do not leak content to the one who has no key
+func newGear(key []byte) *[256]uint64 {
+	gear := &[256]uint64{}
+	for i := 0; i < 32; i++ {
+		h := blake2b.NewMAC(64, key)
+		h.Write([]byte{byte(i)})
+		r := h.Sum(nil)
+		for j := 0; j < 8; j++ {
+			gear[i*8+j] = binary.BigEndian.Uint64(r[j*8:])
+		}
+	}
+	return gear
+}
+
+// newChunker function
+// it creates chunker on reader with config of repository
+func newChunker(rd io.Reader, gear *[256]uint64, c *TRepoConfig) *chunker {
+	return &chunker{
+		rd:   bufio.NewReaderSize(rd, 1<<16),
+		gear: gear,
+		min:  c.ChunkMin,
+		max:  c.ChunkMax,
+		mask: 1<<uint(c.ChunkBits) - 1,
+	}
+}
+
+// Next function
+// return the next chunk, io.EOF when stream is over
+func (c *chunker) Next() (chunk []byte, err error) {
+	var h uint64
+	for len(chunk) < c.max {
+		b, err := c.rd.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return chunk, err
+		}
+		chunk = append(chunk, b)
+		h = h<<1 + c.gear[b]
+		if len(chunk) >= c.min && h&c.mask == 0 {
+			break
+		}
+	}
+	if len(chunk) == 0 {
+		return chunk, io.EOF
+	}
+	return chunk, err
+}
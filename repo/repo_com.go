@@ -0,0 +1,52 @@
+package repo
+
+import (
+	"os"
+	"time"
+)
+
+// repository option
+type RepoOption struct {
+	ChunkMin  int // chunk min size, 0 use RepoChunkMin
+	ChunkBits int // chunk average size is 1<<ChunkBits, 0 use RepoChunkBits
+	ChunkMax  int // chunk max size, 0 use RepoChunkMax
+	ScryptN   int // scrypt cost parameter N, 0 use RepoScryptN
+}
+
+// repository config, gob encoded in 'config' file of repository
+type TRepoConfig struct {
+	Version   int    // repository format version
+	Salt      []byte // scrypt salt of master key
+	N         int    // scrypt cost parameter N
+	R         int    // scrypt block size parameter r
+	P         int    // scrypt parallelization parameter p
+	ChunkMin  int    // chunk min size
+	ChunkBits int    // chunk average size is 1<<ChunkBits
+	ChunkMax  int    // chunk max size
+	Check     []byte // RepoMagic sealed by master key, used to check password
+}
+
+// repository snapshot, gob encoded and sealed in 'snapshots' directory of repository
+type TSnapshot struct {
+	ID    string          // snapshot id
+	Time  time.Time       // snapshot create time
+	Paths []string        // source paths of backup
+	Size  int64           // total size of files
+	Nodes []TSnapshotNode // files and directories in snapshot, parent is always in front of child
+}
+
+type TSnapshotNode struct {
+	Path    string      // slash separated relative path
+	Dir     bool        // node is directory
+	Mode    os.FileMode // permission bits
+	ModTime time.Time   // modify time
+	Size    int64       // file size
+	Chunks  []string    // chunk id list of file content
+}
+
+// prune report
+type TPruneReport struct {
+	Chunks  int   // referenced chunk number
+	Removed int   // removed chunk number
+	Freed   int64 // removed chunk size
+}
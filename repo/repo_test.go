@@ -0,0 +1,125 @@
+package repo
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// countChunks function
+func countChunks(dir string) (n int) {
+	_ = filepath.Walk(filepath.Join(dir, "chunks"), func(p string, fi os.FileInfo, err error) error {
+		if err == nil && !fi.IsDir() {
+			n++
+		}
+		return nil
+	})
+	return n
+}
+
+// TestRepo function
+func TestRepo(t *testing.T) {
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "repo")
+	password := []byte("Satellite-266414")
+	err := Init(dest, password, RepoOption{ChunkMin: 1024, ChunkBits: 12, ChunkMax: 16384, ScryptN: 1024})
+	if err != nil {
+		t.Fatal("Error Init:", err)
+	}
+	_, err = Open(dest, []byte("Satellite-000000"))
+	if err == nil {
+		t.Fatal("Error Open: wrong password should fail")
+	}
+	r, err := Open(dest, password)
+	if err != nil {
+		t.Fatal("Error Open:", err)
+	}
+	// first backup
+	src := filepath.Join(dir, "data")
+	_ = os.MkdirAll(filepath.Join(src, "sub"), 0755)
+	data := make([]byte, 512*1024)
+	rand.New(rand.NewSource(1)).Read(data)
+	_ = ioutil.WriteFile(filepath.Join(src, "a.bin"), data, 0644)
+	_ = ioutil.WriteFile(filepath.Join(src, "sub", "b.txt"), []byte("hello"), 0600)
+	id1, err := r.Backup([]string{src})
+	if err != nil {
+		t.Fatal("Error Backup:", err)
+	}
+	n1 := countChunks(dest)
+	// second backup after insert in the middle, only chunks near the insert are new
+	data2 := append(append(append([]byte{}, data[:200000]...), []byte("insert")...), data[200000:]...)
+	_ = ioutil.WriteFile(filepath.Join(src, "a.bin"), data2, 0644)
+	id2, err := r.Backup([]string{src})
+	if err != nil {
+		t.Fatal("Error Backup:", err)
+	}
+	n2 := countChunks(dest)
+	if n2-n1 > 4 {
+		t.Fatal("Error Backup dedup:", n1, n2)
+	}
+	s, err := r.Snapshots()
+	if err != nil || len(s) != 2 || s[0].ID != id1 || s[1].ID != id2 {
+		t.Fatal("Error Snapshots:", err)
+	}
+	// restore both snapshots
+	for k, v := range map[string][]byte{id1: data, id2: data2} {
+		out := filepath.Join(dir, "out", k)
+		err = r.Restore(k, out)
+		if err != nil {
+			t.Fatal("Error Restore:", err)
+		}
+		b, _ := ioutil.ReadFile(filepath.Join(out, "data", "a.bin"))
+		if !bytes.Equal(b, v) {
+			t.Fatal("Error Restore content:", k)
+		}
+		fi, err := os.Stat(filepath.Join(out, "data", "sub", "b.txt"))
+		if err != nil || fi.Mode().Perm() != 0600 {
+			t.Fatal("Error Restore mode:", err)
+		}
+	}
+	// forget and prune
+	err = r.Forget(id1)
+	if err != nil {
+		t.Fatal("Error Forget:", err)
+	}
+	report, err := r.Prune()
+	if err != nil || report.Removed == 0 || countChunks(dest) != report.Chunks {
+		t.Fatal("Error Prune:", report, err)
+	}
+	err = r.Restore(id2, filepath.Join(dir, "out2"))
+	if err != nil {
+		t.Fatal("Error Restore after Prune:", err)
+	}
+}
+
+// TestBackupCollision function
+func TestBackupCollision(t *testing.T) {
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "repo")
+	password := []byte("Satellite-266414")
+	err := Init(dest, password, RepoOption{ChunkMin: 1024, ChunkBits: 12, ChunkMax: 16384, ScryptN: 1024})
+	if err != nil {
+		t.Fatal("Error Init:", err)
+	}
+	r, _ := Open(dest, password)
+	a := filepath.Join(dir, "a", "data")
+	b := filepath.Join(dir, "b", "data")
+	_ = os.MkdirAll(a, 0755)
+	_ = os.MkdirAll(b, 0755)
+	_ = ioutil.WriteFile(filepath.Join(a, "x.txt"), []byte("alpha"), 0644)
+	_ = ioutil.WriteFile(filepath.Join(b, "x.txt"), []byte("bravo"), 0644)
+	_, err = r.Backup([]string{a, b})
+	if err == nil {
+		t.Fatal("Error Backup: sources with the same base name should fail")
+	}
+	if n := countChunks(dest); n != 0 {
+		t.Fatal("Error Backup: collided sources should write nothing:", n)
+	}
+	ids, _ := ioutil.ReadDir(filepath.Join(dest, "snapshots"))
+	if len(ids) != 0 {
+		t.Fatal("Error Backup: collided sources should write no snapshot")
+	}
+}
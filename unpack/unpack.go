@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	. "qora/global"
 )
 
 // Unpack function
@@ -17,6 +18,12 @@ import (
 // algorithm now support 'AES', 'DES', '3DES', 'RSA' and 'BASE64', but you don't need to care it~
 // return err indicate the success or failure function execute
 func Unpack(src string, dest string) (err error) {
+	// damaged package is repaired into a temporary package when parity file exists
+	src, clean, err := openParity(src)
+	if err != nil {
+		return err
+	}
+	defer clean()
 	// first, open the file
 	file, err := os.Open(src)
 	if err != nil {
@@ -37,6 +44,19 @@ func Unpack(src string, dest string) (err error) {
 		log.Println("Error close file:", err)
 		return err
 	}
+	// volume part is joined into a temporary package first
+	if IsVolume(buf) {
+		path, clean, err := openVolume(src)
+		if err != nil {
+			return err
+		}
+		defer clean()
+		return Unpack(path, dest)
+	}
+	// v2 package is handled by the generic functions
+	if buf[PackVersionOffset] == PackVersion2 {
+		return UnpackWithOption(src, dest, UnpackOption{})
+	}
 	// fourth, find the algorithm
 	buf = buf[48:56]
 	index := bytes.IndexByte(buf, 0)
@@ -64,6 +84,12 @@ func Unpack(src string, dest string) (err error) {
 // you can adjust confine file and confine buffer when you need change
 // other function is same as 'Unpack'
 func UnpackConfine(src string, dest string) (err error) {
+	// damaged package is repaired into a temporary package when parity file exists
+	src, clean, err := openParity(src)
+	if err != nil {
+		return err
+	}
+	defer clean()
 	// first, open the file
 	file, err := os.Open(src)
 	if err != nil {
@@ -84,6 +110,19 @@ func UnpackConfine(src string, dest string) (err error) {
 		log.Println("Error close file:", err)
 		return err
 	}
+	// volume part is joined into a temporary package first
+	if IsVolume(buf) {
+		path, clean, err := openVolume(src)
+		if err != nil {
+			return err
+		}
+		defer clean()
+		return UnpackConfine(path, dest)
+	}
+	// v2 package is handled by the generic functions
+	if buf[PackVersionOffset] == PackVersion2 {
+		return UnpackWithOption(src, dest, UnpackOption{})
+	}
 	// fourth, find the algorithm
 	buf = buf[48:56]
 	index := bytes.IndexByte(buf, 0)
@@ -114,6 +153,12 @@ func UnpackConfine(src string, dest string) (err error) {
 // you should fill target segment with 'capture.png'
 // return err indicate the success or failure function execute
 func UnpackToFile(src string, target string, dest string) (err error) {
+	// damaged package is repaired into a temporary package when parity file exists
+	src, clean, err := openParity(src)
+	if err != nil {
+		return err
+	}
+	defer clean()
 	// first, open the file
 	file, err := os.Open(src)
 	if err != nil {
@@ -134,6 +179,19 @@ func UnpackToFile(src string, target string, dest string) (err error) {
 		log.Println("Error close file:", err)
 		return err
 	}
+	// volume part is joined into a temporary package first
+	if IsVolume(buf) {
+		path, clean, err := openVolume(src)
+		if err != nil {
+			return err
+		}
+		defer clean()
+		return UnpackToFile(path, target, dest)
+	}
+	// v2 package is handled by the generic functions
+	if buf[PackVersionOffset] == PackVersion2 {
+		return UnpackToFileWithOption(src, target, dest, UnpackOption{})
+	}
 	// fourth, find the algorithm
 	buf = buf[48:56]
 	index := bytes.IndexByte(buf, 0)
@@ -161,6 +219,12 @@ func UnpackToFile(src string, target string, dest string) (err error) {
 // you can adjust confine file and confine buffer when you need change
 // other function is same as 'UnpackToFile'
 func UnpackToFileConfine(src string, target string, dest string) (err error) {
+	// damaged package is repaired into a temporary package when parity file exists
+	src, clean, err := openParity(src)
+	if err != nil {
+		return err
+	}
+	defer clean()
 	// first, open the file
 	file, err := os.Open(src)
 	if err != nil {
@@ -181,6 +245,19 @@ func UnpackToFileConfine(src string, target string, dest string) (err error) {
 		log.Println("Error close file:", err)
 		return err
 	}
+	// volume part is joined into a temporary package first
+	if IsVolume(buf) {
+		path, clean, err := openVolume(src)
+		if err != nil {
+			return err
+		}
+		defer clean()
+		return UnpackToFileConfine(path, target, dest)
+	}
+	// v2 package is handled by the generic functions
+	if buf[PackVersionOffset] == PackVersion2 {
+		return UnpackToFileWithOption(src, target, dest, UnpackOption{})
+	}
 	// fourth, find the algorithm
 	buf = buf[48:56]
 	index := bytes.IndexByte(buf, 0)
@@ -211,6 +288,12 @@ func UnpackToFileConfine(src string, target string, dest string) (err error) {
 // you should fill target segment with 'capture.png'
 // return err indicate the success or failure function execute
 func UnpackToMemory(src string, target string, dest *[]byte) (err error) {
+	// damaged package is repaired into a temporary package when parity file exists
+	src, clean, err := openParity(src)
+	if err != nil {
+		return err
+	}
+	defer clean()
 	// first, open the file
 	file, err := os.Open(src)
 	if err != nil {
@@ -231,6 +314,19 @@ func UnpackToMemory(src string, target string, dest *[]byte) (err error) {
 		log.Println("Error close file:", err)
 		return err
 	}
+	// volume part is joined into a temporary package first
+	if IsVolume(buf) {
+		path, clean, err := openVolume(src)
+		if err != nil {
+			return err
+		}
+		defer clean()
+		return UnpackToMemory(path, target, dest)
+	}
+	// v2 package is handled by the generic functions
+	if buf[PackVersionOffset] == PackVersion2 {
+		return UnpackToMemoryWithOption(src, target, dest, UnpackOption{})
+	}
 	// fourth, find the algorithm
 	buf = buf[48:56]
 	index := bytes.IndexByte(buf, 0)
@@ -258,9 +354,16 @@ func UnpackToMemory(src string, target string, dest *[]byte) (err error) {
 // src file support both absolute and relative paths, like 'C:\\file.pak' or '../test/data/file.pak'
 // dest string slice will return the files name in package.
 // sz int slice will return the file number in package.
-// algorithm will return which algorithm used by encrypt package.
+// algorithm will return which algorithm used by encrypt package, it is 'mixed' for mixed package,
+// ExtractEntryInfo replaces this function when the algorithm of every entry is needed.
 // return err indicate the success or failure function execute
 func ExtractInfo(src string, dest *[]string, sz *[]int, algorithm *string) (err error) {
+	// damaged package is repaired into a temporary package when parity file exists
+	src, clean, err := openParity(src)
+	if err != nil {
+		return err
+	}
+	defer clean()
 	// first, open the file
 	file, err := os.Open(src)
 	if err != nil {
@@ -281,6 +384,19 @@ func ExtractInfo(src string, dest *[]string, sz *[]int, algorithm *string) (err
 		log.Println("Error close file:", err)
 		return err
 	}
+	// volume part is joined into a temporary package first
+	if IsVolume(buf) {
+		path, clean, err := openVolume(src)
+		if err != nil {
+			return err
+		}
+		defer clean()
+		return ExtractInfo(path, dest, sz, algorithm)
+	}
+	// v2 package is handled by the generic functions
+	if buf[PackVersionOffset] == PackVersion2 {
+		return ExtractInfoWithOption(src, dest, sz, algorithm, UnpackOption{})
+	}
 	// fourth, find the algorithm
 	buf = buf[48:56]
 	index := bytes.IndexByte(buf, 0)
@@ -315,6 +431,12 @@ func ExtractInfo(src string, dest *[]string, sz *[]int, algorithm *string) (err
 // work return the total work value of unpack process.
 // return err indicate the success or failure function execute
 func WorkCalculate(src string, algorithm *string, work *int64) (err error) {
+	// damaged package is repaired into a temporary package when parity file exists
+	src, clean, err := openParity(src)
+	if err != nil {
+		return err
+	}
+	defer clean()
 	// first, open the file
 	file, err := os.Open(src)
 	if err != nil {
@@ -335,6 +457,19 @@ func WorkCalculate(src string, algorithm *string, work *int64) (err error) {
 		log.Println("Error close file:", err)
 		return err
 	}
+	// volume part is joined into a temporary package first
+	if IsVolume(buf) {
+		path, clean, err := openVolume(src)
+		if err != nil {
+			return err
+		}
+		defer clean()
+		return WorkCalculate(path, algorithm, work)
+	}
+	// v2 package is handled by the generic functions
+	if buf[PackVersionOffset] == PackVersion2 {
+		return WorkCalculateWithOption(src, algorithm, work, UnpackOption{})
+	}
 	// fourth, find the algorithm
 	buf = buf[48:56]
 	index := bytes.IndexByte(buf, 0)
@@ -361,3 +496,12 @@ func WorkCalculate(src string, algorithm *string, work *int64) (err error) {
 	}
 	return err
 }
+
+// UnpackAllToMemory function
+// unpack every file in package to memory instead of file, it is the symmetry of pack.PackEntries.
+// src file support both absolute and relative paths, like 'C:\\file.pak' or '../test/data/file.pak'
+// dest map will return the decrypt data with the file name in package as key.
+// return err indicate the success or failure function execute
+func UnpackAllToMemory(src string) (dest map[string][]byte, err error) {
+	return UnpackAllToMemoryWithOption(src, UnpackOption{})
+}
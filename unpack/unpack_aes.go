@@ -54,9 +54,8 @@ func UnpackAES(src string, dest string) (err error) {
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -65,18 +64,12 @@ func UnpackAES(src string, dest string) (err error) {
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("AES"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -171,9 +164,8 @@ func UnpackAESConfine(src string, dest string) (err error) {
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -182,18 +174,12 @@ func UnpackAESConfine(src string, dest string) (err error) {
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("AES"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -287,9 +273,8 @@ func UnpackAESToFile(src string, target string, dest string) (err error) {
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -298,18 +283,12 @@ func UnpackAESToFile(src string, target string, dest string) (err error) {
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("AES"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -407,9 +386,8 @@ func UnpackAESToFileConfine(src string, target string, dest string) (err error)
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -418,18 +396,12 @@ func UnpackAESToFileConfine(src string, target string, dest string) (err error)
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("AES"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -527,9 +499,8 @@ func UnpackAESToMemory(src string, target string, dest *[]byte) (err error) {
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -538,18 +509,12 @@ func UnpackAESToMemory(src string, target string, dest *[]byte) (err error) {
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("AES"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -644,9 +609,8 @@ func UnpackAESExtractInfo(src string, dest *[]string, sz *[]int) (err error) {
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -655,18 +619,12 @@ func UnpackAESExtractInfo(src string, dest *[]string, sz *[]int) (err error) {
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("AES"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -754,9 +712,8 @@ func UnpackAESWorkCalculate(src string) (work int64, err error) {
 		log.Println("Error read header name:", err)
 		return work, err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return work, err
 	}
@@ -765,18 +722,12 @@ func UnpackAESWorkCalculate(src string) (work int64, err error) {
 		log.Println("Error read header author:", err)
 		return work, err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return work, err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return work, err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("AES"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
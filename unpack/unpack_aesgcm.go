@@ -0,0 +1,33 @@
+package unpack
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"log"
+	. "qora/global"
+)
+
+// AESGCMDecrypt function
+// This function is mainly used for decrypt entry which is packed by aes-gcm.
+// src buffer is the cipher text followed by GCMTagSize bytes tag, the nonce is zero
+// key buffer input aes-256 key of entry
+// aad buffer is the entry name field which is authenticated with data
+// return err indicate the success or failure function execute, wrong key or broken data both fail here
+func AESGCMDecrypt(src, key, aad []byte) (dest []byte, err error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		log.Println("Error key length:", err)
+		return dest, err
+	}
+	gcm, err := cipher.NewGCMWithTagSize(block, GCMTagSize)
+	if err != nil {
+		log.Println("Error create gcm:", err)
+		return dest, err
+	}
+	dest, err = gcm.Open(nil, make([]byte, gcm.NonceSize()), src, aad)
+	if err != nil {
+		err = errors.New("Package entry authentication failed.")
+	}
+	return dest, err
+}
@@ -54,9 +54,8 @@ func UnpackBase64(src string, dest string) (err error) {
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -65,18 +64,12 @@ func UnpackBase64(src string, dest string) (err error) {
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("BASE64"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -159,9 +152,8 @@ func UnpackBase64Confine(src string, dest string) (err error) {
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -170,18 +162,12 @@ func UnpackBase64Confine(src string, dest string) (err error) {
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("BASE64"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -262,9 +248,8 @@ func UnpackBase64ToFile(src string, target string, dest string) (err error) {
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -273,18 +258,12 @@ func UnpackBase64ToFile(src string, target string, dest string) (err error) {
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("BASE64"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -369,9 +348,8 @@ func UnpackBase64ToFileConfine(src string, target string, dest string) (err erro
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -380,18 +358,12 @@ func UnpackBase64ToFileConfine(src string, target string, dest string) (err erro
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("BASE64"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -477,9 +449,8 @@ func UnpackBase64ToMemory(src string, target string, dest *[]byte) (err error) {
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -488,18 +459,12 @@ func UnpackBase64ToMemory(src string, target string, dest *[]byte) (err error) {
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("BASE64"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -584,9 +549,8 @@ func UnpackBase64ExtractInfo(src string, dest *[]string, sz *[]int) (err error)
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -595,18 +559,12 @@ func UnpackBase64ExtractInfo(src string, dest *[]string, sz *[]int) (err error)
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("BASE64"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -683,9 +641,8 @@ func UnpackBase64WorkCalculate(src string) (work int64, err error) {
 		log.Println("Error read header name:", err)
 		return work, err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return work, err
 	}
@@ -694,18 +651,12 @@ func UnpackBase64WorkCalculate(src string) (work int64, err error) {
 		log.Println("Error read header author:", err)
 		return work, err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return work, err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return work, err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("BASE64"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -752,14 +703,14 @@ func UnpackBase64WorkCalculate(src string) (work int64, err error) {
 // It will called by function UnpackBase64ToMemory.
 func UnpackBase64OneToMemory(data []byte, dest *string) (err error) {
 	// first, split the data slice
-	ss, err := SplitByte(data, Base64BufferSize)
+	ss, err := SplitByte(data, Base64UnpackSize)
 	if err != nil {
 		log.Println("Error split bytes:", err)
 		return err
 	}
-	size := len(data) % Base64BufferSize
+	size := len(data) % Base64UnpackSize
 	if size != 0 {
-		last := len(data) / Base64BufferSize
+		last := len(data) / Base64UnpackSize
 		ss[last] = append(ss[last][:0], ss[last][:size]...)
 	}
 	// second, we can call Base64Decrypt function
@@ -813,14 +764,14 @@ func UnpackBase64One(data []byte, head TUnpackBase64One, path string) (err error
 	}
 	file := path + string(s)
 	// first, split the data slice
-	ss, err := SplitByte(data, Base64BufferSize)
+	ss, err := SplitByte(data, Base64UnpackSize)
 	if err != nil {
 		log.Println("Error split bytes:", err)
 		return err
 	}
-	size := len(data) % Base64BufferSize
+	size := len(data) % Base64UnpackSize
 	if size != 0 {
-		last := len(data) / Base64BufferSize
+		last := len(data) / Base64UnpackSize
 		ss[last] = append(ss[last][:0], ss[last][:size]...)
 	}
 	// second, we can call Base64Decrypt function
@@ -854,14 +805,14 @@ func UnpackBase64OneConfine(data []byte, head TUnpackBase64One, path string) (er
 	}
 	file := path + string(s)
 	// first, split the data slice
-	ss, err := SplitByte(data, Base64BufferSize)
+	ss, err := SplitByte(data, Base64UnpackSize)
 	if err != nil {
 		log.Println("Error split bytes:", err)
 		return err
 	}
-	size := len(data) % Base64BufferSize
+	size := len(data) % Base64UnpackSize
 	if size != 0 {
-		last := len(data) / Base64BufferSize
+		last := len(data) / Base64UnpackSize
 		ss[last] = append(ss[last][:0], ss[last][:size]...)
 	}
 	// second, we can call Base64Decrypt function
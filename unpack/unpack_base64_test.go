@@ -2,6 +2,7 @@ package unpack
 
 import (
 	"bytes"
+	"encoding/base64"
 	"io/ioutil"
 	. "qora/utils"
 	"sync"
@@ -119,6 +120,34 @@ func TestUnpackBase64OneToMemory(t *testing.T) {
 	}
 }
 
+// TestUnpackBase64OneToMemoryChunks function
+func TestUnpackBase64OneToMemoryChunks(t *testing.T) {
+	// every 128 bytes of plain data are encoded into 172 bytes with padding, so body is decoded by 172 bytes chunk
+	src := make([]byte, 300)
+	for k := range src {
+		src[k] = byte(k)
+	}
+	var body []byte
+	for i := 0; i < len(src); i += 128 {
+		end := i + 128
+		if end > len(src) {
+			end = len(src)
+		}
+		body = append(body, base64.StdEncoding.EncodeToString(src[i:end])...)
+	}
+	if len(body) != 172*2+60 {
+		t.Fatal("Error base64 body size:", len(body))
+	}
+	var dest string
+	err := UnpackBase64OneToMemory(body, &dest)
+	if err != nil {
+		t.Fatal("Error unpack crypt file:", err)
+	}
+	if dest != string(src) {
+		t.Fatal("Error unpack multi chunk content")
+	}
+}
+
 // TestUnpackBase64OneGo function
 func TestUnpackBase64OneGo(t *testing.T) {
 	var wg sync.WaitGroup
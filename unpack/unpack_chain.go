@@ -0,0 +1,171 @@
+package unpack
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// UnpackChain function
+// This function is mainly used for restore the chain of full package and its incremental packages.
+// src is the package list of chain in any order, the order is decided by base uuid in manifest
+// packages are applied from full package to the last incremental one, deleted files are removed,
+// then every file of the last manifest is checked by sha256, its permission and modify time are restored
+// manifest of unsealed package is not authenticated, so every entry, deleted and manifest file name is checked
+// before anything is written or removed, the name should be a plain file name without path
+// dest file also support both absolute and relative paths, like 'C:\\' or '../test/data/'
+// return err indicate the success or failure function execute
+func UnpackChain(src []string, dest string) (err error) {
+	return UnpackChainWithOption(src, dest, UnpackOption{})
+}
+
+// UnpackChainWithOption function
+// it is common with function UnpackChain, opt.TableKey is used to open the sealed packages of chain
+// return err indicate the success or failure function execute
+func UnpackChainWithOption(src []string, dest string, opt UnpackOption) (err error) {
+	// first, parse the packages and order the chain
+	chain, err := parseChain(src, opt)
+	if err != nil {
+		return err
+	}
+	for _, p := range chain {
+		names := append([]string{}, p.Manifest.Deleted...)
+		for _, v := range p.Entries {
+			names = append(names, v.Name)
+		}
+		for _, v := range p.Manifest.Files {
+			names = append(names, v.Name)
+		}
+		for _, v := range names {
+			err = checkChainName(v)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	// second, apply every package of chain
+	for _, p := range chain {
+		r, err := decryptEntries(p)
+		if err != nil {
+			return err
+		}
+		for _, v := range p.Manifest.Deleted {
+			err = os.Remove(dest + v)
+			if err != nil && !os.IsNotExist(err) {
+				log.Println("Error remove deleted file:", err)
+				return err
+			}
+		}
+		for k, v := range r {
+			err = ioutil.WriteFile(dest+k, v, 0644)
+			if err != nil {
+				log.Println("Error write to dest file:", err)
+				return err
+			}
+		}
+	}
+	// finally, check the tree with the last manifest
+	for _, v := range chain[len(chain)-1].Manifest.Files {
+		data, err := ioutil.ReadFile(dest + v.Name)
+		if err != nil {
+			log.Println("Error read restored file:", err)
+			return err
+		}
+		err = restoreFile(dest+v.Name, data, v)
+		if err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// parseChain function
+// it will parse every package and order them by base uuid, the full package is the first one
+// chain should have only one full package and no branch, every package should be reached from full package
+func parseChain(src []string, opt UnpackOption) (chain []*TUnpackPackage, err error) {
+	var full *TUnpackPackage
+	next := make(map[string]*TUnpackPackage, len(src))
+	for _, v := range src {
+		p, err := ParsePackageWithOption(v, opt)
+		if err != nil {
+			log.Println("Error parse package:", err)
+			return chain, err
+		}
+		if p.Manifest == nil || p.UUID == "" {
+			s := fmt.Sprintf("Package has no manifest: %v", v)
+			err = errors.New(s)
+			return chain, err
+		}
+		if p.Manifest.Base == "" {
+			if full != nil {
+				s := fmt.Sprintf("Package chain has more than one full package: %v", v)
+				err = errors.New(s)
+				return chain, err
+			}
+			full = p
+			continue
+		}
+		if _, ok := next[p.Manifest.Base]; ok {
+			s := fmt.Sprintf("Package chain is branched at base %v: %v", p.Manifest.Base, v)
+			err = errors.New(s)
+			return chain, err
+		}
+		next[p.Manifest.Base] = p
+	}
+	if full == nil {
+		err = errors.New("Package chain has no full package.")
+		return chain, err
+	}
+	for p := full; p != nil; p = next[p.UUID] {
+		chain = append(chain, p)
+		if len(chain) > len(src) {
+			break
+		}
+	}
+	if len(chain) != len(src) {
+		s := fmt.Sprintf("Package chain is broken, only %v of %v packages follow the full package.", len(chain), len(src))
+		err = errors.New(s)
+	}
+	return chain, err
+}
+
+// checkChainName function
+// return error when name is not a plain file name, like absolute path, '..' or name with path separator
+func checkChainName(name string) (err error) {
+	if name == "" || name == "." || name == ".." || filepath.IsAbs(name) || filepath.Base(name) != name || strings.ContainsAny(name, "/\\") {
+		s := fmt.Sprintf("Package chain file name is invalid: %q", name)
+		err = errors.New(s)
+	}
+	return err
+}
+
+// restoreFile function
+// it will check file data by sha256 of manifest, then restore permission and modify time
+func restoreFile(path string, data []byte, f TUnpackManifestFile) (err error) {
+	digest := sha256.Sum256(data)
+	if int64(len(data)) != f.Size || !bytes.Equal(digest[:], f.Digest) {
+		s := fmt.Sprintf("File digest mismatch with manifest: %v", f.Name)
+		err = errors.New(s)
+		return err
+	}
+	if f.Mode != 0 {
+		err = os.Chmod(path, f.Mode)
+		if err != nil {
+			log.Println("Error change file mode:", err)
+			return err
+		}
+	}
+	if !f.ModTime.IsZero() {
+		err = os.Chtimes(path, f.ModTime, f.ModTime)
+		if err != nil {
+			log.Println("Error change file time:", err)
+		}
+	}
+	return err
+}
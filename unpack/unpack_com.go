@@ -1,5 +1,10 @@
 package unpack
 
+import (
+	"os"
+	"time"
+)
+
 var Done int64
 
 // unpack aes
@@ -73,3 +78,148 @@ type TUnpackBase64One struct {
 	Name []byte // [32]byte/256bit
 	Size []byte // [4]byte/32bit
 }
+
+// unpack option
+type UnpackOption struct {
+	TableKey []byte // aes key which sealed the entry table, only needed by sealed package
+	// IgnoreLegacyName allows unpack legacy package which was renamed after pack
+	// legacy package records the file name in header, new package records a random uuid and never checks it
+	IgnoreLegacyName bool
+}
+
+// unpack v2 header extension, gob encoded behind the header
+type TUnpackExtend struct {
+	Sealed     bool   // entry table is sealed
+	Table      []byte // sealed entry table, nonce followed by aes-gcm cipher text
+	Meta       []byte // gob encoded metadata
+	MetaDigest []byte // digest of encoded metadata
+	MetaKeyed  bool   // metadata digest is hmac-sha256 with table key
+	Manifest   []byte // gob encoded manifest, sealed like entry table when the table is sealed
+}
+
+// unpack manifest
+type TUnpackManifest struct {
+	Base    string                // uuid of base package, empty for full package
+	Deleted []string              // files which are in base package but deleted now
+	Files   []TUnpackManifestFile // every file of the tree, including the unchanged files which are only in base package
+}
+
+type TUnpackManifestFile struct {
+	Name    string      // entry name
+	Size    int64       // file size
+	ModTime time.Time   // modify time
+	Mode    os.FileMode // permission bits
+	Digest  []byte      // sha256 of file content
+}
+
+// unpack metadata
+type TUnpackMeta struct {
+	Author  string            `json:"author" yaml:"author"`
+	Creator string            `json:"creator" yaml:"creator"`
+	Version string            `json:"version" yaml:"version"`
+	Created time.Time         `json:"created" yaml:"created"`
+	Comment string            `json:"comment" yaml:"comment"`
+	Labels  map[string]string `json:"labels" yaml:"labels"`
+	// Verified is true when metadata digest exists and matches, keyed digest is verified only with table key
+	Verified bool `json:"verified" yaml:"verified"`
+}
+
+// unpack volume part header
+type TUnpackVolume struct {
+	Magic  []byte // [4]byte/32bit
+	SetID  []byte // [16]byte/128bit
+	Seq    []byte // [4]byte/32bit, start from 1
+	Total  []byte // [4]byte/32bit
+	Size   []byte // [4]byte/32bit, payload size
+	Digest []byte // [32]byte/256bit, sha256 of payload
+	Name   []byte // [32]byte/256bit, file name of the whole package
+}
+
+// unpack parity file header
+type TUnpackParity struct {
+	Size       int      // package size
+	ShardSize  int      // shard size
+	StripeSize int      // max data shard number of one stripe
+	Percent    int      // parity shard number percent of data shard number in one stripe
+	Digests    [][]byte // sha256 of data shards followed by parity shards, the last data shard is zero padded
+}
+
+// repair report of package with parity file
+type TRepairReport struct {
+	Size      int   // package size
+	ShardSize int   // shard size
+	Shards    int   // data shard number
+	Repaired  []int // index of repaired data shards, shard i covers package bytes from i*ShardSize
+	Parity    []int // index of damaged parity shards, they are not used in repair
+}
+
+// salvage report of damaged package
+type TSalvageReport struct {
+	Path      string         // package path
+	Size      int            // scanned package size
+	Type      string         // package algorithm, guessed by scan when header is damaged
+	Number    int            // entry number in header, 0 if header is damaged or entry table is sealed
+	Recovered []string       // name of recovered entries
+	Lost      []TSalvageLost // lost entries and unreadable ranges
+	Notes     []string       // other problems found in scan
+}
+
+type TSalvageLost struct {
+	Name   string // entry name, empty if entry header is lost
+	Offset int    // offset of lost range in package
+	Size   int    // size of lost range
+	Reason string // why the range is lost
+}
+
+// unpack patch, gob encoded, compressed and sealed behind patch magic
+type TUnpackPatch struct {
+	Head    []byte              // header of new package
+	Extend  []byte              // raw header extension of new package with its size, empty for v1 package
+	Sealed  bool                // entry table of new package is sealed, entry headers are not written before bodies
+	Digest  []byte              // sha256 of new package
+	Entries []TUnpackPatchEntry // entries of new package in package order
+}
+
+type TUnpackPatchEntry struct {
+	Head   []byte // raw entry header of new package, the entry key is used to encrypt the plain data again
+	Base   string // name of old entry which delta is based on, empty when entry is new
+	Ctrl   []int  // bsdiff style control triples: diff length, extra length, next old offset
+	Diff   []byte // bytes added to old data
+	Extra  []byte // bytes inserted as is
+	Digest []byte // sha256 of plain data
+}
+
+// inspect report of package
+type TInspectReport struct {
+	Path       string          `json:"path" yaml:"path"`               // package path
+	Size       int             `json:"size" yaml:"size"`               // file size, it is the joined size for volume set
+	Volume     bool            `json:"volume" yaml:"volume"`           // package is joined from volume parts
+	Parity     bool            `json:"parity" yaml:"parity"`           // parity file exists beside the package
+	Version    int             `json:"version" yaml:"version"`         // package format version, 1 or 2
+	Name       string          `json:"name" yaml:"name"`               // package name in header
+	UUID       bool            `json:"uuid" yaml:"uuid"`               // package name is uuid, otherwise it is legacy file name
+	Author     string          `json:"author" yaml:"author"`           // package author in header
+	Type       string          `json:"type" yaml:"type"`               // package algorithm in header
+	Number     int             `json:"number" yaml:"number"`           // entry number in header, 0 for sealed package
+	ExtendSize int             `json:"extend_size" yaml:"extend_size"` // header extension size, 0 for v1 package
+	Sealed     bool            `json:"sealed" yaml:"sealed"`           // entry table is sealed
+	Opened     bool            `json:"opened" yaml:"opened"`           // sealed entry table is opened by table key
+	Meta       *TUnpackMeta    `json:"meta" yaml:"meta"`               // metadata of v2 package
+	Base       string          `json:"base" yaml:"base"`               // uuid of base package in manifest, empty for full package
+	Files      int             `json:"files" yaml:"files"`             // file number in manifest, -1 if manifest is not recorded or sealed without key
+	Entries    []TInspectEntry `json:"entries" yaml:"entries"`         // entries in package order
+	End        int             `json:"end" yaml:"end"`                 // offset behind the last parsed entry
+	Anomalies  []string        `json:"anomalies" yaml:"anomalies"`     // problems found in inspect
+}
+
+type TInspectEntry struct {
+	Name       string `json:"name" yaml:"name"`               // entry name
+	Type       string `json:"type" yaml:"type"`               // entry algorithm
+	HeadOffset int    `json:"head_offset" yaml:"head_offset"` // entry header offset in package, -1 if it is in sealed table
+	HeadSize   int    `json:"head_size" yaml:"head_size"`     // entry header size
+	BodyOffset int    `json:"body_offset" yaml:"body_offset"` // entry body offset in package
+	OriginSize int    `json:"origin_size" yaml:"origin_size"` // plain size
+	CryptSize  int    `json:"crypt_size" yaml:"crypt_size"`   // body size
+	KeySize    int    `json:"key_size" yaml:"key_size"`       // entry key size, 0 for base64
+	Key        bool   `json:"key" yaml:"key"`                 // entry key is present, it is false when the key is all zero
+}
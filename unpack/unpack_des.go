@@ -54,9 +54,8 @@ func Unpack3DES(src string, dest string) (err error) {
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -65,18 +64,12 @@ func Unpack3DES(src string, dest string) (err error) {
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("3DES"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -170,9 +163,8 @@ func UnpackDES(src string, dest string) (err error) {
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -181,18 +173,12 @@ func UnpackDES(src string, dest string) (err error) {
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("DES"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -287,9 +273,8 @@ func Unpack3DESConfine(src string, dest string) (err error) {
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -298,18 +283,12 @@ func Unpack3DESConfine(src string, dest string) (err error) {
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("3DES"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -405,9 +384,8 @@ func UnpackDESConfine(src string, dest string) (err error) {
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -416,18 +394,12 @@ func UnpackDESConfine(src string, dest string) (err error) {
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("DES"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -521,9 +493,8 @@ func Unpack3DESToFile(src string, target string, dest string) (err error) {
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -532,18 +503,12 @@ func Unpack3DESToFile(src string, target string, dest string) (err error) {
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("3DES"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -641,9 +606,8 @@ func Unpack3DESToFileConfine(src string, target string, dest string) (err error)
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -652,18 +616,12 @@ func Unpack3DESToFileConfine(src string, target string, dest string) (err error)
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("3DES"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -761,9 +719,8 @@ func Unpack3DESToMemory(src string, target string, dest *[]byte) (err error) {
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -772,18 +729,12 @@ func Unpack3DESToMemory(src string, target string, dest *[]byte) (err error) {
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("3DES"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -881,9 +832,8 @@ func UnpackDESToFile(src string, target string, dest string) (err error) {
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -892,18 +842,12 @@ func UnpackDESToFile(src string, target string, dest string) (err error) {
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("DES"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -1001,9 +945,8 @@ func UnpackDESToFileConfine(src string, target string, dest string) (err error)
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -1012,18 +955,12 @@ func UnpackDESToFileConfine(src string, target string, dest string) (err error)
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("DES"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -1121,9 +1058,8 @@ func UnpackDESToMemory(src string, target string, dest *[]byte) (err error) {
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -1132,18 +1068,12 @@ func UnpackDESToMemory(src string, target string, dest *[]byte) (err error) {
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("DES"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -1238,9 +1168,8 @@ func Unpack3DESExtractInfo(src string, dest *[]string, sz *[]int) (err error) {
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -1249,18 +1178,12 @@ func Unpack3DESExtractInfo(src string, dest *[]string, sz *[]int) (err error) {
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("3DES"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -1349,9 +1272,8 @@ func UnpackDESExtractInfo(src string, dest *[]string, sz *[]int) (err error) {
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -1360,18 +1282,12 @@ func UnpackDESExtractInfo(src string, dest *[]string, sz *[]int) (err error) {
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("DES"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -1460,9 +1376,8 @@ func Unpack3DESWorkCalculate(src string) (work int64, err error) {
 		log.Println("Error read header name:", err)
 		return work, err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return work, err
 	}
@@ -1471,18 +1386,12 @@ func Unpack3DESWorkCalculate(src string) (work int64, err error) {
 		log.Println("Error read header author:", err)
 		return work, err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return work, err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return work, err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("3DES"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -1571,9 +1480,8 @@ func UnpackDESWorkCalculate(src string) (work int64, err error) {
 		log.Println("Error read header name:", err)
 		return work, err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return work, err
 	}
@@ -1582,18 +1490,12 @@ func UnpackDESWorkCalculate(src string) (work int64, err error) {
 		log.Println("Error read header author:", err)
 		return work, err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return work, err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return work, err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("DES"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
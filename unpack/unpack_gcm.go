@@ -0,0 +1,37 @@
+package unpack
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"log"
+)
+
+// OpenBytes function
+// This function is mainly used for decrypt data which sealed by pack.SealBytes.
+// key buffer input aes key, length should be 16, 24 or 32
+// src buffer is nonce followed by aes-gcm cipher text
+// aad buffer is the additional data which authenticated when seal
+// return err indicate the success or failure function execute, wrong key or broken data both fail here
+func OpenBytes(key, src, aad []byte) (dest []byte, err error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		log.Println("Error key length:", err)
+		return dest, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		log.Println("Error create gcm:", err)
+		return dest, err
+	}
+	if len(src) < gcm.NonceSize()+gcm.Overhead() {
+		err = errors.New("Sealed data is truncated.")
+		return dest, err
+	}
+	size := gcm.NonceSize()
+	dest, err = gcm.Open(nil, src[:size], src[size:], aad)
+	if err != nil {
+		err = errors.New("Sealed data authentication failed.")
+	}
+	return dest, err
+}
@@ -0,0 +1,29 @@
+package unpack
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"testing"
+)
+
+// TestOpenBytes function
+func TestOpenBytes(t *testing.T) {
+	key := []byte("Satellite-266414")
+	block, _ := aes.NewCipher(key)
+	gcm, _ := cipher.NewGCM(block)
+	nonce := make([]byte, gcm.NonceSize())
+	src := gcm.Seal(nonce, nonce, []byte("hello,world!"), []byte("aad"))
+	r, err := OpenBytes(key, src, []byte("aad"))
+	if err != nil || string(r) != "hello,world!" {
+		t.Fatal("Error Open Bytes:", err)
+	}
+	_, err = OpenBytes(key, src, []byte("bad"))
+	if err == nil {
+		t.Fatal("Error Open Bytes: wrong additional data should fail")
+	}
+	src[len(src)-1] ^= 1
+	_, err = OpenBytes(key, src, []byte("aad"))
+	if err == nil {
+		t.Fatal("Error Open Bytes: broken data should fail")
+	}
+}
@@ -0,0 +1,281 @@
+package unpack
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"log"
+	"os"
+	. "qora/global"
+	. "qora/utils"
+	"strings"
+	"text/tabwriter"
+)
+
+// Inspect function
+// This function is mainly used for debug the package, like the broken package which is reported by customer.
+// src file support both absolute and relative paths, like 'C:\\file.pak' or '../test/data/file.pak'
+// every header field is decoded, entry offsets and sizes are validated against the file size,
+// the problems are recorded in r.Anomalies instead of failing, the inspect goes on as far as the package could be read
+// entry data is not decrypted, inspect does not repair the package even if parity file exists
+// format the report by FormatInspect
+// return r the inspect report, err indicate the success or failure function execute
+func Inspect(src string) (r *TInspectReport, err error) {
+	return InspectWithOption(src, UnpackOption{})
+}
+
+// InspectWithOption function
+// it is common with function Inspect, opt.TableKey is used to open the sealed entry table and manifest
+// return r the inspect report, err indicate the success or failure function execute
+func InspectWithOption(src string, opt UnpackOption) (r *TInspectReport, err error) {
+	// first, read file data
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		log.Println("Error read file:", err)
+		return r, err
+	}
+	r = &TInspectReport{Path: src, Size: len(data), Files: -1}
+	if _, err := os.Stat(src + ParitySuffix); err == nil {
+		r.Parity = true
+		if dest, err := repairPackage(src, data); err != nil || !bytes.Equal(dest, data) {
+			r.anomaly("package does not match its parity file, try unpack.Repair")
+		}
+	}
+	// volume part is joined with the rest parts
+	if IsVolume(data) {
+		r.Volume = true
+		data, _, err = joinVolume(src)
+		if err != nil {
+			r.anomaly("%v", err)
+			return r, nil
+		}
+		r.Size = len(data)
+	}
+	// second, decode the header
+	if len(data) < 60 {
+		r.anomaly("file size %v is shorter than package header 60", len(data))
+		return r, nil
+	}
+	p := &TUnpackPackage{Version: 1, Head: data[:60]}
+	r.Version = 1
+	r.Name = string(bytes.Trim(data[0:32], "\x00"))
+	r.UUID = IsUUID(data[0:32])
+	r.Author = string(bytes.Trim(data[32:48], "\x00"))
+	r.Type = string(bytes.SplitN(data[48:56], []byte{0}, 2)[0])
+	r.Number = BytesToInt(data[56:60])
+	if r.Name == "" {
+		r.anomaly("package name is empty")
+	}
+	if _, err := EntryKeySize(r.Type); err != nil && r.Type != MixedType {
+		r.anomaly("unknown package algorithm %q", r.Type)
+		return r, nil
+	}
+	offset := 60
+	// third, decode the header extension of v2 package
+	switch data[PackVersionOffset] {
+	case 0:
+	case PackVersion2:
+		r.Version = 2
+		if len(data) < offset+4 {
+			r.anomaly("header extension size is truncated at offset %v", offset)
+			return r, nil
+		}
+		r.ExtendSize = BytesToInt(data[offset : offset+4])
+		if len(data) < offset+4+r.ExtendSize {
+			r.anomaly("header extension size %v exceeds file size %v", r.ExtendSize, len(data))
+			return r, nil
+		}
+		err = GobDecode(bytes.NewBuffer(data[offset+4:offset+4+r.ExtendSize]), &p.Extend)
+		if err != nil {
+			r.anomaly("header extension could not be decoded: %v", err)
+			return r, nil
+		}
+		offset += 4 + r.ExtendSize
+		r.Sealed = p.Extend.Sealed
+		err = parseMeta(p, opt)
+		if err != nil {
+			r.anomaly("%v", err)
+		}
+		r.Meta = p.Meta
+		err = parseManifest(p, opt)
+		if err != nil {
+			r.anomaly("manifest could not be opened: %v", err)
+		}
+		if p.Manifest != nil {
+			r.Base, r.Files = p.Manifest.Base, len(p.Manifest.Files)
+		}
+	default:
+		r.anomaly("unknown format version byte 0x%02x at offset %v", data[PackVersionOffset], PackVersionOffset)
+		return r, nil
+	}
+	// fourth, read the entry headers
+	var table []byte
+	if r.Sealed {
+		if r.Number != 0 {
+			r.anomaly("sealed package records entry number %v in header", r.Number)
+		}
+		if opt.TableKey == nil {
+			r.End = offset
+			return r, nil
+		}
+		table, err = OpenBytes(opt.TableKey, p.Extend.Table, p.Head)
+		if err != nil {
+			r.anomaly("sealed entry table could not be opened, table key is wrong or header is tampered")
+			r.End = offset
+			return r, nil
+		}
+		r.Opened = true
+	}
+	names := make(map[string]bool)
+	for i, t := 0, 0; (r.Sealed && t < len(table)) || (!r.Sealed && i < r.Number); i++ {
+		v := TInspectEntry{HeadOffset: -1}
+		var e TUnpackEntry
+		var n int
+		if r.Sealed {
+			e, n, err = parseEntryHeadAt(table[t:], r.Type)
+			t += n
+		} else {
+			e, n, err = parseEntryHeadAt(data[offset:], r.Type)
+			v.HeadOffset = offset
+			offset += n
+		}
+		if err != nil {
+			r.anomaly("entry %v header is invalid: %v", i, err)
+			break
+		}
+		v.Name, v.Type, v.HeadSize, v.BodyOffset = e.Name, e.Type, n, offset
+		v.OriginSize, v.CryptSize, v.KeySize = e.OriginSize, e.CryptSize, len(e.Key)
+		v.Key = len(bytes.Trim(e.Key, "\x00")) > 0
+		r.Entries = append(r.Entries, v)
+		r.checkEntry(i, v, names)
+		if offset+e.CryptSize > len(data) {
+			r.anomaly("entry %v body of %v bytes at offset %v exceeds file size %v", i, e.CryptSize, offset, len(data))
+			offset = len(data)
+			break
+		}
+		offset += e.CryptSize
+	}
+	// finally, check the rest data and manifest
+	r.End = offset
+	if offset < len(data) {
+		r.anomaly("%v unexpected bytes behind the last entry at offset %v", len(data)-offset, offset)
+	}
+	if p.Manifest != nil {
+		files := make(map[string]bool, len(p.Manifest.Files))
+		for _, v := range p.Manifest.Files {
+			files[v.Name] = true
+		}
+		for _, v := range r.Entries {
+			if !files[v.Name] {
+				r.anomaly("entry %v is not recorded in manifest", v.Name)
+			}
+		}
+	}
+	return r, nil
+}
+
+// checkEntry function
+// it will check the sizes and key of one entry, names records the entry names which are checked
+func (r *TInspectReport) checkEntry(i int, v TInspectEntry, names map[string]bool) {
+	if v.Name == "" {
+		r.anomaly("entry %v name is empty", i)
+	}
+	if names[v.Name] {
+		r.anomaly("entry %v name %v is duplicated", i, v.Name)
+	}
+	names[v.Name] = true
+	if v.KeySize > 0 && !v.Key {
+		r.anomaly("entry %v key is all zero: %v", i, v.Name)
+	}
+	block := 0
+	switch v.Type {
+	case "AES":
+		block = 16
+	case "DES", "3DES":
+		block = 8
+	case "RSA":
+		block = RSAUnpackSize
+	case GCMType:
+		if v.CryptSize < v.OriginSize+GCMTagSize {
+			r.anomaly("entry %v crypt size %v is less than origin size %v with tag: %v", i, v.CryptSize, v.OriginSize, v.Name)
+		}
+	}
+	if block > 0 && v.CryptSize%block != 0 {
+		r.anomaly("entry %v crypt size %v is not multiple of block size %v: %v", i, v.CryptSize, block, v.Name)
+	}
+	if block > 0 && v.OriginSize > v.CryptSize {
+		r.anomaly("entry %v origin size %v is larger than crypt size %v: %v", i, v.OriginSize, v.CryptSize, v.Name)
+	}
+}
+
+// anomaly function
+// it will record one problem of package
+func (r *TInspectReport) anomaly(format string, a ...interface{}) {
+	r.Anomalies = append(r.Anomalies, fmt.Sprintf(format, a...))
+}
+
+// FormatInspect function
+// This function is mainly used for print the inspect report.
+// format now support 'text', 'json' and 'yaml', empty format is 'text' which is for human
+// return s the formatted report, err indicate the success or failure function execute
+func FormatInspect(r *TInspectReport, format string) (s string, err error) {
+	switch strings.ToLower(format) {
+	case "", "text":
+	case "json":
+		b, err := json.MarshalIndent(r, "", "  ")
+		return string(b) + "\n", err
+	case "yaml":
+		b, err := yaml.Marshal(r)
+		return string(b), err
+	default:
+		s := fmt.Sprintf("Undefined inspect format: %v", format)
+		err = errors.New(s)
+		return "", err
+	}
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Path:\t%v\n", r.Path)
+	fmt.Fprintf(w, "Size:\t%v bytes, volume %v, parity %v\n", r.Size, r.Volume, r.Parity)
+	fmt.Fprintf(w, "Version:\t%v\n", r.Version)
+	fmt.Fprintf(w, "Name:\t%v, uuid %v\n", r.Name, r.UUID)
+	fmt.Fprintf(w, "Author:\t%v\n", r.Author)
+	fmt.Fprintf(w, "Type:\t%v\n", r.Type)
+	fmt.Fprintf(w, "Number:\t%v\n", r.Number)
+	if r.Version == 2 {
+		fmt.Fprintf(w, "Extension:\t%v bytes, sealed %v, opened %v\n", r.ExtendSize, r.Sealed, r.Opened)
+	}
+	if r.Meta != nil {
+		fmt.Fprintf(w, "Meta:\tcreator %v %v, created %v, verified %v\n", r.Meta.Creator, r.Meta.Version, r.Meta.Created.Format("2006-01-02 15:04:05 MST"), r.Meta.Verified)
+		if r.Meta.Comment != "" {
+			fmt.Fprintf(w, "Comment:\t%v\n", r.Meta.Comment)
+		}
+	}
+	if r.Files >= 0 {
+		fmt.Fprintf(w, "Manifest:\t%v files, base %q\n", r.Files, r.Base)
+	}
+	fmt.Fprintf(w, "End:\t%v\n", r.End)
+	_ = w.Flush()
+	if len(r.Entries) > 0 {
+		fmt.Fprintf(&buf, "\nEntries:\n")
+		w = tabwriter.NewWriter(&buf, 0, 4, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintf(w, "#\tHead\tBody\tOrigin\tCrypt\tKey\tType\t Name\n")
+		for k, v := range r.Entries {
+			key := "-"
+			if v.KeySize > 0 {
+				key = fmt.Sprintf("%v/%v", v.KeySize, v.Key)
+			}
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t %v\n", k, v.HeadOffset, v.BodyOffset, v.OriginSize, v.CryptSize, key, v.Type, v.Name)
+		}
+		_ = w.Flush()
+	}
+	if len(r.Anomalies) > 0 {
+		fmt.Fprintf(&buf, "\nAnomalies:\n")
+		for _, v := range r.Anomalies {
+			fmt.Fprintf(&buf, "  - %v\n", v)
+		}
+	}
+	return buf.String(), err
+}
@@ -0,0 +1,65 @@
+package unpack
+
+import (
+	"encoding/json"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestInspect function
+func TestInspect(t *testing.T) {
+	for _, src := range []string{"../test/data/unpack/file_aes.txt", "../test/data/unpack/file_rsa.txt", "../test/data/unpack/file_base64.txt"} {
+		data, _ := ioutil.ReadFile(src)
+		r, err := Inspect(src)
+		if err != nil || len(r.Anomalies) != 0 || r.Version != 1 || len(r.Entries) != r.Number || r.End != len(data) {
+			t.Fatal("Error Inspect:", src, r, err)
+		}
+		for _, format := range []string{"text", "json", "yaml"} {
+			s, err := FormatInspect(r, format)
+			if err != nil || !strings.Contains(s, r.Entries[0].Name) {
+				t.Fatal("Error Format Inspect:", format, err)
+			}
+			var rr TInspectReport
+			if format == "json" {
+				err = json.Unmarshal([]byte(s), &rr)
+			} else if format == "yaml" {
+				err = yaml.Unmarshal([]byte(s), &rr)
+			}
+			if format != "text" && (err != nil || len(rr.Entries) != len(r.Entries)) {
+				t.Fatal("Error Format Inspect decode:", format, err)
+			}
+		}
+		// zero key of the first entry, cut the last entry and append garbage
+		dir := t.TempDir()
+		path := filepath.Join(dir, "file_damage.pak")
+		damage := append([]byte{}, data...)
+		if r.Entries[0].KeySize > 0 {
+			copy(damage[r.Entries[0].HeadOffset+32:], make([]byte, r.Entries[0].KeySize))
+		}
+		_ = ioutil.WriteFile(path, append(damage, "garbage"...), 0644)
+		r, err = Inspect(path)
+		if err != nil || len(r.Anomalies) == 0 || !strings.Contains(r.Anomalies[len(r.Anomalies)-1], "7 unexpected bytes") {
+			t.Fatal("Error Inspect garbage:", src, r.Anomalies, err)
+		}
+		if r.Entries[0].KeySize > 0 && (r.Entries[0].Key || !strings.Contains(r.Anomalies[0], "key is all zero")) {
+			t.Fatal("Error Inspect zero key:", src, r.Anomalies)
+		}
+		_ = ioutil.WriteFile(path, damage[:len(damage)-10], 0644)
+		r, err = Inspect(path)
+		if err != nil || len(r.Entries) != r.Number || !strings.Contains(r.Anomalies[len(r.Anomalies)-1], "exceeds file size") {
+			t.Fatal("Error Inspect truncated:", src, r.Anomalies, err)
+		}
+		_ = ioutil.WriteFile(path, damage[:40], 0644)
+		r, err = Inspect(path)
+		if err != nil || len(r.Anomalies) != 1 {
+			t.Fatal("Error Inspect header:", src, r.Anomalies, err)
+		}
+	}
+	_, err := FormatInspect(&TInspectReport{}, "xml")
+	if err == nil {
+		t.Fatal("Error Format Inspect: undefined format should fail")
+	}
+}
@@ -0,0 +1,235 @@
+package unpack
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// UnpackWithOption function
+// This function is mainly used for unpack v2 package, v1 package is also accepted.
+// src file support both absolute and relative paths, like 'C:\\file.pak' or '../test/data/file.pak'
+// dest file also support both absolute and relative paths, like 'C:\\' or '../test/data/'
+// opt.TableKey should be set when the entry table of package is sealed
+// files recorded in manifest are checked by sha256, their permission and modify time are restored as well
+// return err indicate the success or failure function execute
+func UnpackWithOption(src string, dest string, opt UnpackOption) (err error) {
+	// clear global variable
+	atomic.StoreInt64(&Done, 0)
+	p, err := ParsePackageWithOption(src, opt)
+	if err != nil {
+		log.Println("Error parse package:", err)
+		return err
+	}
+	r, err := decryptEntries(p)
+	if err != nil {
+		return err
+	}
+	files := make(map[string]TUnpackManifestFile)
+	if p.Manifest != nil {
+		for _, v := range p.Manifest.Files {
+			files[v.Name] = v
+		}
+	}
+	for k, v := range r {
+		err = ioutil.WriteFile(dest+k, v, 0644)
+		if err != nil {
+			log.Println("Error write to dest file:", err)
+			return err
+		}
+		if f, ok := files[k]; ok {
+			err = restoreFile(dest+k, v, f)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return err
+}
+
+// UnpackToFileWithOption function
+// This function is mainly used for unpack one target file of v2 package to file.
+// target string is the file which you want to decrypt from package, for instance, 'capture.png'
+// return err indicate the success or failure function execute
+func UnpackToFileWithOption(src string, target string, dest string, opt UnpackOption) (err error) {
+	var r []byte
+	err = UnpackToMemoryWithOption(src, target, &r, opt)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(dest+target, r, 0644)
+	if err != nil {
+		log.Println("Error write to dest file:", err)
+	}
+	return err
+}
+
+// UnpackToMemoryWithOption function
+// This function is mainly used for unpack one target file of v2 package to memory.
+// dest is a slice which used to receive decrypt data. You can send '[]byte' slice address here.
+// return err indicate the success or failure function execute
+func UnpackToMemoryWithOption(src string, target string, dest *[]byte, opt UnpackOption) (err error) {
+	// clear global variable
+	atomic.StoreInt64(&Done, 0)
+	p, err := ParsePackageWithOption(src, opt)
+	if err != nil {
+		log.Println("Error parse package:", err)
+		return err
+	}
+	for _, v := range p.Entries {
+		if v.Name == target {
+			*dest, err = DecryptEntry(v)
+			return err
+		}
+	}
+	s := fmt.Sprintf("Package entry not found: %v", target)
+	err = errors.New(s)
+	return err
+}
+
+// UnpackAllToMemoryWithOption function
+// This function is mainly used for unpack every file of v2 package to memory.
+// dest map will return the decrypt data with the file name in package as key.
+// return err indicate the success or failure function execute
+func UnpackAllToMemoryWithOption(src string, opt UnpackOption) (dest map[string][]byte, err error) {
+	// start multi-cpu
+	core := runtime.NumCPU()
+	runtime.GOMAXPROCS(core)
+	// clear global variable
+	atomic.StoreInt64(&Done, 0)
+	// first, parse the package
+	p, err := ParsePackageWithOption(src, opt)
+	if err != nil {
+		log.Println("Error parse package:", err)
+		return dest, err
+	}
+	// second, decrypt every entry
+	return decryptEntries(p)
+}
+
+// decryptEntries function
+// it will decrypt every entry of parsed package through goroutine
+// return the decrypt data with the file name in package as key
+func decryptEntries(p *TUnpackPackage) (dest map[string][]byte, err error) {
+	// first, decrypt every entry through goroutine
+	wg := &sync.WaitGroup{}
+	r := make([][]byte, len(p.Entries))
+	e := make([]error, len(p.Entries))
+	for k, v := range p.Entries {
+		wg.Add(1)
+		go func(k int, v TUnpackEntry) {
+			defer wg.Done()
+			r[k], e[k] = DecryptEntry(v)
+		}(k, v)
+	}
+	wg.Wait()
+	// second, fill the map
+	dest = make(map[string][]byte, len(p.Entries))
+	for k, v := range p.Entries {
+		if e[k] != nil {
+			return nil, e[k]
+		}
+		dest[v.Name] = r[k]
+	}
+	return dest, err
+}
+
+// ExtractInfoWithOption function
+// This function is mainly used for check verbose information of v2 package.
+// dest string slice will return the files name in package.
+// sz int slice will return the file size in package.
+// algorithm will return which algorithm used by encrypt package, it is 'mixed' when entries have their own algorithm,
+// see ExtractEntryInfo for algorithm of every entry.
+// return err indicate the success or failure function execute
+func ExtractInfoWithOption(src string, dest *[]string, sz *[]int, algorithm *string, opt UnpackOption) (err error) {
+	p, err := ParsePackageWithOption(src, opt)
+	if err != nil {
+		log.Println("Error parse package:", err)
+		return err
+	}
+	for _, v := range p.Entries {
+		*dest = append(*dest, v.Name)
+		*sz = append(*sz, v.OriginSize)
+	}
+	*algorithm = strings.ToLower(p.Type)
+	return err
+}
+
+// ExtractEntryInfo function
+// This function is mainly used for check verbose information of every entry, entries of mixed package have their own algorithm.
+// dest string slice will return the files name in package.
+// sz int slice will return the file size in package.
+// algorithm string slice will return the algorithm of every entry.
+// return err indicate the success or failure function execute
+func ExtractEntryInfo(src string, dest *[]string, sz *[]int, algorithm *[]string) (err error) {
+	return ExtractEntryInfoWithOption(src, dest, sz, algorithm, UnpackOption{})
+}
+
+// ExtractEntryInfoWithOption function
+// it is common with function ExtractEntryInfo, opt.TableKey is used to open the sealed entry table
+// return err indicate the success or failure function execute
+func ExtractEntryInfoWithOption(src string, dest *[]string, sz *[]int, algorithm *[]string, opt UnpackOption) (err error) {
+	p, err := ParsePackageWithOption(src, opt)
+	if err != nil {
+		log.Println("Error parse package:", err)
+		return err
+	}
+	for _, v := range p.Entries {
+		*dest = append(*dest, v.Name)
+		*sz = append(*sz, v.OriginSize)
+		*algorithm = append(*algorithm, strings.ToLower(v.Type))
+	}
+	return err
+}
+
+// ExtractMeta function
+// This function is mainly used for check metadata of package, like author, create time, comment and labels.
+// the metadata is kept plain in header, it could be read without table key even if entry table is sealed
+// v1 package does not record metadata, only the author in header is returned
+// return meta the package metadata, err indicate the success or failure function execute
+func ExtractMeta(src string) (meta *TUnpackMeta, err error) {
+	return ExtractMetaWithOption(src, UnpackOption{})
+}
+
+// ExtractMetaWithOption function
+// it is common with function ExtractMeta, opt.TableKey is used to verify the keyed metadata digest
+// meta.Verified tells whether the metadata digest is verified
+// return meta the package metadata, err indicate the success or failure function execute
+func ExtractMetaWithOption(src string, opt UnpackOption) (meta *TUnpackMeta, err error) {
+	p, err := ParsePackageWithOption(src, opt)
+	if err == ErrSealedPackage {
+		err = nil
+	}
+	if err != nil {
+		log.Println("Error parse package:", err)
+		return meta, err
+	}
+	if p.Meta == nil {
+		return &TUnpackMeta{Author: p.Author}, err
+	}
+	return p.Meta, err
+}
+
+// WorkCalculateWithOption function
+// This function is mainly used for calculate the total work of unpack v2 package.
+// algorithm return the algorithm type which used in unpack
+// work return the total crypt size of entries.
+// return err indicate the success or failure function execute
+func WorkCalculateWithOption(src string, algorithm *string, work *int64, opt UnpackOption) (err error) {
+	p, err := ParsePackageWithOption(src, opt)
+	if err != nil {
+		log.Println("Error parse package:", err)
+		return err
+	}
+	*work = 0
+	for _, v := range p.Entries {
+		*work += int64(v.CryptSize)
+	}
+	*algorithm = p.Type
+	return err
+}
@@ -0,0 +1,479 @@
+package unpack
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	. "qora/global"
+	. "qora/utils"
+	"time"
+)
+
+// ErrSealedPackage is returned when the entry table is sealed but no table key is given
+var ErrSealedPackage = errors.New("Package entry table is sealed, table key is needed.")
+
+// unpack package
+type TUnpackPackage struct {
+	Name     string           // package name in header, it is the uuid for new package
+	UUID     string           // package uuid, empty for legacy package which records file name
+	Author   string           // package author in header
+	Type     string           // package algorithm
+	Number   int              // entry number
+	Version  int              // package format version, 1 or 2
+	Head     []byte           // raw header bytes
+	Extend   TUnpackExtend    // header extension of v2 package
+	Meta     *TUnpackMeta     // metadata of v2 package, nil if not recorded
+	Manifest *TUnpackManifest // manifest of v2 package, nil if not recorded or sealed without key
+	Entries  []TUnpackEntry   // entries in package order
+}
+
+type TUnpackEntry struct {
+	Name       string // entry file name
+	Type       string // entry algorithm
+	Key        []byte // entry key(empty for base64)
+	OriginSize int    // plain size(crypt size for base64)
+	CryptSize  int    // body size
+	Head       []byte // raw entry header bytes
+	Body       []byte // raw entry body bytes
+}
+
+// EntryKeySize function
+// This function is mainly used for get the key field size of one entry header.
+// algorithm now support 'AES', 'DES', '3DES', 'RSA', 'BASE64' and 'AESGCM', you can send both up case and low case
+// return size of the key field and err indicate the success or failure function execute
+func EntryKeySize(algorithm string) (size int, err error) {
+	switch algorithm {
+	case "AES", "aes":
+		size = 16
+	case "DES", "des":
+		size = 8
+	case "3DES", "3des":
+		size = 24
+	case "RSA", "rsa":
+		size = 1024
+	case "BASE64", "base64":
+		size = 0
+	case "AESGCM", "aesgcm":
+		size = 32
+	default:
+		s := fmt.Sprint("Undefined unpack algorithm.")
+		err = errors.New(s)
+	}
+	return size, err
+}
+
+// EntryHeadSize function
+// This function is mainly used for get the size of one entry header, it is the entry layout shared by pack and unpack.
+// algorithm is the entry algorithm, entry of mixed package adds 8 bytes of entry algorithm behind entry name
+// header is name, key, origin size and crypt size, base64 header is name and size only
+// return n the header size and err indicate the success or failure function execute
+func EntryHeadSize(algorithm string, mixed bool) (n int, err error) {
+	size, err := EntryKeySize(algorithm)
+	if err != nil {
+		return n, err
+	}
+	n = 32 + size + 8
+	if algorithm == "BASE64" || algorithm == "base64" {
+		n = 32 + 4
+	}
+	if mixed {
+		n += 8
+	}
+	return n, err
+}
+
+// ParsePackage function
+// This function is mainly used for split package into header and raw entries without decrypt.
+// src file support both absolute and relative paths, like 'C:\\file.pak' or '../test/data/file.pak'
+// the package name in header is not checked here, caller should decide whether it matters
+// return p the parsed package, err indicate the success or failure function execute
+func ParsePackage(src string) (p *TUnpackPackage, err error) {
+	return ParsePackageWithOption(src, UnpackOption{})
+}
+
+// ParsePackageWithOption function
+// it is common with function ParsePackage, opt.TableKey is used to open the sealed entry table
+// src could also be any part of volume set, the whole set is joined before parse
+// legacy package which was renamed after pack is refused unless opt.IgnoreLegacyName is set
+// return p the parsed package, err indicate the success or failure function execute
+func ParsePackageWithOption(src string, opt UnpackOption) (p *TUnpackPackage, err error) {
+	// first, open the file
+	file, err := os.Open(src)
+	if err != nil {
+		log.Println("Error open file:", err)
+		return p, err
+	}
+	defer file.Close()
+	// second, read file data
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		log.Println("Error read file:", err)
+		return p, err
+	}
+	// damaged package is repaired when parity file exists
+	data, err = repairPackage(src, data)
+	if err != nil {
+		return p, err
+	}
+	// volume part is joined with the rest parts
+	if IsVolume(data) {
+		data, _, err = joinVolume(src)
+		if err != nil {
+			return p, err
+		}
+	} else if len(data) >= 32 {
+		// renamed legacy package is refused unless opt.IgnoreLegacyName is set
+		err = checkPackageName(data[0:32], filepath.Base(src), opt.IgnoreLegacyName)
+		if err != nil {
+			log.Println("Error check package name:", err)
+			return p, err
+		}
+	}
+	return ParsePackageBytesWithOption(data, opt)
+}
+
+// ParsePackageBytes function
+// This function is mainly used for split package data into header and raw entries without decrypt.
+// data is the whole package content
+// return p the parsed package, err indicate the success or failure function execute
+func ParsePackageBytes(data []byte) (p *TUnpackPackage, err error) {
+	return ParsePackageBytesWithOption(data, UnpackOption{})
+}
+
+// ParsePackageBytesWithOption function
+// it is common with function ParsePackageBytes, opt.TableKey is used to open the sealed entry table
+// return p the parsed package, err indicate the success or failure function execute
+func ParsePackageBytesWithOption(data []byte, opt UnpackOption) (p *TUnpackPackage, err error) {
+	// first, read the header
+	if len(data) < 60 {
+		err = errors.New("Package header is truncated.")
+		return p, err
+	}
+	p = &TUnpackPackage{Version: 1}
+	p.Head = data[:60]
+	p.Name = string(bytes.Trim(data[0:32], "\x00"))
+	if IsUUID(data[0:32]) {
+		p.UUID = p.Name
+	}
+	p.Author = string(bytes.Trim(data[32:48], "\x00"))
+	p.Type = string(bytes.SplitN(data[48:56], []byte{0}, 2)[0])
+	p.Number = BytesToInt(data[56:60])
+	if p.Type != MixedType {
+		_, err = EntryKeySize(p.Type)
+		if err != nil {
+			return p, err
+		}
+	}
+	offset := 60
+	// second, read the header extension of v2 package
+	if data[PackVersionOffset] == PackVersion2 {
+		p.Version = 2
+		if len(data) < offset+4 || len(data) < offset+4+BytesToInt(data[offset:offset+4]) {
+			err = errors.New("Package header extension is truncated.")
+			return p, err
+		}
+		n := BytesToInt(data[offset : offset+4])
+		err = GobDecode(bytes.NewBuffer(data[offset+4:offset+4+n]), &p.Extend)
+		if err != nil {
+			return p, err
+		}
+		offset += 4 + n
+		err = parseMeta(p, opt)
+		if err != nil {
+			return p, err
+		}
+		err = parseManifest(p, opt)
+		if err != nil {
+			return p, err
+		}
+	}
+	// third, open the sealed entry table
+	if p.Extend.Sealed {
+		if opt.TableKey == nil {
+			err = ErrSealedPackage
+			return p, err
+		}
+		table, err := OpenBytes(opt.TableKey, p.Extend.Table, p.Head)
+		if err != nil {
+			log.Println("Error open entry table:", err)
+			return p, err
+		}
+		for i := 0; i < len(table); {
+			e, n, err := parseEntryHeadAt(table[i:], p.Type)
+			if err != nil {
+				s := fmt.Sprintf("Package entry %v header is invalid: %v", len(p.Entries), err)
+				err = errors.New(s)
+				return p, err
+			}
+			i += n
+			if offset+e.CryptSize > len(data) {
+				s := fmt.Sprintf("Package entry %v body is truncated: %v", len(p.Entries), e.Name)
+				err = errors.New(s)
+				return p, err
+			}
+			e.Body = data[offset : offset+e.CryptSize]
+			offset += e.CryptSize
+			p.Entries = append(p.Entries, e)
+		}
+		p.Number = len(p.Entries)
+		return p, err
+	}
+	// fourth, read every one entry in packet
+	for i := 0; i < p.Number; i++ {
+		e, n, err := parseEntryHeadAt(data[offset:], p.Type)
+		if err != nil {
+			s := fmt.Sprintf("Package entry %v header is invalid: %v", i, err)
+			err = errors.New(s)
+			return p, err
+		}
+		offset += n
+		if offset+e.CryptSize > len(data) {
+			s := fmt.Sprintf("Package entry %v body is truncated: %v", i, e.Name)
+			err = errors.New(s)
+			return p, err
+		}
+		e.Body = data[offset : offset+e.CryptSize]
+		offset += e.CryptSize
+		p.Entries = append(p.Entries, e)
+	}
+	return p, err
+}
+
+// checkPackageName function
+// package with uuid in header is accepted whatever its file name is
+// legacy package should keep the file name in header, unless ignore is set by UnpackOption.IgnoreLegacyName
+func checkPackageName(head []byte, name string, ignore bool) (err error) {
+	if IsUUID(head) || ignore {
+		return err
+	}
+	s := make([]byte, 32)
+	BytesCopy(&s, []byte(name))
+	if !bytes.Equal(head, s) {
+		s := fmt.Sprintf("Package name mismatch: %v is packed as %v, unpack renamed package by UnpackWithOption with UnpackOption.IgnoreLegacyName", name, string(bytes.Trim(head, "\x00")))
+		err = errors.New(s)
+	}
+	return err
+}
+
+// metaLabel struct
+// metadata label as it is encoded in header extension
+type metaLabel struct {
+	K string // label name
+	V string // label value
+}
+
+// metaGob struct
+// metadata as it is encoded in header extension, labels are a name sorted list,
+// Labels map is only written by early package, it is still read
+type metaGob struct {
+	Author    string
+	Creator   string
+	Version   string
+	Created   time.Time
+	Comment   string
+	Labels    map[string]string
+	LabelList []metaLabel
+}
+
+// decodeMeta function
+// it decodes the metadata of header extension, label list is turned back into map
+// return err indicate the success or failure function execute
+func decodeMeta(data []byte) (meta *TUnpackMeta, err error) {
+	g := metaGob{}
+	err = GobDecode(bytes.NewBuffer(data), &g)
+	if err != nil {
+		return meta, err
+	}
+	meta = &TUnpackMeta{Author: g.Author, Creator: g.Creator, Version: g.Version, Created: g.Created, Comment: g.Comment, Labels: g.Labels}
+	for _, v := range g.LabelList {
+		if meta.Labels == nil {
+			meta.Labels = make(map[string]string, len(g.LabelList))
+		}
+		meta.Labels[v.K] = v.V
+	}
+	return meta, err
+}
+
+// parseMeta function
+// it will decode the metadata of header extension and verify its digest
+// keyed digest could only be verified with table key, metadata is left unverified without key
+func parseMeta(p *TUnpackPackage, opt UnpackOption) (err error) {
+	if len(p.Extend.Meta) == 0 {
+		return err
+	}
+	p.Meta, err = decodeMeta(p.Extend.Meta)
+	if err != nil {
+		return err
+	}
+	if len(p.Extend.MetaDigest) == 0 {
+		return err
+	}
+	var digest []byte
+	if p.Extend.MetaKeyed {
+		if opt.TableKey == nil {
+			return err
+		}
+		m := hmac.New(sha256.New, opt.TableKey)
+		m.Write(p.Extend.Meta)
+		digest = m.Sum(nil)
+	} else {
+		r := sha256.Sum256(p.Extend.Meta)
+		digest = r[:]
+	}
+	if !hmac.Equal(digest, p.Extend.MetaDigest) {
+		err = errors.New("Package metadata digest mismatch.")
+		return err
+	}
+	p.Meta.Verified = true
+	return err
+}
+
+// parseManifest function
+// it will decode the manifest of header extension, sealed manifest is opened by table key
+// sealed manifest is left nil without key, entry table of it could not be read either
+func parseManifest(p *TUnpackPackage, opt UnpackOption) (err error) {
+	data := p.Extend.Manifest
+	if len(data) == 0 || (p.Extend.Sealed && opt.TableKey == nil) {
+		return err
+	}
+	if p.Extend.Sealed {
+		data, err = OpenBytes(opt.TableKey, data, p.Head)
+		if err != nil {
+			log.Println("Error open manifest:", err)
+			return err
+		}
+	}
+	p.Manifest = &TUnpackManifest{}
+	err = GobDecode(bytes.NewBuffer(data), p.Manifest)
+	return err
+}
+
+// parseEntryHeadAt function
+// it will parse the entry header at the beginning of data with package algorithm tp,
+// entry of mixed package records its own algorithm in 8 bytes behind entry name, the raw header keeps it
+// return the entry and the header size
+func parseEntryHeadAt(data []byte, tp string) (e TUnpackEntry, n int, err error) {
+	t := tp
+	if tp == MixedType {
+		if len(data) < 40 {
+			err = errors.New("Entry header is truncated.")
+			return e, n, err
+		}
+		t = string(bytes.SplitN(data[32:40], []byte{0}, 2)[0])
+	}
+	size, err := EntryKeySize(t)
+	if err != nil {
+		return e, n, err
+	}
+	n, _ = EntryHeadSize(t, false)
+	if tp != MixedType {
+		if len(data) < n {
+			err = errors.New("Entry header is truncated.")
+			return e, n, err
+		}
+		return parseEntryHead(data[:n], t, size), n, err
+	}
+	n += 8
+	if len(data) < n {
+		err = errors.New("Entry header is truncated.")
+		return e, n, err
+	}
+	e = parseEntryHead(bytes.Join([][]byte{data[:32], data[40:n]}, []byte("")), t, size)
+	e.Head = data[:n]
+	return e, n, err
+}
+
+// parseEntryHead function
+// it will fill the entry fields from raw entry header
+func parseEntryHead(head []byte, tp string, size int) (e TUnpackEntry) {
+	e.Type = tp
+	e.Head = head
+	e.Name = string(bytes.Trim(head[0:32], "\x00"))
+	if tp == "BASE64" {
+		e.CryptSize = BytesToInt(head[32:36])
+		e.OriginSize = e.CryptSize
+	} else {
+		e.Key = head[32 : 32+size]
+		e.OriginSize = BytesToInt(head[32+size : 36+size])
+		e.CryptSize = BytesToInt(head[36+size : 40+size])
+	}
+	return e
+}
+
+// DecryptEntry function
+// This function is mainly used for decrypt one raw entry which returned by ParsePackage.
+// it will call the correspond one to memory function base on entry algorithm
+// return dest the plain data, err indicate the success or failure function execute
+func DecryptEntry(e TUnpackEntry) (dest []byte, err error) {
+	// broken body may let decrypt routine fail and the truncate panic, turn it into error
+	defer func() {
+		if r := recover(); r != nil {
+			s := fmt.Sprintf("Package entry is broken: %v", e.Name)
+			err = errors.New(s)
+			dest = nil
+		}
+	}()
+	switch e.Type {
+	case "AES", "aes":
+		hh := TUnpackAESOne{Name: e.Head[0:32], Key: e.Key, OriginSize: IntToBytes(e.OriginSize), CryptSize: IntToBytes(e.CryptSize)}
+		err = checkEntrySize(e, 16)
+		if err == nil {
+			err = UnpackAESOneToMemory(e.Body, hh, &dest)
+		}
+	case "DES", "des":
+		hh := TUnpackDESOne{Name: e.Head[0:32], Key: e.Key, OriginSize: IntToBytes(e.OriginSize), CryptSize: IntToBytes(e.CryptSize)}
+		err = checkEntrySize(e, 8)
+		if err == nil {
+			err = UnpackDESOneToMemory(e.Body, hh, &dest)
+		}
+	case "3DES", "3des":
+		hh := TUnpack3DESOne{Name: e.Head[0:32], Key: e.Key, OriginSize: IntToBytes(e.OriginSize), CryptSize: IntToBytes(e.CryptSize)}
+		err = checkEntrySize(e, 8)
+		if err == nil {
+			err = Unpack3DESOneToMemory(e.Body, hh, &dest)
+		}
+	case "RSA", "rsa":
+		hh := TUnpackRSAOne{Name: e.Head[0:32], Key: e.Key, OriginSize: IntToBytes(e.OriginSize), CryptSize: IntToBytes(e.CryptSize)}
+		err = checkEntrySize(e, RSAUnpackSize)
+		if err == nil {
+			err = UnpackRSAOneToMemory(e.Body, hh, &dest)
+		}
+	case "BASE64", "base64":
+		var s string
+		err = UnpackBase64OneToMemory(e.Body, &s)
+		dest = []byte(s)
+	case "AESGCM", "aesgcm":
+		if e.CryptSize < e.OriginSize+GCMTagSize {
+			s := fmt.Sprintf("Package entry size is invalid: %v", e.Name)
+			err = errors.New(s)
+			break
+		}
+		dest, err = AESGCMDecrypt(e.Body, e.Key, e.Head[0:32])
+		if err == nil {
+			dest = dest[:e.OriginSize]
+		}
+	default:
+		s := fmt.Sprint("Undefined unpack algorithm.")
+		err = errors.New(s)
+	}
+	if err != nil {
+		log.Println("Error decrypt entry:", err)
+	}
+	return dest, err
+}
+
+// checkEntrySize function
+// This function is mainly used for check entry sizes before decrypt, broken sizes would panic when truncate.
+func checkEntrySize(e TUnpackEntry, block int) (err error) {
+	if e.CryptSize%block != 0 || e.OriginSize > e.CryptSize {
+		s := fmt.Sprintf("Package entry size is invalid: %v", e.Name)
+		err = errors.New(s)
+	}
+	return err
+}
@@ -0,0 +1,99 @@
+package unpack
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// TestParsePackage function
+func TestParsePackage(t *testing.T) {
+	for _, src := range []string{"../test/data/unpack/file_aes.txt", "../test/data/unpack/file_des.txt", "../test/data/unpack/file_3des.txt", "../test/data/unpack/file_rsa.txt", "../test/data/unpack/file_base64.txt"} {
+		p, err := ParsePackage(src)
+		if err != nil {
+			t.Fatal("Error Parse Package:", src, err)
+		}
+		if len(p.Entries) != p.Number {
+			t.Fatal("Error Parse Package entry number:", src)
+		}
+		for _, v := range p.Entries {
+			r, err := DecryptEntry(v)
+			if err != nil {
+				t.Fatal("Error Decrypt Entry:", src, v.Name, err)
+			}
+			if v.Type != "BASE64" && len(r) != v.OriginSize {
+				t.Fatal("Error Decrypt Entry size:", src, v.Name)
+			}
+		}
+	}
+}
+
+// TestEntryHeadSize function
+func TestEntryHeadSize(t *testing.T) {
+	for _, v := range []struct {
+		algorithm string
+		mixed     bool
+		size      int
+	}{{"AES", false, 56}, {"3des", false, 64}, {"RSA", true, 1072}, {"BASE64", false, 36}, {"BASE64", true, 44}, {"AESGCM", false, 72}} {
+		n, err := EntryHeadSize(v.algorithm, v.mixed)
+		if err != nil || n != v.size {
+			t.Fatal("Error Entry Head Size:", v.algorithm, n, err)
+		}
+	}
+	_, err := EntryHeadSize("MIXED", false)
+	if err == nil {
+		t.Fatal("Error Entry Head Size: undefined algorithm should fail")
+	}
+}
+
+// TestParsePackageBytes function
+func TestParsePackageBytes(t *testing.T) {
+	data, err := ioutil.ReadFile("../test/data/unpack/file_aes.txt")
+	if err != nil {
+		t.Fatal("Error Read File:", err)
+	}
+	_, err = ParsePackageBytes(data[:len(data)-1])
+	if err == nil {
+		t.Fatal("Error Parse Package Bytes should reject truncated package")
+	}
+	_, err = ParsePackageBytes(data[:10])
+	if err == nil {
+		t.Fatal("Error Parse Package Bytes should reject truncated header")
+	}
+}
+
+// TestExtractMeta function
+func TestExtractMeta(t *testing.T) {
+	r, err := ExtractMeta("../test/data/unpack/file_aes.txt")
+	if err != nil {
+		t.Fatal("Error Extract Meta:", err)
+	}
+	if r.Author != "Alopex6414" || r.Verified {
+		t.Fatal("Error Extract Meta content:", r)
+	}
+}
+
+// TestUnpackRenamedLegacy function
+func TestUnpackRenamedLegacy(t *testing.T) {
+	data, _ := ioutil.ReadFile("../test/data/unpack/file_aes.txt")
+	dir := t.TempDir()
+	src := filepath.Join(dir, "file_aes (1).txt")
+	_ = ioutil.WriteFile(src, data, 0644)
+	err := UnpackAES(src, dir+"/")
+	if err == nil {
+		t.Fatal("Error Unpack AES: renamed legacy package should fail")
+	}
+	err = UnpackWithOption(src, dir+"/", UnpackOption{})
+	if err == nil {
+		t.Fatal("Error Unpack with option: renamed legacy package should fail")
+	}
+	err = UnpackWithOption(src, dir+"/", UnpackOption{IgnoreLegacyName: true})
+	if err != nil {
+		t.Fatal("Error Unpack with IgnoreLegacyName:", err)
+	}
+	r, _ := ioutil.ReadFile(filepath.Join(dir, "file_1.txt"))
+	s, _ := ioutil.ReadFile("../test/data/unpack/file_1.txt")
+	if string(r) != string(s) {
+		t.Fatal("Error Unpack AES content")
+	}
+}
@@ -0,0 +1,234 @@
+package unpack
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	. "qora/global"
+	. "qora/utils"
+)
+
+// ErrStaleParity is returned when parity file does not describe the package, like the package is edited after parity is generated
+var ErrStaleParity = errors.New("Parity file does not match package, it is stale.")
+
+// Repair function
+// This function is mainly used for repair package with its parity file 'name.qpar'.
+// damaged or missing shards of package are recovered through reed-solomon parity, and package is rewritten
+// unpack functions also repair package by themselves in memory or temporary file, the package file is not touched
+// stale parity file fails with ErrStaleParity and package is not touched, unpack functions ignore it
+// return r the repair report, err indicate the success or failure function execute
+func Repair(src string) (r *TRepairReport, err error) {
+	data, err := ioutil.ReadFile(src)
+	if err != nil && !os.IsNotExist(err) {
+		log.Println("Error read file:", err)
+		return r, err
+	}
+	par, err := ioutil.ReadFile(src + ParitySuffix)
+	if err != nil {
+		log.Println("Error read parity file:", err)
+		return r, err
+	}
+	dest, r, err := repairData(data, par)
+	if err != nil {
+		return r, err
+	}
+	if bytes.Equal(data, dest) {
+		return r, err
+	}
+	// write through temporary file, so package is never half written
+	tmp := src + ".tmp"
+	err = ioutil.WriteFile(tmp, dest, 0644)
+	if err != nil {
+		log.Println("Error write package file:", err)
+		return r, err
+	}
+	err = os.Rename(tmp, src)
+	if err != nil {
+		log.Println("Error rename package file:", err)
+		_ = os.Remove(tmp)
+	}
+	return r, err
+}
+
+// repairPackage function
+// it will repair package data in memory when parity file of src exists
+func repairPackage(src string, data []byte) (dest []byte, err error) {
+	par, err := ioutil.ReadFile(src + ParitySuffix)
+	if os.IsNotExist(err) {
+		return data, nil
+	}
+	if err != nil {
+		log.Println("Error read parity file:", err)
+		return data, err
+	}
+	dest, r, err := repairData(data, par)
+	if err == ErrStaleParity {
+		log.Println("Ignore stale parity file:", src)
+		return data, nil
+	}
+	if err != nil {
+		return data, err
+	}
+	if len(r.Repaired) > 0 {
+		log.Println("Repair package shards:", src, r.Repaired)
+	}
+	return dest, err
+}
+
+// openParity function
+// it will repair package into a temporary file when package is damaged and parity file exists
+// path is src itself if nothing is repaired, clean should be called to remove the temporary file after use
+func openParity(src string) (path string, clean func(), err error) {
+	path, clean = src, func() {}
+	if _, err := os.Stat(src + ParitySuffix); err != nil {
+		return path, clean, nil
+	}
+	data, err := ioutil.ReadFile(src)
+	if err != nil && !os.IsNotExist(err) {
+		log.Println("Error read file:", err)
+		return path, clean, err
+	}
+	dest, err := repairPackage(src, data)
+	if err != nil || bytes.Equal(data, dest) {
+		return path, clean, err
+	}
+	dir, err := ioutil.TempDir("", "qora-repair-")
+	if err != nil {
+		log.Println("Error create temporary dir:", err)
+		return path, clean, err
+	}
+	clean = func() { _ = os.RemoveAll(dir) }
+	_, name := filepath.Split(src)
+	path = filepath.Join(dir, name)
+	err = ioutil.WriteFile(path, dest, 0644)
+	if err != nil {
+		log.Println("Error write package file:", err)
+	}
+	return path, clean, err
+}
+
+// repairData function
+// it will check every shard of package data by digest, and recover damaged shards through parity
+// parity is stale when package size differs and it is not a damaged copy: package grows, no shard is intact,
+// or damaged shards are too many to repair, then ErrStaleParity is returned, so the edited package is never turned back by its old parity
+// return the repaired package data and repair report
+func repairData(data []byte, par []byte) (dest []byte, r *TRepairReport, err error) {
+	// first, read the parity file header
+	if len(par) < 40 || !bytes.HasPrefix(par, []byte(ParityMagic)) || len(par) < 40+BytesToInt(par[4:8]) {
+		err = errors.New("Parity file header is invalid.")
+		return dest, r, err
+	}
+	n := BytesToInt(par[4:8])
+	digest := sha256.Sum256(par[40 : 40+n])
+	if !bytes.Equal(digest[:], par[8:40]) {
+		err = errors.New("Parity file header is damaged.")
+		return dest, r, err
+	}
+	h := TUnpackParity{}
+	err = GobDecode(bytes.NewBuffer(par[40:40+n]), &h)
+	if err != nil {
+		return dest, r, err
+	}
+	if h.ShardSize <= 0 || h.StripeSize <= 0 || h.Percent <= 0 {
+		err = errors.New("Parity file header is invalid.")
+		return dest, r, err
+	}
+	shard := (h.Size + h.ShardSize - 1) / h.ShardSize
+	if len(h.Digests) < shard {
+		err = errors.New("Parity file header is invalid.")
+		return dest, r, err
+	}
+	r = &TRepairReport{Size: h.Size, ShardSize: h.ShardSize, Shards: shard}
+	par = par[40+n:]
+	if len(data) > h.Size || (len(data) > 0 && len(data) < h.Size && !intactShard(data, h)) {
+		return dest, r, ErrStaleParity
+	}
+	// second, repair stripe by stripe
+	var rr [][]byte
+	p := 0
+	for i := 0; i < shard; i += h.StripeSize {
+		end := i + h.StripeSize
+		if end > shard {
+			end = shard
+		}
+		m := ((end-i)*h.Percent + 99) / 100
+		// check data shards
+		var s [][]byte
+		var damaged []int
+		for k := i; k < end; k++ {
+			v := make([]byte, h.ShardSize)
+			if k*h.ShardSize < len(data) {
+				copy(v, data[k*h.ShardSize:])
+			}
+			if !checkShard(v, h.Digests[k]) {
+				v = nil
+				damaged = append(damaged, k)
+			}
+			s = append(s, v)
+		}
+		// check parity shards
+		for k := 0; k < m; k++ {
+			var v []byte
+			if (p+k+1)*h.ShardSize <= len(par) {
+				v = par[(p+k)*h.ShardSize : (p+k+1)*h.ShardSize]
+			}
+			if shard+p+k >= len(h.Digests) || v == nil || !checkShard(v, h.Digests[shard+p+k]) {
+				v = nil
+				r.Parity = append(r.Parity, p+k)
+			}
+			s = append(s, v)
+		}
+		p += m
+		if len(damaged) > 0 {
+			err = RSReconstruct(s, end-i)
+			if err != nil && len(data) != h.Size {
+				return dest, r, ErrStaleParity
+			}
+			if err != nil {
+				s := fmt.Sprintf("Package shards %v could not be repaired: %v", damaged, err)
+				err = errors.New(s)
+				return dest, r, err
+			}
+			for _, k := range damaged {
+				if !checkShard(s[k-i], h.Digests[k]) {
+					s := fmt.Sprintf("Package shard %v could not be repaired.", k)
+					err = errors.New(s)
+					return dest, r, err
+				}
+			}
+			r.Repaired = append(r.Repaired, damaged...)
+		}
+		rr = append(rr, s[:end-i]...)
+	}
+	// finally, join the data shards
+	if len(r.Repaired) == 0 && len(data) == h.Size {
+		return data, r, err
+	}
+	dest = bytes.Join(rr, []byte(""))[:h.Size]
+	return dest, r, err
+}
+
+// intactShard function
+// return whether any data shard of package matches its digest
+func intactShard(data []byte, h TUnpackParity) bool {
+	for k := 0; k*h.ShardSize < len(data); k++ {
+		v := make([]byte, h.ShardSize)
+		copy(v, data[k*h.ShardSize:])
+		if checkShard(v, h.Digests[k]) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkShard function
+// return whether shard matches its digest
+func checkShard(shard []byte, digest []byte) bool {
+	r := sha256.Sum256(shard)
+	return bytes.Equal(r[:], digest)
+}
@@ -0,0 +1,237 @@
+package unpack
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	. "qora/global"
+	. "qora/utils"
+)
+
+// Patch function
+// This function is mainly used for rebuild the new package from old package and patch which made by pack.Diff.
+// input old package path, patch path and output package path, return error info
+// every entry is rebuilt from old plain entry and delta, checked by sha256, then encrypted again by the key of new package,
+// so the output is the same as new package byte by byte, except package with rsa entries whose padding is random,
+// rsa entries are different in bytes but decrypt to the same data
+// out file is written through a temporary file, it could be the same as old
+// return err indicate the success or failure function execute
+func Patch(old string, patch string, out string) (err error) {
+	return PatchWithOption(old, patch, out, UnpackOption{})
+}
+
+// PatchWithOption function
+// it is common with function Patch, opt.TableKey is used to open the sealed old package and the patch
+// return err indicate the success or failure function execute
+func PatchWithOption(old string, patch string, out string, opt UnpackOption) (err error) {
+	// first, read the old package and open the patch
+	od, err := ioutil.ReadFile(old)
+	if err != nil {
+		log.Println("Error read file:", err)
+		return err
+	}
+	r, err := openPatch(patch, od, opt)
+	if err != nil {
+		return err
+	}
+	// second, decrypt the old entries
+	p, err := ParsePackageBytesWithOption(od, opt)
+	if err != nil {
+		log.Println("Error parse package:", err)
+		return err
+	}
+	plain := make(map[string][]byte, len(p.Entries))
+	for _, v := range p.Entries {
+		plain[v.Name], err = DecryptEntry(v)
+		if err != nil {
+			return err
+		}
+	}
+	// third, rebuild every entry
+	tp := string(bytes.SplitN(r.Head[48:56], []byte{0}, 2)[0])
+	s := [][]byte{r.Head, r.Extend}
+	random := false
+	for _, v := range r.Entries {
+		e, n, err := parseEntryHeadAt(v.Head, tp)
+		if err != nil || n != len(v.Head) {
+			err = errors.New("Patch entry header is invalid.")
+			return err
+		}
+		random = random || e.Type == "RSA"
+		base, ok := plain[v.Base]
+		if v.Base != "" && !ok {
+			s := fmt.Sprintf("Patch base entry not found in old package: %v", v.Base)
+			err = errors.New(s)
+			return err
+		}
+		data, err := applyDelta(base, v.Ctrl, v.Diff, v.Extra)
+		if err != nil {
+			return err
+		}
+		digest := sha256.Sum256(data)
+		if !bytes.Equal(digest[:], v.Digest) {
+			s := fmt.Sprintf("Patch entry digest mismatch: %v", e.Name)
+			err = errors.New(s)
+			return err
+		}
+		body, err := encryptEntry(e, data)
+		if err != nil {
+			return err
+		}
+		if !r.Sealed {
+			s = append(s, v.Head)
+		}
+		s = append(s, body)
+	}
+	data := bytes.Join(s, []byte(""))
+	digest := sha256.Sum256(data)
+	if !random && !bytes.Equal(digest[:], r.Digest) {
+		err = errors.New("Patched package digest mismatch.")
+		return err
+	}
+	// finally, replace out file through temporary file
+	tmp := out + ".tmp"
+	err = ioutil.WriteFile(tmp, data, 0644)
+	if err != nil {
+		log.Println("Error write package file:", err)
+		return err
+	}
+	err = os.Rename(tmp, out)
+	if err != nil {
+		log.Println("Error rename package file:", err)
+		_ = os.Remove(tmp)
+	}
+	return err
+}
+
+// openPatch function
+// it will open the sealed patch with the key derived from old package, then decompress and decode it
+func openPatch(patch string, old []byte, opt UnpackOption) (r TUnpackPatch, err error) {
+	data, err := ioutil.ReadFile(patch)
+	if err != nil {
+		log.Println("Error read file:", err)
+		return r, err
+	}
+	if !bytes.HasPrefix(data, []byte(PatchMagic)) {
+		err = errors.New("Invalid patch magic.")
+		return r, err
+	}
+	zdata, err := OpenBytes(patchKey(old, opt.TableKey), data[len(PatchMagic):], []byte(PatchMagic))
+	if err != nil {
+		err = errors.New("Patch does not match the old package or it is corrupted.")
+		return r, err
+	}
+	zr := flate.NewReader(bytes.NewReader(zdata))
+	defer zr.Close()
+	buf, err := ioutil.ReadAll(zr)
+	if err != nil {
+		log.Println("Error decompress patch:", err)
+		return r, err
+	}
+	err = GobDecode(bytes.NewBuffer(buf), &r)
+	if err != nil {
+		return r, err
+	}
+	if len(r.Head) != 60 {
+		err = errors.New("Patch package header is invalid.")
+	}
+	return r, err
+}
+
+// patchKey function
+// it derives the aes-256 patch key from sha256 of old package and table key
+func patchKey(old []byte, key []byte) []byte {
+	digest := sha256.Sum256(old)
+	m := hmac.New(sha256.New, append([]byte(PatchMagic), key...))
+	m.Write(digest[:])
+	return m.Sum(nil)
+}
+
+// applyDelta function
+// it rebuilds new data from old data by bsdiff style control triples
+func applyDelta(old []byte, ctrl []int, diff []byte, extra []byte) (r []byte, err error) {
+	err = errors.New("Patch delta is invalid.")
+	if len(ctrl)%3 != 0 {
+		return r, err
+	}
+	pos, dpos, xpos := 0, 0, 0
+	for i := 0; i < len(ctrl); i += 3 {
+		d, x, next := ctrl[i], ctrl[i+1], ctrl[i+2]
+		if d < 0 || x < 0 || next < 0 || pos+d > len(old) || dpos+d > len(diff) || xpos+x > len(extra) {
+			return nil, err
+		}
+		for j := 0; j < d; j++ {
+			r = append(r, old[pos+j]+diff[dpos+j])
+		}
+		r = append(r, extra[xpos:xpos+x]...)
+		pos, dpos, xpos = next, dpos+d, xpos+x
+	}
+	return r, nil
+}
+
+// encryptEntry function
+// it will encrypt plain data again by the key in entry header, chunks are the same as pack,
+// plain data is zero padded to crypt size first, which is also what pack does
+// every chunk is encrypted by EntryEncrypt which pack also calls
+func encryptEntry(e TUnpackEntry, data []byte) (body []byte, err error) {
+	if e.Type == "BASE64" {
+		for i := 0; i < len(data); i += Base64BufferSize {
+			r, _ := EntryEncrypt(e.Type, nil, nil, data[i:min(i+Base64BufferSize, len(data))])
+			body = append(body, r...)
+		}
+		return body, err
+	}
+	if e.Type == GCMType {
+		if len(data) > e.CryptSize-GCMTagSize {
+			s := fmt.Sprintf("Package entry size is invalid: %v", e.Name)
+			err = errors.New(s)
+			return body, err
+		}
+		src := make([]byte, e.CryptSize-GCMTagSize)
+		copy(src, data)
+		return EntryEncrypt(e.Type, e.Key, e.Head[0:32], src)
+	}
+	// first, find the chunk size, rsa entry is encrypted by public key of the private key in header
+	key := e.Key
+	chunk, size := AESBufferSize, AESBufferSize
+	switch e.Type {
+	case "AES":
+	case "DES", "3DES":
+		chunk, size = DESBufferSize, DESBufferSize
+	case "RSA":
+		chunk, size = RSAPacketSize, RSAUnpackSize
+		key, err = RSAPublicKeyFromPrivate(e.Key)
+	default:
+		err = errors.New("Undefined unpack algorithm.")
+	}
+	if err != nil {
+		return body, err
+	}
+	// second, pad the plain data
+	n := e.CryptSize / size
+	if e.CryptSize%size != 0 || len(data) > n*chunk {
+		s := fmt.Sprintf("Package entry size is invalid: %v", e.Name)
+		err = errors.New(s)
+		return body, err
+	}
+	src := make([]byte, n*chunk)
+	copy(src, data)
+	// finally, encrypt every chunk like pack does
+	body = make([]byte, 0, e.CryptSize)
+	for i := 0; i < n; i++ {
+		r, err := EntryEncrypt(e.Type, key, nil, src[i*chunk:(i+1)*chunk])
+		if err != nil || len(r) != size {
+			s := fmt.Sprintf("Error encrypt entry: %v", e.Name)
+			err = errors.New(s)
+			return body, err
+		}
+		body = append(body, r...)
+	}
+	return body, err
+}
@@ -57,9 +57,8 @@ func UnpackRSA(src string, dest string) (err error) {
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -68,18 +67,12 @@ func UnpackRSA(src string, dest string) (err error) {
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("RSA"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -174,9 +167,8 @@ func UnpackRSAConfine(src string, dest string) (err error) {
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -185,18 +177,12 @@ func UnpackRSAConfine(src string, dest string) (err error) {
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("RSA"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -290,9 +276,8 @@ func UnpackRSAToFile(src string, target string, dest string) (err error) {
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -301,18 +286,12 @@ func UnpackRSAToFile(src string, target string, dest string) (err error) {
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("RSA"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -410,9 +389,8 @@ func UnpackRSAToFileConfine(src string, target string, dest string) (err error)
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -421,18 +399,12 @@ func UnpackRSAToFileConfine(src string, target string, dest string) (err error)
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("RSA"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -530,9 +502,8 @@ func UnpackRSAToMemory(src string, target string, dest *[]byte) (err error) {
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -541,18 +512,12 @@ func UnpackRSAToMemory(src string, target string, dest *[]byte) (err error) {
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("RSA"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -647,9 +612,8 @@ func UnpackRSAExtractInfo(src string, dest *[]string, sz *[]int) (err error) {
 		log.Println("Error read header name:", err)
 		return err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return err
 	}
@@ -658,18 +622,12 @@ func UnpackRSAExtractInfo(src string, dest *[]string, sz *[]int) (err error) {
 		log.Println("Error read header author:", err)
 		return err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("RSA"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
@@ -758,9 +716,8 @@ func UnpackRSAWorkCalculate(src string) (work int64, err error) {
 		log.Println("Error read header name:", err)
 		return work, err
 	}
-	s := make([]byte, 32)
-	BytesCopy(&s, []byte(name))
-	if !bytes.Equal(h.Name, s) {
+	err = checkPackageName(h.Name, name, false)
+	if err != nil {
 		log.Println("Error read header name:", err)
 		return work, err
 	}
@@ -769,18 +726,12 @@ func UnpackRSAWorkCalculate(src string) (work int64, err error) {
 		log.Println("Error read header author:", err)
 		return work, err
 	}
-	s = make([]byte, 16)
-	BytesCopy(&s, []byte("Alopex6414"))
-	if !bytes.Equal(h.Author, s) {
-		log.Println("Error read header author:", err)
-		return work, err
-	}
 	_, err = rd.Read(h.Type)
 	if err != nil {
 		log.Println("Error read header type:", err)
 		return work, err
 	}
-	s = make([]byte, 8)
+	s := make([]byte, 8)
 	BytesCopy(&s, []byte("RSA"))
 	if !bytes.Equal(h.Type, s) {
 		log.Println("Error read header type:", err)
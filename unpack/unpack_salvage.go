@@ -0,0 +1,336 @@
+package unpack
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	. "qora/global"
+	. "qora/utils"
+	"strings"
+	"unicode/utf8"
+)
+
+// Salvage function
+// This function is mainly used for recover entries from truncated or corrupted package.
+// it scans the package, resynchronizes on plausible entry headers and writes every entry which could still be decrypted,
+// then a report 'salvage_report.txt' of recovered and lost entries is written into dest as well
+// v1 package, v2 package and volume parts are supported, parity file is used first when it exists
+// entry body has no digest, so damage inside one body which keeps its size could not be found here
+// src file support both absolute and relative paths, like 'C:\\file.pak' or '../test/data/file.pak'
+// dest file also support both absolute and relative paths, like 'C:\\' or '../test/data/'
+// return r the salvage report, err is only returned when nothing could be scanned or written
+func Salvage(src string, dest string) (r *TSalvageReport, err error) {
+	return SalvageWithOption(src, dest, UnpackOption{})
+}
+
+// SalvageWithOption function
+// it is common with function Salvage, opt.TableKey is used to open the sealed entry table
+// entry table of sealed package is the only place of entry headers, so nothing could be salvaged without key
+// return r the salvage report, err is only returned when nothing could be scanned or written
+func SalvageWithOption(src string, dest string, opt UnpackOption) (r *TSalvageReport, err error) {
+	// first, read the package data
+	r = &TSalvageReport{Path: src}
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		log.Println("Error read file:", err)
+		return r, err
+	}
+	data, err = repairPackage(src, data)
+	if err != nil {
+		r.Notes = append(r.Notes, fmt.Sprintf("parity repair failed: %v", err))
+	}
+	if IsVolume(data) {
+		data = salvageVolume(src, r)
+	}
+	r.Size = len(data)
+	// second, scan the entries
+	out := make(map[string][]byte)
+	salvageScan(data, opt, r, out)
+	// finally, write the entries and report
+	for _, v := range r.Recovered {
+		err = ioutil.WriteFile(dest+v, out[v], 0644)
+		if err != nil {
+			log.Println("Error write to dest file:", err)
+			return r, err
+		}
+	}
+	err = ioutil.WriteFile(dest+SalvageReportName, []byte(r.String()), 0644)
+	if err != nil {
+		log.Println("Error write salvage report:", err)
+	}
+	return r, err
+}
+
+// String function
+// return the text report
+func (r *TSalvageReport) String() string {
+	var s []string
+	s = append(s, fmt.Sprintf("package: %v", r.Path))
+	s = append(s, fmt.Sprintf("size: %v", r.Size))
+	s = append(s, fmt.Sprintf("algorithm: %v", r.Type))
+	if r.Number > 0 {
+		s = append(s, fmt.Sprintf("entries in header: %v", r.Number))
+	}
+	for _, v := range r.Notes {
+		s = append(s, fmt.Sprintf("note: %v", v))
+	}
+	for _, v := range r.Recovered {
+		s = append(s, fmt.Sprintf("recovered: %v", v))
+	}
+	for _, v := range r.Lost {
+		name := v.Name
+		if name == "" {
+			name = "<unknown>"
+		}
+		s = append(s, fmt.Sprintf("lost: %v at offset %v, %v bytes, %v", name, v.Offset, v.Size, v.Reason))
+	}
+	return strings.Join(s, "\n") + "\n"
+}
+
+// salvageVolume function
+// it will join the parts of volume set which are still readable, missing or corrupt parts are noted in report
+func salvageVolume(src string, r *TSalvageReport) (data []byte) {
+	buf, _ := ioutil.ReadFile(src)
+	h, _, err := parseVolume(buf)
+	if err != nil {
+		r.Notes = append(r.Notes, fmt.Sprintf("volume header is invalid: %v", err))
+		return data
+	}
+	var rr [][]byte
+	for i := 1; i <= BytesToInt(h.Total); i++ {
+		path := volumePath(src, i)
+		buf, err := ioutil.ReadFile(path)
+		if err == nil {
+			buf, err = repairPackage(path, buf)
+		}
+		if err != nil {
+			r.Notes = append(r.Notes, fmt.Sprintf("volume part is missing: %v", path))
+			continue
+		}
+		hh, payload, err := parseVolume(buf)
+		if err != nil || !bytes.Equal(hh.SetID, h.SetID) {
+			r.Notes = append(r.Notes, fmt.Sprintf("volume part is corrupt: %v", path))
+			if len(buf) > VolumeHeadSize {
+				rr = append(rr, buf[VolumeHeadSize:])
+			}
+			continue
+		}
+		rr = append(rr, payload)
+	}
+	return bytes.Join(rr, []byte(""))
+}
+
+// salvageScan function
+// it will read the header if it is still valid, then scan the entries
+func salvageScan(data []byte, opt UnpackOption, r *TSalvageReport, out map[string][]byte) {
+	// first, read the header
+	types := []string{"AES", "DES", "3DES", "RSA", "BASE64", GCMType}
+	offset := 0
+	if len(data) >= 60 {
+		tp := string(bytes.SplitN(data[48:56], []byte{0}, 2)[0])
+		if _, err := EntryKeySize(tp); err == nil || tp == MixedType {
+			types = []string{tp}
+			r.Number = BytesToInt(data[56:60])
+			offset = 60
+		} else {
+			r.Notes = append(r.Notes, "package header is damaged, scan with every algorithm")
+		}
+	}
+	// second, skip the header extension of v2 package
+	if offset == 60 && data[PackVersionOffset] == PackVersion2 {
+		ext := TUnpackExtend{}
+		n := 0
+		if len(data) >= 64 {
+			n = BytesToInt(data[60:64])
+		}
+		if len(data) < 64+n || GobDecode(bytes.NewBuffer(data[64:64+n]), &ext) != nil {
+			r.Notes = append(r.Notes, "package header extension is damaged")
+		} else {
+			offset = 64 + n
+		}
+		if ext.Sealed {
+			r.Type = types[0]
+			salvageSealed(data, offset, ext, opt, r, out)
+			return
+		}
+	}
+	// finally, scan with every candidate algorithm and keep the one which recovers most data
+	var best *TSalvageReport
+	var bestOut map[string][]byte
+	bestSize := -1
+	for _, v := range types {
+		rr := &TSalvageReport{Type: v}
+		o := make(map[string][]byte)
+		salvageEntries(data, offset, v, rr, o)
+		size := 0
+		for _, vv := range o {
+			size += len(vv)
+		}
+		if size > bestSize {
+			best, bestOut, bestSize = rr, o, size
+		}
+	}
+	r.Type = best.Type
+	r.Recovered = best.Recovered
+	r.Lost = best.Lost
+	for k, v := range bestOut {
+		out[k] = v
+	}
+}
+
+// salvageSealed function
+// entry headers of sealed package are kept in entry table, so bodies are located by table instead of scan
+func salvageSealed(data []byte, offset int, ext TUnpackExtend, opt UnpackOption, r *TSalvageReport, out map[string][]byte) {
+	if opt.TableKey == nil {
+		r.Notes = append(r.Notes, "package entry table is sealed, table key is needed")
+		return
+	}
+	table, err := OpenBytes(opt.TableKey, ext.Table, data[:60])
+	if err != nil {
+		r.Notes = append(r.Notes, fmt.Sprintf("package entry table could not be opened: %v", err))
+		return
+	}
+	for i := 0; i < len(table); {
+		e, n, err := parseEntryHeadAt(table[i:], r.Type)
+		if err != nil {
+			r.Notes = append(r.Notes, fmt.Sprintf("package entry table is invalid: %v", err))
+			return
+		}
+		salvageEntry(data, offset, e, r, out)
+		offset += e.CryptSize
+		i += n
+	}
+}
+
+// salvageEntries function
+// it will walk through entries, and resynchronize on the next plausible entry header when one header is broken
+func salvageEntries(data []byte, offset int, tp string, r *TSalvageReport, out map[string][]byte) {
+	for offset < len(data) {
+		if e, n, ok := plausibleEntryAt(data[offset:], tp); ok {
+			salvageEntry(data, offset+n, e, r, out)
+			offset += n + e.CryptSize
+			continue
+		}
+		next := offset + 1
+		for next < len(data) {
+			if _, _, ok := plausibleEntryAt(data[next:], tp); ok {
+				break
+			}
+			next++
+		}
+		r.Lost = append(r.Lost, TSalvageLost{Offset: offset, Size: next - offset, Reason: "no entry header found"})
+		offset = next
+	}
+}
+
+// salvageEntry function
+// it will decrypt one entry whose body starts at offset and fill the report
+func salvageEntry(data []byte, offset int, e TUnpackEntry, r *TSalvageReport, out map[string][]byte) {
+	if offset+e.CryptSize > len(data) {
+		n := len(data) - offset
+		if n < 0 {
+			n = 0
+		}
+		r.Lost = append(r.Lost, TSalvageLost{Name: e.Name, Offset: offset, Size: n, Reason: "body is truncated"})
+		return
+	}
+	if _, ok := out[e.Name]; ok {
+		r.Lost = append(r.Lost, TSalvageLost{Name: e.Name, Offset: offset, Size: e.CryptSize, Reason: "duplicate entry name"})
+		return
+	}
+	e.Body = data[offset : offset+e.CryptSize]
+	dest, err := DecryptEntry(e)
+	if err != nil {
+		r.Lost = append(r.Lost, TSalvageLost{Name: e.Name, Offset: offset, Size: e.CryptSize, Reason: err.Error()})
+		return
+	}
+	out[e.Name] = dest
+	r.Recovered = append(r.Recovered, e.Name)
+}
+
+// plausibleEntryAt function
+// it is common with function plausibleEntry, entry of mixed package is checked with its own algorithm
+// return the entry and its header size
+func plausibleEntryAt(data []byte, tp string) (e TUnpackEntry, n int, ok bool) {
+	t, head := tp, data
+	if tp == MixedType {
+		if len(data) < 40 {
+			return e, n, false
+		}
+		t = string(bytes.SplitN(data[32:40], []byte{0}, 2)[0])
+		// the longest header is rsa, and 64 bytes of base64 body are checked behind header
+		end := len(data)
+		if end > 40+1024+8+64 {
+			end = 40 + 1024 + 8 + 64
+		}
+		head = bytes.Join([][]byte{data[:32], data[40:end]}, []byte(""))
+	}
+	size, err := EntryKeySize(t)
+	if err != nil {
+		return e, n, false
+	}
+	e, ok = plausibleEntry(head, t, size)
+	if !ok {
+		return e, n, false
+	}
+	e, n, err = parseEntryHeadAt(data, tp)
+	return e, n, err == nil
+}
+
+// plausibleEntry function
+// return whether data starts with an entry header which could be written by pack
+func plausibleEntry(data []byte, tp string, size int) (e TUnpackEntry, ok bool) {
+	n, _ := EntryHeadSize(tp, false)
+	if len(data) < n {
+		return e, false
+	}
+	// entry name should be a plain file name followed by zero
+	name := data[0:32]
+	i := bytes.IndexByte(name, 0)
+	if i <= 0 || len(bytes.Trim(name[i:], "\x00")) != 0 || !utf8.Valid(name[:i]) {
+		return e, false
+	}
+	for _, v := range name[:i] {
+		if v < 0x20 || v == 0x7f {
+			return e, false
+		}
+	}
+	s := string(name[:i])
+	if s == "." || s == ".." || filepath.Base(s) != s || strings.ContainsAny(s, "/\\") {
+		return e, false
+	}
+	e = parseEntryHead(data[:n], tp, size)
+	// sizes should match the chunk size of algorithm
+	switch tp {
+	case "AES":
+		ok = e.CryptSize == (e.OriginSize+AESBufferSize-1)/AESBufferSize*AESBufferSize
+	case "DES", "3DES":
+		ok = e.CryptSize == (e.OriginSize+DESBufferSize-1)/DESBufferSize*DESBufferSize
+	case "RSA":
+		ok = e.CryptSize == (e.OriginSize+RSAPacketSize-1)/RSAPacketSize*RSAUnpackSize && bytes.HasPrefix(e.Key, []byte("-----BEGIN"))
+	case "BASE64":
+		ok = e.CryptSize > 0 && e.CryptSize%4 == 0 && isBase64(data[n:], e.CryptSize)
+	case GCMType:
+		ok = e.CryptSize >= e.OriginSize+GCMTagSize
+	}
+	return e, ok
+}
+
+// isBase64 function
+// return whether the beginning of data is base64 text
+func isBase64(data []byte, size int) bool {
+	if size > 64 {
+		size = 64
+	}
+	if len(data) < size {
+		size = len(data)
+	}
+	for _, v := range data[:size] {
+		if !(v >= 'A' && v <= 'Z' || v >= 'a' && v <= 'z' || v >= '0' && v <= '9' || v == '+' || v == '/' || v == '=') {
+			return false
+		}
+	}
+	return size > 0
+}
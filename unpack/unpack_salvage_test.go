@@ -0,0 +1,55 @@
+package unpack
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// TestSalvage function
+func TestSalvage(t *testing.T) {
+	for _, src := range []string{"../test/data/unpack/file_aes.txt", "../test/data/unpack/file_rsa.txt", "../test/data/unpack/file_base64.txt"} {
+		data, _ := ioutil.ReadFile(src)
+		p, err := ParsePackage(src)
+		if err != nil || len(p.Entries) < 3 {
+			t.Fatal("Error Parse Package:", src, err)
+		}
+		// overwrite the second entry header and cut the last entry
+		damage := append([]byte{}, data...)
+		offset := len(p.Head) + len(p.Entries[0].Head) + len(p.Entries[0].Body)
+		copy(damage[offset:offset+32], bytes.Repeat([]byte{0xff}, 32))
+		damage = damage[:len(damage)-10]
+		dir := t.TempDir()
+		path := filepath.Join(dir, "file_damage.pak")
+		_ = ioutil.WriteFile(path, damage, 0644)
+		r, err := Salvage(path, dir+"/")
+		if err != nil {
+			t.Fatal("Error Salvage:", src, err)
+		}
+		if len(r.Recovered) != len(p.Entries)-2 || r.Recovered[0] != p.Entries[0].Name {
+			t.Fatal("Error Salvage recovered:", src, r.Recovered)
+		}
+		last := r.Lost[len(r.Lost)-1]
+		if last.Name != p.Entries[len(p.Entries)-1].Name || last.Reason != "body is truncated" {
+			t.Fatal("Error Salvage lost:", src, r.Lost)
+		}
+		for _, v := range r.Recovered {
+			s, _ := ioutil.ReadFile(filepath.Join(dir, v))
+			ss, _ := ioutil.ReadFile(filepath.Join("../test/data/unpack", v))
+			if !bytes.Equal(s, ss) {
+				t.Fatal("Error Salvage content:", src, v)
+			}
+		}
+		if _, err := ioutil.ReadFile(filepath.Join(dir, "salvage_report.txt")); err != nil {
+			t.Fatal("Error Salvage report:", err)
+		}
+		// damaged package header
+		copy(damage[0:60], make([]byte, 60))
+		_ = ioutil.WriteFile(path, damage, 0644)
+		r, err = Salvage(path, dir+"/")
+		if err != nil || len(r.Recovered) != len(p.Entries)-2 || r.Type != p.Type {
+			t.Fatal("Error Salvage without header:", src, r, err)
+		}
+	}
+}
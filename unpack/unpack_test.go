@@ -861,3 +861,15 @@ func BenchmarkWorkCalculate5(b *testing.B) {
 		}
 	}
 }
+
+// TestUnpackAllToMemory function
+func TestUnpackAllToMemory(t *testing.T) {
+	src := "../test/data/unpack/file_aes.txt"
+	dest, err := UnpackAllToMemory(src)
+	if err != nil {
+		t.Fatal("Error Unpack All To Memory:", err)
+	}
+	if len(dest) == 0 {
+		t.Fatal("Error Unpack All To Memory: empty result")
+	}
+}
@@ -0,0 +1,165 @@
+package unpack
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	. "qora/global"
+	. "qora/utils"
+	"strings"
+)
+
+// VolumeError is returned when some parts of volume set are missing or corrupt
+type VolumeError struct {
+	Missing []string // path of missing parts
+	Corrupt []string // path of corrupt parts, followed by the reason
+}
+
+func (e *VolumeError) Error() string {
+	var s []string
+	if len(e.Missing) > 0 {
+		s = append(s, "missing "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Corrupt) > 0 {
+		s = append(s, "corrupt "+strings.Join(e.Corrupt, ", "))
+	}
+	return "Volume set is broken: " + strings.Join(s, "; ")
+}
+
+// IsVolume function
+// return whether data is the beginning of a volume part
+func IsVolume(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(VolumeMagic))
+}
+
+// JoinVolume function
+// This function is mainly used for join volume parts into the whole package.
+// src is any part of the set, like '../test/data/file.q01', the other parts are found beside it
+// dest is the path of joined package
+// return err indicate the success or failure function execute, it is *VolumeError when parts are missing or corrupt
+func JoinVolume(src string, dest string) (err error) {
+	data, _, err := joinVolume(src)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(dest, data, 0644)
+	if err != nil {
+		log.Println("Error write package file:", err)
+	}
+	return err
+}
+
+// openVolume function
+// it will join the volume set into a temporary package named as the original package
+// clean should be called to remove the temporary package after use
+func openVolume(src string) (path string, clean func(), err error) {
+	data, name, err := joinVolume(src)
+	if err != nil {
+		return path, clean, err
+	}
+	dir, err := ioutil.TempDir("", "qora-volume-")
+	if err != nil {
+		log.Println("Error create temporary dir:", err)
+		return path, clean, err
+	}
+	clean = func() { _ = os.RemoveAll(dir) }
+	path = filepath.Join(dir, name)
+	err = ioutil.WriteFile(path, data, 0644)
+	if err != nil {
+		log.Println("Error write package file:", err)
+		clean()
+	}
+	return path, clean, err
+}
+
+// joinVolume function
+// it will read every part of the set which src belongs to and check them
+// return the joined package data and the original package name
+func joinVolume(src string) (data []byte, name string, err error) {
+	// first, read the header of src part
+	buf, err := ioutil.ReadFile(src)
+	if err != nil {
+		log.Println("Error read file:", err)
+		return data, name, err
+	}
+	h, _, err := parseVolume(buf)
+	if err != nil {
+		return data, name, err
+	}
+	name = string(bytes.Trim(h.Name, "\x00"))
+	total := BytesToInt(h.Total)
+	// second, read and check every part
+	e := &VolumeError{}
+	var r [][]byte
+	for i := 1; i <= total; i++ {
+		path := volumePath(src, i)
+		buf, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			e.Missing = append(e.Missing, path)
+			continue
+		}
+		if err == nil {
+			buf, err = repairPackage(path, buf)
+		}
+		if err != nil {
+			e.Corrupt = append(e.Corrupt, fmt.Sprintf("%v(%v)", path, err))
+			continue
+		}
+		hh, payload, err := parseVolume(buf)
+		switch {
+		case err != nil:
+			e.Corrupt = append(e.Corrupt, fmt.Sprintf("%v(%v)", path, err))
+		case !bytes.Equal(hh.SetID, h.SetID):
+			e.Corrupt = append(e.Corrupt, fmt.Sprintf("%v(belongs to another volume set)", path))
+		case BytesToInt(hh.Seq) != i || BytesToInt(hh.Total) != total:
+			e.Corrupt = append(e.Corrupt, fmt.Sprintf("%v(sequence mismatch)", path))
+		default:
+			digest := sha256.Sum256(payload)
+			if !bytes.Equal(digest[:], hh.Digest) {
+				e.Corrupt = append(e.Corrupt, fmt.Sprintf("%v(digest mismatch)", path))
+				continue
+			}
+			r = append(r, payload)
+		}
+	}
+	if len(e.Missing) > 0 || len(e.Corrupt) > 0 {
+		err = e
+		log.Println("Error join volume:", err)
+		return data, name, err
+	}
+	data = bytes.Join(r, []byte(""))
+	return data, name, err
+}
+
+// parseVolume function
+// it will split one part into header and payload
+func parseVolume(data []byte) (h TUnpackVolume, payload []byte, err error) {
+	if len(data) < VolumeHeadSize || !IsVolume(data) {
+		err = errors.New("Volume header is invalid.")
+		return h, payload, err
+	}
+	h.Magic = data[0:4]
+	h.SetID = data[4:20]
+	h.Seq = data[20:24]
+	h.Total = data[24:28]
+	h.Size = data[28:32]
+	h.Digest = data[32:64]
+	h.Name = data[64:96]
+	payload = data[VolumeHeadSize:]
+	if len(payload) != BytesToInt(h.Size) {
+		err = errors.New("Volume payload is truncated.")
+		return h, payload, err
+	}
+	return h, payload, err
+}
+
+// volumePath function
+// return the path of part seq which is in the same set with part src
+func volumePath(src string, seq int) string {
+	return fmt.Sprintf("%v.q%02d", strings.TrimSuffix(src, filepath.Ext(src)), seq)
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	. "qora/global"
+)
+
+// EntryEncrypt function
+// it encrypts one chunk of entry body, pack and unpack.Patch both call it so the entry body is always the same
+// tp is entry algorithm, key is the entry key, AES, DES, 3DES and AESGCM take raw key, RSA takes pem public key
+// AES, DES and 3DES: chunk is padded to block size by PKCS7 and encrypted by cbc mode, the first block of key is iv
+// RSA: chunk is encrypted by PKCS1v15, it should not be longer than RSAPacketSize
+// AESGCM: chunk is sealed with zero nonce, aad is the entry name, key should never be used twice
+// BASE64: chunk is encoded by standard encoding, key is not used
+// return err indicate the success or failure function execute
+func EntryEncrypt(tp string, key, aad, src []byte) (dest []byte, err error) {
+	var block cipher.Block
+	switch tp {
+	case "AES", GCMType:
+		block, err = aes.NewCipher(key)
+	case "DES":
+		block, err = des.NewCipher(key)
+	case "3DES":
+		block, err = des.NewTripleDESCipher(key)
+	case "RSA":
+		b, _ := pem.Decode(key)
+		if b == nil {
+			err = errors.New("RSA Public Key Error")
+			return dest, err
+		}
+		pi, err := x509.ParsePKIXPublicKey(b.Bytes)
+		if err != nil {
+			return dest, err
+		}
+		pub, ok := pi.(*rsa.PublicKey)
+		if !ok {
+			err = errors.New("RSA Public Key Error")
+			return dest, err
+		}
+		return rsa.EncryptPKCS1v15(rand.Reader, pub, src)
+	case "BASE64":
+		dest = []byte(base64.StdEncoding.EncodeToString(src))
+		return dest, err
+	default:
+		err = errors.New("Undefined pack algorithm.")
+	}
+	if err != nil {
+		return dest, err
+	}
+	if tp == GCMType {
+		gcm, err := cipher.NewGCMWithTagSize(block, GCMTagSize)
+		if err != nil {
+			return dest, err
+		}
+		dest = gcm.Seal(nil, make([]byte, gcm.NonceSize()), src, aad)
+		return dest, err
+	}
+	size := block.BlockSize()
+	if len(src)%size != 0 {
+		padding := size - len(src)%size
+		src = append(src[:len(src):len(src)], bytes.Repeat([]byte{byte(padding)}, padding)...)
+	}
+	dest = make([]byte, len(src))
+	cipher.NewCBCEncrypter(block, key[:size]).CryptBlocks(dest, src)
+	return dest, err
+}
+
+// RSAPublicKeyFromPrivate function
+// it returns the pem public key of pem private key, the private key is stored in rsa entry header
+// return err indicate the success or failure function execute
+func RSAPublicKeyFromPrivate(key []byte) (pub []byte, err error) {
+	b, _ := pem.Decode(key)
+	if b == nil {
+		err = errors.New("RSA Private Key Error")
+		return pub, err
+	}
+	pri, err := x509.ParsePKCS1PrivateKey(b.Bytes)
+	if err != nil {
+		return pub, err
+	}
+	der, err := x509.MarshalPKIXPublicKey(&pri.PublicKey)
+	if err != nil {
+		return pub, err
+	}
+	pub = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	return pub, err
+}
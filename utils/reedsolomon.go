@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+)
+
+// galois field GF(2^8) with primitive polynomial x^8+x^4+x^3+x^2+1
+var (
+	gfExp [512]byte
+	gfLog [256]byte
+	gfMul [256][256]byte
+)
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		gfExp[i] = byte(x)
+		gfLog[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+	for i := 255; i < 512; i++ {
+		gfExp[i] = gfExp[i-255]
+	}
+	for a := 1; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			gfMul[a][b] = gfExp[int(gfLog[a])+int(gfLog[b])]
+		}
+	}
+}
+
+// gfInv function
+// return the multiplicative inverse of a, a should not be zero
+func gfInv(a byte) byte {
+	return gfExp[255-int(gfLog[a])]
+}
+
+// cauchyRow function
+// return the coefficient row of parity shard i, every square sub-matrix of cauchy matrix is invertible
+func cauchyRow(i int, data int) []byte {
+	r := make([]byte, data)
+	for j := 0; j < data; j++ {
+		r[j] = gfInv(byte(data+i) ^ byte(j))
+	}
+	return r
+}
+
+// mulAdd function
+// dest ^= c * src
+func mulAdd(dest []byte, src []byte, c byte) {
+	if c == 0 {
+		return
+	}
+	t := &gfMul[c]
+	for k, v := range src {
+		dest[k] ^= t[v]
+	}
+}
+
+// RSEncode function
+// input data shards with the same size and parity shard number, return parity shards
+// data shard number and parity shard number should be no more than 256 in total
+// any parity shard number of missing shards could be recovered by RSReconstruct
+func RSEncode(data [][]byte, parity int) (r [][]byte, err error) {
+	if len(data) == 0 || parity <= 0 || len(data)+parity > 256 {
+		s := fmt.Sprintf("Invalid shard number: %v data, %v parity", len(data), parity)
+		err = errors.New(s)
+		return r, err
+	}
+	size := len(data[0])
+	for _, v := range data {
+		if len(v) != size {
+			err = errors.New("Data shards should have the same size.")
+			return r, err
+		}
+	}
+	r = make([][]byte, parity)
+	for i := 0; i < parity; i++ {
+		r[i] = make([]byte, size)
+		for j, c := range cauchyRow(i, len(data)) {
+			mulAdd(r[i], data[j], c)
+		}
+	}
+	return r, err
+}
+
+// RSReconstruct function
+// input shards, data shards followed by parity shards, nil means the shard is missing or damaged, and data shard number
+// missing data shards are recovered in place, missing parity shards are left nil
+// return err when the left shards are not enough
+func RSReconstruct(shards [][]byte, data int) (err error) {
+	// first, pick data number of present shards
+	var index []int
+	size := 0
+	missing := false
+	for k, v := range shards {
+		if v == nil {
+			if k < data {
+				missing = true
+			}
+			continue
+		}
+		if len(index) < data {
+			index = append(index, k)
+			size = len(v)
+		}
+	}
+	if !missing {
+		return err
+	}
+	if len(index) < data {
+		s := fmt.Sprintf("Too many shards are missing: %v left, %v needed", len(index), data)
+		err = errors.New(s)
+		return err
+	}
+	// second, build the coefficient matrix of present shards and invert it
+	m := make([][]byte, data)
+	for k, v := range index {
+		if v < data {
+			m[k] = make([]byte, data)
+			m[k][v] = 1
+		} else {
+			m[k] = cauchyRow(v-data, data)
+		}
+	}
+	inv, err := gfInvert(m)
+	if err != nil {
+		return err
+	}
+	// finally, recover the missing data shards
+	for j := 0; j < data; j++ {
+		if shards[j] != nil {
+			continue
+		}
+		r := make([]byte, size)
+		for k, v := range index {
+			mulAdd(r, shards[v], inv[j][k])
+		}
+		shards[j] = r
+	}
+	return err
+}
+
+// gfInvert function
+// return the inverse of square matrix m through gauss-jordan elimination
+func gfInvert(m [][]byte) (r [][]byte, err error) {
+	n := len(m)
+	a := make([][]byte, n)
+	r = make([][]byte, n)
+	for i := 0; i < n; i++ {
+		a[i] = append([]byte{}, m[i]...)
+		r[i] = make([]byte, n)
+		r[i][i] = 1
+	}
+	for c := 0; c < n; c++ {
+		p := c
+		for p < n && a[p][c] == 0 {
+			p++
+		}
+		if p == n {
+			err = errors.New("Matrix is singular.")
+			return r, err
+		}
+		a[c], a[p] = a[p], a[c]
+		r[c], r[p] = r[p], r[c]
+		t := gfInv(a[c][c])
+		for k := 0; k < n; k++ {
+			a[c][k] = gfMul[t][a[c][k]]
+			r[c][k] = gfMul[t][r[c][k]]
+		}
+		for i := 0; i < n; i++ {
+			if i != c && a[i][c] != 0 {
+				f := a[i][c]
+				mulAdd(a[i], a[c], f)
+				mulAdd(r[i], r[c], f)
+			}
+		}
+	}
+	return r, err
+}
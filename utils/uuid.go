@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// NewUUID function
+// return a random uuid(version 4) in 32 hex characters without hyphen, it just fills the package header name
+func NewUUID() (r []byte, err error) {
+	b := make([]byte, 16)
+	_, err = rand.Read(b)
+	if err != nil {
+		return r, err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	r = make([]byte, 32)
+	hex.Encode(r, b)
+	return r, err
+}
+
+// IsUUID function
+// return whether b is an uuid created by NewUUID
+func IsUUID(b []byte) bool {
+	if len(b) != 32 || b[12] != '4' {
+		return false
+	}
+	for _, v := range b {
+		if (v < '0' || v > '9') && (v < 'a' || v > 'f') {
+			return false
+		}
+	}
+	return true
+}